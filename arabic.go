@@ -0,0 +1,110 @@
+package reportbro
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	arabicTatweel = 'ـ'
+	arabicLam     = 'ل'
+	arabicHamza   = 'ء'
+	// zero width joiner which forces the joining of the adjacent letters
+	arabicZeroWidthJoiner = '\u200d'
+)
+
+// arabicLetter contains the first presentation form (isolated) of an arabic letter, dual joining
+// letters have four consecutive forms (isolated, final, initial, medial), right joining letters
+// only join the previous letter and have two forms (isolated, final)
+type arabicLetter struct {
+	forms       rune
+	dualJoining bool
+}
+
+var arabicLetters = map[rune]arabicLetter{
+	'ء': {0xFE80, false}, 'آ': {0xFE81, false}, 'أ': {0xFE83, false}, 'ؤ': {0xFE85, false},
+	'إ': {0xFE87, false}, 'ئ': {0xFE89, true}, 'ا': {0xFE8D, false}, 'ب': {0xFE8F, true},
+	'ة': {0xFE93, false}, 'ت': {0xFE95, true}, 'ث': {0xFE99, true}, 'ج': {0xFE9D, true},
+	'ح': {0xFEA1, true}, 'خ': {0xFEA5, true}, 'د': {0xFEA9, false}, 'ذ': {0xFEAB, false},
+	'ر': {0xFEAD, false}, 'ز': {0xFEAF, false}, 'س': {0xFEB1, true}, 'ش': {0xFEB5, true},
+	'ص': {0xFEB9, true}, 'ض': {0xFEBD, true}, 'ط': {0xFEC1, true}, 'ظ': {0xFEC5, true},
+	'ع': {0xFEC9, true}, 'غ': {0xFECD, true}, 'ف': {0xFED1, true}, 'ق': {0xFED5, true},
+	'ك': {0xFED9, true}, 'ل': {0xFEDD, true}, 'م': {0xFEE1, true}, 'ن': {0xFEE5, true},
+	'ه': {0xFEE9, true}, 'و': {0xFEED, false}, 'ى': {0xFEEF, false}, 'ي': {0xFEF1, true},
+	'ٱ': {0xFB50, false}, 'پ': {0xFB56, true}, 'چ': {0xFB7A, true}, 'ژ': {0xFB8A, false},
+	'ک': {0xFB8E, true}, 'گ': {0xFB92, true}, 'ی': {0xFBFC, true},
+}
+
+// arabicLamAlefLigatures contains the isolated form of the ligature of lam and an alef,
+// the final form follows the isolated form
+var arabicLamAlefLigatures = map[rune]rune{
+	'آ': 0xFEF5, 'أ': 0xFEF7, 'إ': 0xFEF9, 'ا': 0xFEFB,
+}
+
+// shapeArabicText replaces arabic letters by their contextual presentation forms and
+// lam followed by alef by the lam-alef ligature. The text must be in logical order.
+func shapeArabicText(text string) string {
+	if strings.IndexFunc(text, isArabicCharacter) == -1 {
+		return text
+	}
+	runes := []rune(text)
+	// next returns the index of the next character which is not a transparent mark
+	next := func(i int) int {
+		for i++; i < len(runes) && unicode.Is(unicode.Mn, runes[i]); i++ {
+		}
+		return i
+	}
+	var shapedText strings.Builder
+	prevJoinsNext := false
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if unicode.Is(unicode.Mn, c) {
+			// marks do not interrupt the joining of letters
+			shapedText.WriteRune(c)
+			continue
+		}
+		letter, isLetter := arabicLetters[c]
+		if !isLetter {
+			shapedText.WriteRune(c)
+			prevJoinsNext = c == arabicTatweel || c == arabicZeroWidthJoiner
+			continue
+		}
+		nextIndex := next(i)
+		if c == arabicLam && nextIndex < len(runes) {
+			if ligature, ok := arabicLamAlefLigatures[runes[nextIndex]]; ok {
+				if prevJoinsNext {
+					ligature++
+				}
+				shapedText.WriteRune(ligature)
+				// marks between lam and alef are kept after the ligature
+				for _, mark := range runes[i+1 : nextIndex] {
+					shapedText.WriteRune(mark)
+				}
+				i = nextIndex
+				prevJoinsNext = false
+				continue
+			}
+		}
+		joinsNext := false
+		if letter.dualJoining && nextIndex < len(runes) {
+			nextChar := runes[nextIndex]
+			_, nextIsLetter := arabicLetters[nextChar]
+			joinsNext = (nextIsLetter && nextChar != arabicHamza) || nextChar == arabicTatweel || nextChar == arabicZeroWidthJoiner
+		}
+		form := letter.forms
+		if letter.dualJoining {
+			if prevJoinsNext && joinsNext {
+				form += 3
+			} else if joinsNext {
+				form += 2
+			} else if prevJoinsNext {
+				form++
+			}
+		} else if prevJoinsNext && c != arabicHamza {
+			form++
+		}
+		shapedText.WriteRune(form)
+		prevJoinsNext = joinsNext
+	}
+	return shapedText.String()
+}
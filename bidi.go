@@ -0,0 +1,309 @@
+package reportbro
+
+import (
+	"strings"
+	"unicode"
+)
+
+// bidiClass is the bidirectional character type of the Unicode bidirectional algorithm
+type bidiClass int
+
+const (
+	bidiL   bidiClass = iota // left-to-right
+	bidiR                    // right-to-left
+	bidiAL                   // right-to-left arabic
+	bidiEN                   // european number
+	bidiES                   // european number separator
+	bidiET                   // european number terminator
+	bidiAN                   // arabic number
+	bidiCS                   // common number separator
+	bidiNSM                  // nonspacing mark
+	bidiBN                   // boundary neutral
+	bidiB                    // paragraph separator
+	bidiS                    // segment separator
+	bidiWS                   // whitespace
+	bidiON                   // other neutrals
+)
+
+// bidiMirroredCharacters contains the characters which are replaced by their mirrored
+// counterpart in right-to-left text
+var bidiMirroredCharacters = map[rune]rune{
+	'(': ')', ')': '(', '<': '>', '>': '<', '[': ']', ']': '[', '{': '}', '}': '{',
+	'«': '»', '»': '«', '‹': '›', '›': '‹', '≤': '≥', '≥': '≤',
+}
+
+// getBidiClass returns the bidirectional type of a character, explicit embeddings and
+// overrides are not supported and are handled as boundary neutrals
+func getBidiClass(c rune) bidiClass {
+	switch {
+	case c >= '0' && c <= '9', c >= 0x06F0 && c <= 0x06F9, c == '²', c == '³', c == '¹':
+		return bidiEN
+	case c >= 0x0660 && c <= 0x0669, c == 0x066B, c == 0x066C, c >= 0x0600 && c <= 0x0605:
+		return bidiAN
+	case c == '+' || c == '-' || c == 0x2212:
+		return bidiES
+	case c == '#' || c == '%' || c == '°' || c == 0x066A || (c >= 0x2030 && c <= 0x2034) || unicode.Is(unicode.Sc, c):
+		return bidiET
+	case c == ',' || c == '.' || c == ':' || c == '/' || c == 0xA0 || c == 0x060C:
+		return bidiCS
+	case c == '\n' || c == '\r' || c == 0x2029:
+		return bidiB
+	case c == '\t':
+		return bidiS
+	case unicode.Is(unicode.Mn, c) || unicode.Is(unicode.Me, c):
+		return bidiNSM
+	case unicode.Is(unicode.Cf, c):
+		return bidiBN
+	case unicode.IsSpace(c):
+		return bidiWS
+	case isArabicCharacter(c):
+		return bidiAL
+	case (c >= 0x0590 && c <= 0x05FF) || (c >= 0x07C0 && c <= 0x085F) || (c >= 0xFB1D && c <= 0xFB4F):
+		return bidiR
+	case unicode.IsLetter(c) || unicode.IsDigit(c) || unicode.Is(unicode.Mc, c):
+		return bidiL
+	}
+	return bidiON
+}
+
+// isArabicCharacter returns true for characters of the arabic blocks including presentation forms
+func isArabicCharacter(c rune) bool {
+	return (c >= 0x0600 && c <= 0x06FF) || (c >= 0x0750 && c <= 0x077F) || (c >= 0x08A0 && c <= 0x08FF) ||
+		(c >= 0xFB50 && c <= 0xFDFF) || (c >= 0xFE70 && c <= 0xFEFF)
+}
+
+// hasRTLText returns true if the text contains right-to-left characters
+func hasRTLText(text string) bool {
+	for _, c := range text {
+		if class := getBidiClass(c); class == bidiR || class == bidiAL || class == bidiAN {
+			return true
+		}
+	}
+	return false
+}
+
+// isRTLParagraph returns the direction of a paragraph, for auto direction the paragraph
+// is right-to-left if the first strong character is right-to-left
+func isRTLParagraph(text string, textDirection TextDirection) bool {
+	if textDirection == TextDirectionLTR || textDirection == TextDirectionRTL {
+		return textDirection == TextDirectionRTL
+	}
+	for _, c := range text {
+		switch getBidiClass(c) {
+		case bidiL:
+			return false
+		case bidiR, bidiAL:
+			return true
+		}
+	}
+	return false
+}
+
+// getBidiLevels returns the embedding level of each character of a line by applying the
+// weak, neutral and implicit rules of the Unicode bidirectional algorithm
+func getBidiLevels(text []rune, rtl bool) []int {
+	paragraphLevel := 0
+	paragraphClass := bidiL
+	if rtl {
+		paragraphLevel = 1
+		paragraphClass = bidiR
+	}
+	classes := make([]bidiClass, len(text))
+	for i, c := range text {
+		classes[i] = getBidiClass(c)
+	}
+
+	// W1: nonspacing marks get the type of the previous character, boundary neutrals are ignored
+	prevClass := paragraphClass
+	for i, class := range classes {
+		if class == bidiNSM || class == bidiBN {
+			classes[i] = prevClass
+		} else {
+			prevClass = class
+		}
+	}
+	// W2, W3: european numbers after arabic letters are arabic numbers, arabic letters are right-to-left
+	lastStrong := paragraphClass
+	for i, class := range classes {
+		switch class {
+		case bidiL, bidiR:
+			lastStrong = class
+		case bidiAL:
+			lastStrong = class
+			classes[i] = bidiR
+		case bidiEN:
+			if lastStrong == bidiAL {
+				classes[i] = bidiAN
+			}
+		}
+	}
+	// W4: a single separator between two numbers of the same type gets the number type
+	for i := 1; i+1 < len(classes); i++ {
+		if classes[i] == bidiES && classes[i-1] == bidiEN && classes[i+1] == bidiEN {
+			classes[i] = bidiEN
+		} else if classes[i] == bidiCS && classes[i-1] == classes[i+1] &&
+			(classes[i-1] == bidiEN || classes[i-1] == bidiAN) {
+			classes[i] = classes[i-1]
+		}
+	}
+	// W5: terminators adjacent to european numbers are european numbers
+	for i := 0; i < len(classes); i++ {
+		if classes[i] != bidiET {
+			continue
+		}
+		end := i
+		for end < len(classes) && classes[end] == bidiET {
+			end++
+		}
+		if (i > 0 && classes[i-1] == bidiEN) || (end < len(classes) && classes[end] == bidiEN) {
+			for j := i; j < end; j++ {
+				classes[j] = bidiEN
+			}
+		}
+		i = end - 1
+	}
+	// W6, W7: remaining separators are neutral, european numbers after left-to-right text are left-to-right
+	lastStrong = paragraphClass
+	for i, class := range classes {
+		switch class {
+		case bidiES, bidiET, bidiCS:
+			classes[i] = bidiON
+		case bidiL, bidiR:
+			lastStrong = class
+		case bidiEN:
+			if lastStrong == bidiL {
+				classes[i] = bidiL
+			}
+		}
+	}
+	// N1, N2: neutrals get the direction of the surrounding text if it is the same on both sides,
+	// otherwise the paragraph direction
+	strongDirection := func(class bidiClass) bidiClass {
+		if class == bidiEN || class == bidiAN {
+			return bidiR
+		}
+		return class
+	}
+	for i := 0; i < len(classes); i++ {
+		if !isBidiNeutral(classes[i]) {
+			continue
+		}
+		end := i
+		for end < len(classes) && isBidiNeutral(classes[end]) {
+			end++
+		}
+		before := paragraphClass
+		if i > 0 {
+			before = strongDirection(classes[i-1])
+		}
+		after := paragraphClass
+		if end < len(classes) {
+			after = strongDirection(classes[end])
+		}
+		direction := paragraphClass
+		if before == after {
+			direction = before
+		}
+		for j := i; j < end; j++ {
+			classes[j] = direction
+		}
+		i = end - 1
+	}
+	// I1, I2: resolve the implicit levels
+	levels := make([]int, len(classes))
+	for i, class := range classes {
+		levels[i] = paragraphLevel
+		if paragraphLevel == 0 {
+			if class == bidiR {
+				levels[i] = 1
+			} else if class == bidiAN || class == bidiEN {
+				levels[i] = 2
+			}
+		} else if class == bidiL || class == bidiEN || class == bidiAN {
+			levels[i] = 2
+		}
+	}
+	// L1: whitespace at the end of the line is reset to the paragraph level
+	for i := len(text) - 1; i >= 0; i-- {
+		if class := getBidiClass(text[i]); class != bidiWS && class != bidiS && class != bidiB && class != bidiBN {
+			break
+		}
+		levels[i] = paragraphLevel
+	}
+	return levels
+}
+
+func isBidiNeutral(class bidiClass) bool {
+	return class == bidiB || class == bidiS || class == bidiWS || class == bidiON
+}
+
+// getBidiVisualOrder returns the indices of the items in visual order, from the highest level
+// to the lowest odd level each sequence of items at that level or higher is reversed (L2)
+func getBidiVisualOrder(levels []int) []int {
+	order := make([]int, len(levels))
+	maxLevel := 0
+	minOddLevel := -1
+	for i, level := range levels {
+		order[i] = i
+		if level > maxLevel {
+			maxLevel = level
+		}
+		if level%2 == 1 && (minOddLevel == -1 || level < minOddLevel) {
+			minOddLevel = level
+		}
+	}
+	if minOddLevel == -1 {
+		return order
+	}
+	for level := maxLevel; level >= minOddLevel; level-- {
+		for i := 0; i < len(order); i++ {
+			if levels[order[i]] < level {
+				continue
+			}
+			end := i
+			for end < len(order) && levels[order[end]] >= level {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+			}
+			i = end
+		}
+	}
+	return order
+}
+
+// getVisualText returns the text of a line in the order it is rendered from left to right,
+// mirrored characters in right-to-left runs are replaced (L4)
+func getVisualText(text string, rtl bool) string {
+	if !rtl && !hasRTLText(text) {
+		return text
+	}
+	runes := []rune(text)
+	levels := getBidiLevels(runes, rtl)
+	var visualText strings.Builder
+	for _, i := range getBidiVisualOrder(levels) {
+		c := runes[i]
+		if levels[i]%2 == 1 {
+			c = getBidiMirroredCharacter(c)
+		}
+		visualText.WriteRune(c)
+	}
+	return visualText.String()
+}
+
+// reverseBidiText returns the characters of a right-to-left run in reverse order with mirrored characters replaced
+func reverseBidiText(text string) string {
+	runes := []rune(text)
+	for a, b := 0, len(runes)-1; a <= b; a, b = a+1, b-1 {
+		runes[a], runes[b] = getBidiMirroredCharacter(runes[b]), getBidiMirroredCharacter(runes[a])
+	}
+	return string(runes)
+}
+
+func getBidiMirroredCharacter(c rune) rune {
+	if mirrored, ok := bidiMirroredCharacters[c]; ok {
+		return mirrored
+	}
+	return c
+}
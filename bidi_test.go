@@ -0,0 +1,80 @@
+package reportbro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetVisualText(t *testing.T) {
+	tests := []struct {
+		text string
+		rtl  bool
+		want string
+	}{
+		{"abc", false, "abc"},
+		{"שלום", true, "םולש"},
+		{"שלום 123", true, "123 םולש"},
+		{"abc שלום def", false, "abc םולש def"},
+		{"שלום (abc)", true, "(abc) םולש"},
+		{"abc (שלום)", false, "abc (םולש)"},
+		{"מחיר: 1,234.50 ₪", true, "₪ 1,234.50 :ריחמ"},
+	}
+	for _, test := range tests {
+		if got := getVisualText(test.text, test.rtl); got != test.want {
+			t.Errorf("getVisualText(%q, %v) = %q, want %q", test.text, test.rtl, got, test.want)
+		}
+	}
+}
+
+func TestGetBidiLevels(t *testing.T) {
+	tests := []struct {
+		text string
+		rtl  bool
+		want []int
+	}{
+		{"abc", false, []int{0, 0, 0}},
+		{"שלום 123", true, []int{1, 1, 1, 1, 1, 2, 2, 2}},
+		{"ab של", false, []int{0, 0, 0, 1, 1}},
+	}
+	for _, test := range tests {
+		if got := getBidiLevels([]rune(test.text), test.rtl); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("getBidiLevels(%q, %v) = %v, want %v", test.text, test.rtl, got, test.want)
+		}
+	}
+}
+
+func TestIsRTLParagraph(t *testing.T) {
+	tests := []struct {
+		text          string
+		textDirection TextDirection
+		want          bool
+	}{
+		{"123 abc", TextDirectionAuto, false},
+		{"123 שלום", TextDirectionAuto, true},
+		{"שלום", TextDirectionLTR, false},
+		{"abc", TextDirectionRTL, true},
+	}
+	for _, test := range tests {
+		if got := isRTLParagraph(test.text, test.textDirection); got != test.want {
+			t.Errorf("isRTLParagraph(%q, %v) = %v, want %v", test.text, test.textDirection, got, test.want)
+		}
+	}
+}
+
+func TestShapeArabicText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"abc", "abc"},
+		{"ب", "ﺏ"},
+		{"بيت", "ﺑﻴﺖ"},
+		{"لا", "ﻻ"},
+		{"سلام", "ﺳﻼﻡ"},
+	}
+	for _, test := range tests {
+		if got := shapeArabicText(test.text); got != test.want {
+			t.Errorf("shapeArabicText(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
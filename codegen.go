@@ -0,0 +1,266 @@
+package reportbro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GoCodeOptions contains the options of GenerateGoCode
+type GoCodeOptions struct {
+	// Package is the package name of the generated file
+	Package string
+	// TypeName is the name of the struct of the report data, default is ReportData
+	TypeName string
+	// FuncName is the name of the render function, default is Render
+	FuncName string
+	// EmbedPath is the path of the report definition file relative to the generated file, the file is
+	// included with go:embed. If empty the report definition is contained in the generated code.
+	EmbedPath string
+	// Source is the name of the report definition file mentioned in the header of the generated code
+	Source string
+}
+
+// commonInitialisms are written in upper case in generated field names, e.g. customer_id -> CustomerID
+var commonInitialisms = map[string]bool{
+	"api": true, "css": true, "eu": true, "html": true, "http": true, "https": true, "iban": true, "id": true,
+	"ip": true, "json": true, "pdf": true, "sku": true, "sql": true, "uid": true, "uri": true, "url": true,
+	"uuid": true, "vat": true, "xml": true,
+}
+
+// goCodeGenerator creates the Go types for the parameters of a report definition
+type goCodeGenerator struct {
+	decimalMode bool
+	imports     map[string]bool
+	types       []string
+	typeNames   map[string]bool
+}
+
+func (self *goCodeGenerator) init(reportDefinition map[string]interface{}) {
+	if documentProperties, ok := reportDefinition["documentProperties"].(map[string]interface{}); ok {
+		self.decimalMode = GetBoolValue(documentProperties, "decimalMode")
+	}
+	self.imports = make(map[string]bool)
+	self.types = make([]string, 0)
+	self.typeNames = make(map[string]bool)
+}
+
+// addStruct adds a struct type for the given parameters, the type name must be reserved with
+// getUniqueTypeName. Nested structs for array and map parameters are added after the struct.
+func (self *goCodeGenerator) addStruct(typeName string, doc string, parameters []interface{}) {
+	nestedTypes := make([]func(), 0)
+	var code strings.Builder
+	fmt.Fprintf(&code, "// %s %s\ntype %s struct {\n", typeName, doc, typeName)
+	fieldNames := make(map[string]bool)
+	for _, item := range parameters {
+		parameter := item.(Parameter)
+		if parameter.IsInternal || parameter.Eval || parameter.Type.isAggregate() || parameter.Name == "" {
+			continue
+		}
+		fieldName := getUniqueName(getGoName(parameter.Name), fieldNames)
+		var fieldType string
+		switch parameter.Type {
+		case ParameterTypeArray, ParameterTypeMap:
+			var nestedTypeName string
+			if parameter.Type == ParameterTypeArray {
+				nestedTypeName = self.getUniqueTypeName(typeName + fieldName + "Row")
+				fieldType = "[]" + nestedTypeName
+			} else {
+				nestedTypeName = self.getUniqueTypeName(typeName + fieldName)
+				fieldType = nestedTypeName
+				if parameter.Nullable {
+					fieldType = "*" + nestedTypeName
+				}
+			}
+			children, parameterName, isArray := parameter.Children, parameter.Name, parameter.Type == ParameterTypeArray
+			nestedTypes = append(nestedTypes, func() {
+				doc := fmt.Sprintf("is the data of map parameter %s", parameterName)
+				if isArray {
+					doc = fmt.Sprintf("is a row of array parameter %s", parameterName)
+				}
+				self.addStruct(nestedTypeName, doc, children)
+			})
+		case ParameterTypeSimpleArray:
+			fieldType = "[]" + self.getValueType(parameter.ArrayItemType, false)
+		default:
+			fieldType = self.getValueType(parameter.Type, parameter.Nullable)
+		}
+		fmt.Fprintf(&code, "\t%s %s `json:%s`\n", fieldName, fieldType, strconv.Quote(parameter.Name))
+	}
+	code.WriteString("}\n")
+	self.types = append(self.types, code.String())
+	for _, addNestedType := range nestedTypes {
+		addNestedType()
+	}
+}
+
+// getValueType returns the Go type of a scalar parameter, nullable values are pointers
+func (self *goCodeGenerator) getValueType(parameterType ParameterType, nullable bool) string {
+	var valueType string
+	switch parameterType {
+	case ParameterTypeString:
+		valueType = "string"
+	case ParameterTypeNumber:
+		if self.decimalMode {
+			self.imports["github.com/shopspring/decimal"] = true
+			valueType = "decimal.Decimal"
+		} else {
+			valueType = "float64"
+		}
+	case ParameterTypeBoolean:
+		valueType = "bool"
+	case ParameterTypeDate, ParameterTypeDatetime, ParameterTypeTime:
+		self.imports["time"] = true
+		valueType = "time.Time"
+	case ParameterTypeImage:
+		// nil is no image anyway
+		return "[]byte"
+	default:
+		return "interface{}"
+	}
+	if nullable {
+		return "*" + valueType
+	}
+	return valueType
+}
+
+// getUniqueTypeName returns an unused type name and reserves it
+func (self *goCodeGenerator) getUniqueTypeName(typeName string) string {
+	return getUniqueName(typeName, self.typeNames)
+}
+
+// getGoName returns the exported Go name of a parameter name, e.g. invoice_number -> InvoiceNumber.
+// Characters which are not allowed in identifiers separate the words of the name, names which do not
+// start with an upper case letter after conversion (e.g. digits or chinese characters) get the prefix X.
+func getGoName(name string) string {
+	var goName strings.Builder
+	parts := strings.FieldsFunc(name, func(c rune) bool { return !unicode.IsLetter(c) && !unicode.IsDigit(c) })
+	for _, part := range parts {
+		if commonInitialisms[strings.ToLower(part)] {
+			goName.WriteString(strings.ToUpper(part))
+		} else {
+			first, size := utf8.DecodeRuneInString(part)
+			goName.WriteRune(unicode.ToUpper(first))
+			goName.WriteString(part[size:])
+		}
+	}
+	if goName.Len() == 0 {
+		return "Field"
+	}
+	if first, _ := utf8.DecodeRuneInString(goName.String()); !unicode.IsUpper(first) {
+		return "X" + goName.String()
+	}
+	return goName.String()
+}
+
+// getUniqueName appends a number to the name in case it is already used and marks the name as used
+func getUniqueName(name string, usedNames map[string]bool) string {
+	uniqueName := name
+	for i := 2; usedNames[uniqueName]; i++ {
+		uniqueName = fmt.Sprintf("%s%d", name, i)
+	}
+	usedNames[uniqueName] = true
+	return uniqueName
+}
+
+// GenerateGoCode returns the source code of Go structs matching the parameters of the report definition and
+// a function to render the report with typed data. Array parameters are slices of structs, map parameters
+// nested structs, dates time.Time and images []byte. Computed and internal parameters are omitted.
+func GenerateGoCode(reportDefinition map[string]interface{}, options GoCodeOptions) ([]byte, error) {
+	if options.Package == "" {
+		return nil, fmt.Errorf("package name is missing")
+	}
+	if options.TypeName == "" {
+		options.TypeName = "ReportData"
+	}
+	if options.FuncName == "" {
+		options.FuncName = "Render"
+	}
+	reportParameters, ok := reportDefinition["parameters"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("report definition has no parameters")
+	}
+	parameters := make([]interface{}, 0, len(reportParameters))
+	for _, item := range reportParameters {
+		data, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter in report definition")
+		}
+		parameters = append(parameters, NewParameter(&report{}, data))
+	}
+
+	generator := goCodeGenerator{}
+	generator.init(reportDefinition)
+	typeName := generator.getUniqueTypeName(options.TypeName)
+	generator.addStruct(typeName, "is the data of the report", parameters)
+	generator.imports["encoding/json"] = true
+	generator.imports["github.com/GeorgeD19/reportbro-lib-go"] = true
+
+	definitionName := strings.ToLower(options.FuncName[:1]) + options.FuncName[1:] + "ReportDefinition"
+	var definition string
+	if options.EmbedPath != "" {
+		generator.imports["embed"] = true
+		definition = fmt.Sprintf("//go:embed %s\nvar %s []byte\n", options.EmbedPath, definitionName)
+	} else {
+		data, err := json.Marshal(reportDefinition)
+		if err != nil {
+			return nil, err
+		}
+		definition = fmt.Sprintf("var %s = []byte(%s)\n", definitionName, strconv.Quote(string(data)))
+	}
+
+	buf := &bytes.Buffer{}
+	source := ""
+	if options.Source != "" {
+		source = " from " + options.Source
+	}
+	fmt.Fprintf(buf, "// Code generated by reportbro-gen%s. DO NOT EDIT.\n\npackage %s\n\nimport (\n", source, options.Package)
+	for _, importPath := range []string{"embed", "encoding/json", "time", "github.com/GeorgeD19/reportbro-lib-go", "github.com/shopspring/decimal"} {
+		if !generator.imports[importPath] {
+			continue
+		}
+		if importPath == "github.com/GeorgeD19/reportbro-lib-go" {
+			// third party imports are separated from the standard library
+			buf.WriteString("\n")
+		}
+		if importPath == "embed" {
+			buf.WriteString("\t_ \"embed\"\n")
+		} else if importPath == "github.com/GeorgeD19/reportbro-lib-go" {
+			fmt.Fprintf(buf, "\treportbro %q\n", importPath)
+		} else {
+			fmt.Fprintf(buf, "\t%q\n", importPath)
+		}
+	}
+	buf.WriteString(")\n\n")
+	for _, code := range generator.types {
+		buf.WriteString(code)
+		buf.WriteString("\n")
+	}
+	buf.WriteString(definition)
+	fmt.Fprintf(buf, `
+// %s renders the report with the given data as PDF
+func %s(data %s) ([]byte, error) {
+	var reportDefinition map[string]interface{}
+	if err := json.Unmarshal(%s, &reportDefinition); err != nil {
+		return nil, err
+	}
+	if definition, ok := reportDefinition["report"].(map[string]interface{}); ok {
+		// file containing report definition and test data
+		reportDefinition = definition
+	}
+	report := reportbro.NewReport(reportDefinition, data, false, "", nil)
+	return report.GeneratePDF(false)
+}
+`, options.FuncName, options.FuncName, typeName, definitionName)
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code is invalid: %w", err)
+	}
+	return code, nil
+}
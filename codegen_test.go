@@ -0,0 +1,26 @@
+package reportbro
+
+import "testing"
+
+func TestGetGoName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"invoice_number", "InvoiceNumber"},
+		{"customer_id", "CustomerID"},
+		{"_total__", "Total"},
+		{"größe", "Größe"},
+		{"ärger_über", "ÄrgerÜber"},
+		{"order-no", "OrderNo"},
+		{"unit price", "UnitPrice"},
+		{"1st_line", "X1stLine"},
+		{"名前", "X名前"},
+		{"__", "Field"},
+	}
+	for _, test := range tests {
+		if got := getGoName(test.name); got != test.want {
+			t.Errorf("getGoName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -152,6 +152,11 @@ func (self *Container) createRenderElements(containerHeight float64, ctx Context
 	newPage := false
 	processedElements := make([]DocElementBaseProvider, 0)
 	completedElements := map[int]bool{}
+	// elements in original order and the elements of the render elements created on this page,
+	// needed to move elements which are kept with the next element to the next page
+	sortedElements := append([]DocElementBaseProvider{}, self.SortedElements...)
+	renderElementsStart := len(self.RenderElements)
+	renderedElements := make([]DocElementBaseProvider, 0)
 
 	self.RenderElementsCreated = false
 	self.ManualPageBreak = false
@@ -198,12 +203,27 @@ func (self *Container) createRenderElements(containerHeight float64, ctx Context
 						newPage = true
 					}
 					if newPage == false {
+						firstRenderElement := elem.base().FirstRenderElement
 						renderElem, complete = elem.getNextRenderElement(offsetY, containerHeight, ctx, pdfDoc)
+						if renderElem != nil && !complete && firstRenderElement && elem.base().KeepTogether &&
+							self.AllowPageBreak && offsetY > 0 && len(renderedElements) > 0 && elem.restart(ctx, pdfDoc) {
+							// element does not fit on this page and is kept together, it is moved to the next page
+							// unless it does not fit on an empty page either
+							_, fitsOnPage := elem.getNextRenderElement(0, containerHeight, ctx, pdfDoc)
+							elem.restart(ctx, pdfDoc)
+							if fitsOnPage {
+								renderElem = nil
+								newPage = true
+							} else {
+								renderElem, complete = elem.getNextRenderElement(offsetY, containerHeight, ctx, pdfDoc)
+							}
+						}
 						if renderElem != nil {
 							if complete {
 								processedElements = append(processedElements, elem)
 							}
 							self.RenderElements = append(self.RenderElements, renderElem)
+							renderedElements = append(renderedElements, elem)
 							self.RenderElementsCreated = true
 							if renderElem.base().RenderBottom > self.UsedBandHeight {
 								self.UsedBandHeight = renderElem.base().RenderBottom
@@ -234,6 +254,46 @@ func (self *Container) createRenderElements(containerHeight float64, ctx Context
 		}
 	}
 
+	if len(self.SortedElements) > 0 && self.AllowPageBreak && !self.ManualPageBreak {
+		for i := len(processedElements) - 1; i >= 0; i-- {
+			elem := processedElements[i]
+			if !elem.base().KeepWithNext || self.isNextElementStarted(elem, renderedElements) {
+				continue
+			}
+			// move element to the next page together with the next element, unless the page would be empty
+			remainingElements := make([]DocElementBaseProvider, 0)
+			remainingRenderElements := make([]DocElementBaseProvider, 0)
+			for j, renderedElement := range renderedElements {
+				if renderedElement != elem {
+					remainingElements = append(remainingElements, renderedElement)
+					remainingRenderElements = append(remainingRenderElements, self.RenderElements[renderElementsStart+j])
+				}
+			}
+			if len(remainingElements) == 0 || len(remainingElements) == len(renderedElements) || !elem.restart(ctx, pdfDoc) {
+				continue
+			}
+			renderedElements = remainingElements
+			self.RenderElements = append(self.RenderElements[:renderElementsStart], remainingRenderElements...)
+			processedElements = removeElement(processedElements, i)
+			delete(completedElements, elem.base().ID)
+			if NextOffsetY == nil || elem.base().Y < *NextOffsetY {
+				NextOffsetY = &elem.base().Y
+			}
+			// keep original order of elements
+			unfinishedElements := map[*DocElementBase]bool{elem.base(): true}
+			for _, sortedElement := range self.SortedElements {
+				unfinishedElements[sortedElement.base()] = true
+			}
+			self.SortedElements = make([]DocElementBaseProvider, 0)
+			for _, sortedElement := range sortedElements {
+				if unfinishedElements[sortedElement.base()] {
+					self.SortedElements = append(self.SortedElements, sortedElement)
+				}
+			}
+			self.UsedBandHeight = self.GetRenderElementsBottom()
+		}
+	}
+
 	self.FirstElementOffsetY = 0
 	if NextOffsetY != nil {
 		self.FirstElementOffsetY = *NextOffsetY
@@ -256,6 +316,26 @@ func (self *Container) createRenderElements(containerHeight float64, ctx Context
 	return (len(self.SortedElements) == 0)
 }
 
+// isNextElementStarted returns true if all successors of the element are finished or if rendering
+// of a successor was started on the current page
+func (self *Container) isNextElementStarted(elem DocElementBaseProvider, renderedElements []DocElementBaseProvider) bool {
+	for _, successor := range elem.base().Successors {
+		for _, renderedElement := range renderedElements {
+			if renderedElement.base() == successor.base() {
+				return true
+			}
+		}
+	}
+	for _, successor := range elem.base().Successors {
+		for _, sortedElement := range self.SortedElements {
+			if sortedElement.base() == successor.base() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (self *Container) renderPDF(containerOffsetX float64, containerOffsetY float64, pdfDoc *FPDFRB, cleanup bool) {
 	counter := 0
 	for _, renderElem := range self.RenderElements {
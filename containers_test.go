@@ -0,0 +1,94 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// getTestPages renders a report with the given doc elements (the content height is 800 points)
+// and returns the texts of each page. The array parameter rows contains the numbers 1 to 20.
+func getTestPages(t *testing.T, docElements ...string) [][]string {
+	t.Helper()
+	js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","marginLeft":20,"marginTop":21,"marginRight":20,"marginBottom":21},
+		"parameters":[{"id":1000,"name":"rows","type":"array","children":[{"id":1001,"name":"number","type":"number"}]}],
+		"styles":[],"docElements":[` + strings.Join(docElements, ",") + `],"version":2}`
+	var definition map[string]interface{}
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	rows := make([]interface{}, 20)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"number": i + 1}
+	}
+	report := NewReport(definition, map[string]interface{}{"rows": rows}, false, "", nil)
+	pdf, err := report.GeneratePDF(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return getPDFPageTexts(t, pdf)
+}
+
+// testTextElement returns a text element with lines named prefix1 to prefixN
+func testTextElement(id int, y float64, prefix string, lineCount int, options string) string {
+	lines := make([]string, lineCount)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%d", prefix, i+1)
+	}
+	return fmt.Sprintf(`{"id":%d,"elementType":"text","containerId":"0_content","x":0,"y":%v,"width":300,"height":20,
+		"content":%q,"fontSize":10,"lineSpacing":1%s}`, id, y, strings.Join(lines, "\n"), options)
+}
+
+// testLines returns the texts prefix<from> to prefix<to>
+func testLines(prefix string, from int, to int) []string {
+	lines := make([]string, 0)
+	for i := from; i <= to; i++ {
+		lines = append(lines, fmt.Sprintf("%s%d", prefix, i))
+	}
+	return lines
+}
+
+func TestKeepTogether(t *testing.T) {
+	// a section with a band for each row
+	section := func(bandHeight int, options string) string {
+		return fmt.Sprintf(`{"id":3,"elementType":"section","containerId":"0_content","x":0,"y":700,"width":300,"height":%d,"dataSource":"${rows}",
+			"header":false,"footer":false,"contentData":{"id":4,"height":%d,"linkedContainerId":"4"}`, bandHeight, bandHeight) + options + `},
+			{"id":5,"elementType":"text","containerId":"4","x":0,"y":0,"width":300,"height":20,"content":"s${number}","fontSize":10,"lineSpacing":1}`
+	}
+	tests := []struct {
+		name        string
+		docElements []string
+		want        [][]string
+	}{
+		{"split", []string{testTextElement(1, 0, "a", 1, ""), testTextElement(2, 700, "b", 20, "")},
+			[][]string{append(testLines("a", 1, 1), testLines("b", 1, 10)...), testLines("b", 11, 20)}},
+		{"keep together", []string{testTextElement(1, 0, "a", 1, ""), testTextElement(2, 700, "b", 20, `,"keepTogether":true`)},
+			[][]string{testLines("a", 1, 1), testLines("b", 1, 20)}},
+		{"split section", []string{testTextElement(1, 0, "a", 1, ""), section(20, "")},
+			[][]string{append(testLines("a", 1, 1), testLines("s", 1, 5)...), testLines("s", 6, 20)}},
+		{"keep together section", []string{testTextElement(1, 0, "a", 1, ""), section(20, `,"keepTogether":true`)},
+			[][]string{testLines("a", 1, 1), testLines("s", 1, 20)}},
+		{"section larger than a page", []string{testTextElement(1, 0, "a", 1, ""), section(50, `,"keepTogether":true`)},
+			[][]string{append(testLines("a", 1, 1), testLines("s", 1, 2)...), testLines("s", 3, 18), testLines("s", 19, 20)}},
+		// a block which does not fit on an empty page is split instead of being moved to the next page
+		{"larger than a page", []string{testTextElement(1, 0, "a", 1, ""), testTextElement(2, 700, "b", 100, `,"keepTogether":true`)},
+			[][]string{append(testLines("a", 1, 1), testLines("b", 1, 10)...), testLines("b", 11, 90), testLines("b", 91, 100)}},
+		{"keep with next", []string{testTextElement(1, 0, "a", 1, ""), testTextElement(2, 680, "h", 1, `,"keepWithNext":true`),
+			testTextElement(3, 700, "b", 20, `,"keepTogether":true`)},
+			[][]string{testLines("a", 1, 1), append(testLines("h", 1, 1), testLines("b", 1, 20)...)}},
+		// the next element starts on the same page
+		{"keep with split element", []string{testTextElement(1, 0, "a", 1, ""), testTextElement(2, 680, "h", 1, `,"keepWithNext":true`),
+			testTextElement(3, 700, "b", 20, "")},
+			[][]string{append(testLines("a", 1, 1), append(testLines("h", 1, 1), testLines("b", 1, 10)...)...), testLines("b", 11, 20)}},
+		// the element is not moved if it would leave the page empty
+		{"keep with next at top of page", []string{testTextElement(2, 0, "h", 1, `,"keepWithNext":true`),
+			testTextElement(3, 20, "b", 100, `,"keepTogether":true`)},
+			[][]string{append(testLines("h", 1, 1), testLines("b", 1, 78)...), testLines("b", 79, 100)}},
+	}
+	for _, test := range tests {
+		if got := getTestPages(t, test.docElements...); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: pages = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
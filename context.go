@@ -3,17 +3,19 @@ package reportbro
 import (
 	"log"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Knetic/govaluate"
 	"github.com/PaesslerAG/gval"
-	"github.com/dustin/go-humanize"
-	"github.com/jinzhu/now"
+	"github.com/shopspring/decimal"
 	"github.com/spf13/cast"
-	"github.com/vjeantet/jodaTime"
 )
 
+var regexInvalidIdentifierChars = regexp.MustCompile(`\W`)
+
 var EVAL_DEFAULT_NAMES = map[string]interface{}{
 	"True":  true,
 	"False": false,
@@ -21,19 +23,34 @@ var EVAL_DEFAULT_NAMES = map[string]interface{}{
 }
 
 type Context struct {
-	parameters            map[string]interface{}
-	Data                  map[string]interface{}
-	DataStr               string // For debugging
-	Report                *report
-	PatternLocale         string
-	PatternCurrencySymbol string
-	RootData              map[string]interface{}
+	parameters             map[string]interface{}
+	Data                   map[string]interface{}
+	DataStr                string // For debugging
+	Report                 *report
+	PatternLocale          string
+	PatternCurrencySymbol  string
+	PatternCurrency        string
+	PatternCurrencyDisplay CurrencyDisplay
+	RoundingMode           RoundingMode
+	DecimalMode            bool
+	RootData               map[string]interface{}
+	numberFormatters       map[string]*NumberFormatter
+	currency               string // currency of the element which is currently prepared
+	resolvingCurrency      bool
+	roundingMode           RoundingMode // rounding mode of the element which is currently prepared
+	// isRenderable returns true if the fonts of the element which is currently prepared have glyphs for the text
+	isRenderable func(text string) bool
 }
 
 func (self *Context) init(report report, parameters map[string]interface{}, data map[string]interface{}) {
 	self.Report = &report
 	self.PatternLocale = report.documentProperties.PatternLocale
 	self.PatternCurrencySymbol = report.documentProperties.PatternCurrencySymbol
+	self.PatternCurrency = report.documentProperties.PatternCurrency
+	self.PatternCurrencyDisplay = report.documentProperties.PatternCurrencyDisplay
+	self.RoundingMode = report.documentProperties.RoundingMode
+	self.DecimalMode = report.documentProperties.DecimalMode
+	self.numberFormatters = make(map[string]*NumberFormatter)
 	self.parameters = parameters
 	self.Data = data
 	self.Data = Merge(self.Data, EVAL_DEFAULT_NAMES)
@@ -88,7 +105,7 @@ func (self *Context) popContext() {
 	if data == nil {
 		log.Println(Error{Message: "Context.pop_context failed - no parent available"})
 	}
-	delete(self.parameters, "__parent")
+	delete(self.Data, "__parent")
 	self.Data = data.(map[string]interface{})
 }
 
@@ -173,6 +190,14 @@ func (self *Context) evaluateExpression(expr interface{}, objectID int, field st
 		if err == nil {
 			expr = parsedExpr
 		}
+		for index, value := range data {
+			if dateValue, ok := value.(time.Time); ok {
+				data[index] = getDateExpressionValue(dateValue)
+			}
+		}
+		if self.DecimalMode {
+			return self.evaluateDecimalExpression(cast.ToString(expr), data)
+		}
 		for index, value := range data {
 			if strValue, ok := value.(string); ok {
 				strValue = strings.Replace(strValue, ",", "", -1)
@@ -211,6 +236,28 @@ func (self *Context) evaluateExpression(expr interface{}, objectID int, field st
 	return true
 }
 
+// evaluateDecimalExpression evaluates an expression in decimal mode, numbers are converted
+// to decimals so arithmetic is exact. Number strings are parsed with the pattern locale and
+// quotients are rounded with the rounding mode of the element or document.
+func (self *Context) evaluateDecimalExpression(expr string, data map[string]interface{}) interface{} {
+	for index, value := range data {
+		if strValue, ok := value.(string); ok {
+			if decimalValue, err := parseLocaleDecimal(strValue, self.PatternLocale); err == nil {
+				data[index] = decimalValue
+			}
+		} else if _, ok := value.(bool); !ok && value != nil {
+			if decimalValue, ok := toDecimal(value); ok {
+				data[index] = decimalValue
+			}
+		}
+	}
+	value, err := getDecimalLanguage(self.getRoundingMode(nil)).Evaluate(expr, data)
+	if err == nil {
+		return value
+	}
+	return expr
+}
+
 // stripParameterName @static
 func stripParameterName(expr string) string {
 	if expr != "" {
@@ -243,86 +290,18 @@ func (self *Context) getFormattedValue(value interface{}, parameter Parameter, o
 	}
 	if valueType == ParameterTypeString {
 		rv = value.(string)
-	} else if valueType == ParameterTypeNumber || valueType == ParameterTypeAverage || valueType == ParameterTypeSum {
-		var usedPattern string
-		var patternHasCurrency bool
+	} else if valueType.isNumeric() {
+		usedPattern := parameter.Pattern
 		if pattern != "" {
 			usedPattern = pattern
-			patternHasCurrency = strings.Contains(pattern, "$")
-		} else {
-			usedPattern = parameter.Pattern
-			patternHasCurrency = parameter.PatternHasCurrency
-		}
-
-		if usedPattern != "" {
-			usedPattern = strings.Replace(usedPattern, " ", "", -1)
-			usedPattern = strings.Replace(usedPattern, "0", "#", -1)
-
-			if strings.Count(cast.ToString(usedPattern), ".") < 1 {
-				usedPattern += "."
-			}
-
-			runes := cast.ToString(value)
-			out := []rune(runes)
-			caught := false
-			for i := len(runes); i > 0; i-- {
-				if "," == string(runes[i-1]) {
-					if !caught {
-						caught = true
-						out[i-1] = rune('.')
-					}
-				}
-			}
-			floatVal := cast.ToFloat64(strings.Replace(string(out), ",", "", -1))
-
-			if patternHasCurrency {
-				usedPattern = strings.Replace(usedPattern, "$", "", -1)
-
-				value = humanize.FormatFloat(usedPattern, floatVal)
-				value = self.PatternCurrencySymbol + cast.ToString(value)
-			} else {
-				if usedPattern != "" {
-					value = humanize.FormatFloat(usedPattern, floatVal)
-				}
-			}
-			rv = value
-		} else {
-			usedPattern += "####."
-			runes := cast.ToString(value)
-			out := []rune(runes)
-			caught := false
-			for i := len(runes); i > 0; i-- {
-				if "," == string(runes[i-1]) {
-					if !caught {
-						caught = true
-						out[i-1] = rune('.')
-					}
-				}
-			}
-			floatVal := cast.ToFloat64(strings.Replace(string(out), ",", "", -1))
-
-			if floatVal != float64(int64(floatVal)) {
-				usedPattern += "##"
-			}
-
-			value = humanize.FormatFloat(usedPattern, floatVal)
-
-			rv = cast.ToString(value)
 		}
-	} else if valueType == ParameterTypeDate {
+		rv = self.formatNumber(value, usedPattern, &parameter, objectID)
+	} else if valueType.isDate() {
 		usedPattern := parameter.Pattern
 		if pattern != "" {
 			usedPattern = pattern
 		}
-		rv = value
-		if usedPattern != "" {
-			t, err := now.Parse(cast.ToString(value))
-			if err == nil {
-				date := jodaTime.Format(usedPattern, t)
-				value = date
-			}
-			rv = value
-		}
+		rv = self.formatDate(value, usedPattern, valueType)
 	} else {
 		rv = value
 	}
@@ -330,6 +309,109 @@ func (self *Context) getFormattedValue(value interface{}, parameter Parameter, o
 	return cast.ToString(rv)
 }
 
+// getNumberFormatter returns the (cached) number formatter for the pattern and the locale of the report
+func (self *Context) getNumberFormatter(pattern string) *NumberFormatter {
+	if self.numberFormatters == nil {
+		self.numberFormatters = make(map[string]*NumberFormatter)
+	}
+	if formatter, ok := self.numberFormatters[pattern]; ok {
+		return formatter
+	}
+	formatter := NewNumberFormatter(pattern, self.PatternLocale)
+	formatter.CurrencySymbol = self.PatternCurrencySymbol
+	formatter.CurrencyDisplay = self.PatternCurrencyDisplay
+	self.numberFormatters[pattern] = &formatter
+	return &formatter
+}
+
+// getCurrency returns the currency code set for the prepared element or the given parameter,
+// the currency of the element has precedence. A currency can also be read from data,
+// e.g. "${currency}". An empty string is returned if no currency is set.
+func (self *Context) getCurrency(parameter *Parameter, objectID int) string {
+	if self.resolvingCurrency {
+		return ""
+	}
+	currency := self.currency
+	if currency == "" && parameter != nil {
+		currency = parameter.Currency
+	}
+	if strings.Contains(currency, "${") {
+		// use copy of context to avoid recursion when the currency is read from data
+		currencyCtx := *self
+		currencyCtx.resolvingCurrency = true
+		currency = currencyCtx.fillParameters(currency, objectID, "currency", "")
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency != "" && !isCurrencyCode(currency) {
+		log.Println(Error{Message: "errorMsgInvalidCurrency", ObjectID: objectID, Field: "currency", Info: currency})
+		return ""
+	}
+	return currency
+}
+
+// formatDate formats a date value with the given pattern and the month and weekday names of
+// the report locale, a default pattern depending on the parameter type is used if no pattern is set
+func (self *Context) formatDate(value interface{}, pattern string, parameterType ParameterType) string {
+	t, ok := value.(time.Time)
+	if !ok {
+		var err error
+		if t, err = parseDateValue(value, parameterType, self.Report.documentProperties.TimeZone); err != nil {
+			return cast.ToString(value)
+		}
+	}
+	if pattern == "" {
+		pattern = getDefaultDatePattern(t, parameterType)
+	}
+	return formatDate(t, pattern, self.PatternLocale)
+}
+
+// getRoundingMode returns the rounding mode used to format numbers, the rounding mode of the
+// prepared element has precedence over the rounding mode of the parameter and the document.
+func (self *Context) getRoundingMode(parameter *Parameter) RoundingMode {
+	if self.roundingMode != 0 {
+		return self.roundingMode
+	}
+	if parameter != nil && parameter.RoundingMode != 0 {
+		return parameter.RoundingMode
+	}
+	return self.RoundingMode
+}
+
+// formatNumber formats a number value with the given pattern. Without pattern the number
+// is formatted without grouping and with two fraction digits if it is not an integer.
+// If a currency is set for the element or parameter and the pattern does not contain a currency sign
+// the standard currency pattern of the locale is used, otherwise the currency of the document is used
+// for currency patterns. Numbers are formatted from their exact decimal representation.
+func (self *Context) formatNumber(value interface{}, pattern string, parameter *Parameter, objectID int) string {
+	var number decimal.Decimal
+	if s, ok := value.(string); ok {
+		var err error
+		if number, err = parseLocaleDecimal(s, self.PatternLocale); err != nil {
+			return s
+		}
+	} else if number, ok = toDecimal(value); !ok {
+		return cast.ToString(value)
+	}
+	currency := self.getCurrency(parameter, objectID)
+	if currency != "" && !strings.ContainsAny(pattern, "¤$") {
+		pattern = getLocaleCurrencyPattern(self.PatternLocale)
+	} else if currency == "" {
+		currency = self.PatternCurrency
+	}
+	if pattern == "" {
+		if !number.Equal(number.Truncate(0)) {
+			pattern = "0.00"
+		} else {
+			pattern = "0"
+		}
+	}
+	formatter := *self.getNumberFormatter(pattern)
+	formatter.Currency = currency
+	formatter.RoundingMode = self.getRoundingMode(parameter)
+	formatter.isRenderable = self.isRenderable
+	return formatter.FormatDecimal(number.String())
+}
+
 func (self *Context) replaceParameters(expr string, data *map[string]interface{}) interface{} {
 	var value interface{}
 	pos := pyFind(expr, "${", 0, 0)
@@ -356,8 +438,9 @@ func (self *Context) replaceParameters(expr string, data *map[string]interface{}
 					} else {
 						value = nil
 					}
-					// use valid python identifier for parameter name
-					parameterName = collectionName + "_" + fieldName
+					// use valid python identifier for parameter name, field names of group
+					// variables can contain brackets, e.g. group.sum(amount)
+					parameterName = regexInvalidIdentifierChars.ReplaceAllString(collectionName+"_"+fieldName, "_")
 				} else {
 					value, _ = self.getData(parameterName, nil)
 				}
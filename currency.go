@@ -0,0 +1,215 @@
+package reportbro
+
+import (
+	"strings"
+)
+
+// currencyInfo contains the display data of an ISO 4217 currency
+type currencyInfo struct {
+	// Symbol is the symbol used in locales of other territories (e.g. "CA$" for CAD)
+	Symbol string
+	// LocalSymbol is the symbol used in locales of territories using the currency (e.g. "$" for CAD in en_CA)
+	LocalSymbol string
+	// Digits is the default number of fraction digits
+	Digits int
+}
+
+var currencies = map[string]currencyInfo{
+	"AED": {"AED", "د.إ.", 2},
+	"ARS": {"ARS", "$", 2},
+	"AUD": {"A$", "$", 2},
+	"BGN": {"BGN", "лв.", 2},
+	"BHD": {"BHD", "د.ب.", 3},
+	"BRL": {"R$", "R$", 2},
+	"CAD": {"CA$", "$", 2},
+	"CHF": {"CHF", "CHF", 2},
+	"CLP": {"CLP", "$", 0},
+	"CNY": {"CN¥", "¥", 2},
+	"COP": {"COP", "$", 2},
+	"CZK": {"CZK", "Kč", 2},
+	"DKK": {"DKK", "kr.", 2},
+	"EUR": {"€", "€", 2},
+	"GBP": {"£", "£", 2},
+	"HKD": {"HK$", "HK$", 2},
+	"HUF": {"HUF", "Ft", 2},
+	"IDR": {"IDR", "Rp", 2},
+	"ILS": {"₪", "₪", 2},
+	"INR": {"₹", "₹", 2},
+	"ISK": {"ISK", "kr", 0},
+	"JOD": {"JOD", "د.أ.", 3},
+	"JPY": {"¥", "¥", 0},
+	"KRW": {"₩", "₩", 0},
+	"KWD": {"KWD", "د.ك.", 3},
+	"MXN": {"MX$", "$", 2},
+	"MYR": {"MYR", "RM", 2},
+	"NOK": {"NOK", "kr", 2},
+	"NZD": {"NZ$", "$", 2},
+	"OMR": {"OMR", "ر.ع.", 3},
+	"PHP": {"₱", "₱", 2},
+	"PLN": {"PLN", "zł", 2},
+	"RON": {"RON", "RON", 2},
+	"RUB": {"RUB", "₽", 2},
+	"SAR": {"SAR", "ر.س.", 2},
+	"SEK": {"SEK", "kr", 2},
+	"SGD": {"SGD", "$", 2},
+	"THB": {"THB", "฿", 2},
+	"TND": {"TND", "د.ت.", 3},
+	"TRY": {"TRY", "₺", 2},
+	"TWD": {"NT$", "$", 2},
+	"UAH": {"UAH", "₴", 2},
+	"USD": {"$", "$", 2},
+	"VND": {"₫", "₫", 0},
+	"ZAR": {"ZAR", "R", 2},
+}
+
+// territoryCurrencies maps a territory to the currency used there
+var territoryCurrencies = map[string]string{
+	"ae": "AED", "ar": "ARS", "at": "EUR", "au": "AUD", "be": "EUR", "bg": "BGN", "bh": "BHD", "br": "BRL",
+	"ca": "CAD", "ch": "CHF", "cl": "CLP", "cn": "CNY", "co": "COP", "cz": "CZK", "de": "EUR", "dk": "DKK",
+	"ee": "EUR", "es": "EUR", "fi": "EUR", "fr": "EUR", "gb": "GBP", "gr": "EUR", "hk": "HKD", "hr": "EUR",
+	"hu": "HUF", "id": "IDR", "ie": "EUR", "il": "ILS", "in": "INR", "is": "ISK", "it": "EUR", "jo": "JOD",
+	"jp": "JPY", "kr": "KRW", "kw": "KWD", "li": "CHF", "lt": "EUR", "lu": "EUR", "lv": "EUR", "mx": "MXN",
+	"my": "MYR", "nl": "EUR", "no": "NOK", "nz": "NZD", "om": "OMR", "ph": "PHP", "pl": "PLN", "pt": "EUR",
+	"ro": "RON", "ru": "RUB", "sa": "SAR", "se": "SEK", "sg": "SGD", "si": "EUR", "sk": "EUR", "th": "THB",
+	"tn": "TND", "tr": "TRY", "tw": "TWD", "ua": "UAH", "us": "USD", "vn": "VND", "za": "ZAR",
+}
+
+// languageTerritories maps a language to its default territory, used for locales without territory
+var languageTerritories = map[string]string{
+	"bg": "bg", "ca": "es", "cs": "cz", "da": "dk", "de": "de", "el": "gr", "en": "us", "es": "es",
+	"et": "ee", "eu": "es", "fi": "fi", "fil": "ph", "fr": "fr", "ga": "ie", "gl": "es", "he": "il",
+	"hi": "in", "hr": "hr", "hu": "hu", "id": "id", "is": "is", "it": "it", "ja": "jp", "ko": "kr",
+	"lt": "lt", "lv": "lv", "mk": "mk", "ms": "my", "mt": "mt", "nb": "no", "nl": "nl", "nn": "no",
+	"no": "no", "pl": "pl", "pt": "br", "ro": "ro", "ru": "ru", "sk": "sk", "sl": "si", "sr": "rs",
+	"sv": "se", "th": "th", "tr": "tr", "uk": "ua", "vi": "vn", "zh": "cn",
+}
+
+// localeCurrencyPatterns contains the standard currency pattern of a locale, "¤" is replaced
+// by the currency symbol (or code) and the fraction digits are defined by the currency
+var localeCurrencyPatterns = map[string]string{
+	"en":    "¤#,##0.00",
+	"en_in": "¤#,##,##0.00",
+	"en_za": "¤#,##0.00",
+	"en_ch": "¤ #,##0.00;¤-#,##0.00",
+	"de":    "#,##0.00 ¤",
+	"de_at": "¤ #,##0.00",
+	"de_ch": "¤ #,##0.00;¤-#,##0.00",
+	"de_li": "¤ #,##0.00",
+	"fr":    "#,##0.00 ¤",
+	"it":    "#,##0.00 ¤",
+	"it_ch": "¤ #,##0.00;¤-#,##0.00",
+	"es":    "#,##0.00 ¤",
+	"es_mx": "¤#,##0.00",
+	"es_us": "¤#,##0.00",
+	"es_ar": "¤ #,##0.00",
+	"es_co": "¤ #,##0.00",
+	"es_cl": "¤#,##0.00;¤-#,##0.00",
+	"pt":    "¤ #,##0.00",
+	"pt_pt": "#,##0.00 ¤",
+	"nl":    "¤ #,##0.00;¤ -#,##0.00",
+	"da":    "#,##0.00 ¤",
+	"sv":    "#,##0.00 ¤",
+	"nb":    "¤ #,##0.00",
+	"nn":    "#,##0.00 ¤",
+	"no":    "¤ #,##0.00",
+	"fi":    "#,##0.00 ¤",
+	"is":    "#,##0.00 ¤",
+	"pl":    "#,##0.00 ¤",
+	"cs":    "#,##0.00 ¤",
+	"sk":    "#,##0.00 ¤",
+	"sl":    "#,##0.00 ¤",
+	"hr":    "#,##0.00 ¤",
+	"bs":    "#,##0.00 ¤",
+	"sr":    "#,##0.00 ¤",
+	"mk":    "#,##0.00 ¤",
+	"hu":    "#,##0.00 ¤",
+	"ro":    "#,##0.00 ¤",
+	"bg":    "#,##0.00 ¤",
+	"ru":    "#,##0.00 ¤",
+	"uk":    "#,##0.00 ¤",
+	"be":    "#,##0.00 ¤",
+	"lt":    "#,##0.00 ¤",
+	"lv":    "#,##0.00 ¤",
+	"et":    "#,##0.00 ¤",
+	"el":    "#,##0.00 ¤",
+	"tr":    "¤#,##0.00",
+	"ca":    "#,##0.00 ¤",
+	"gl":    "#,##0.00 ¤",
+	"eu":    "#,##0.00 ¤",
+	"id":    "¤#,##0.00",
+	"vi":    "#,##0.00 ¤",
+	"ms":    "¤#,##0.00",
+	"fil":   "¤#,##0.00",
+	"he":    "#,##0.00 ¤",
+	"hi":    "¤#,##,##0.00",
+	"th":    "¤#,##0.00",
+	"ja":    "¤#,##0.00",
+	"ko":    "¤#,##0.00",
+	"zh":    "¤#,##0.00",
+}
+
+// getLocaleTerritory returns the (lowercase) territory of a locale, for locales without
+// territory the default territory of the language is returned
+func getLocaleTerritory(locale string) string {
+	locale = normalizeLocale(locale)
+	if pos := strings.LastIndex(locale, "_"); pos != -1 {
+		return locale[pos+1:]
+	}
+	return languageTerritories[locale]
+}
+
+// getLocaleCurrency returns the ISO 4217 code of the currency used in the territory of the locale
+func getLocaleCurrency(locale string) string {
+	if currency, ok := territoryCurrencies[getLocaleTerritory(locale)]; ok {
+		return currency
+	}
+	return "USD"
+}
+
+// getLocaleCurrencyPattern returns the standard currency pattern of the locale
+func getLocaleCurrencyPattern(locale string) string {
+	locale = normalizeLocale(locale)
+	if pattern, ok := localeCurrencyPatterns[locale]; ok {
+		return pattern
+	}
+	if pos := strings.Index(locale, "_"); pos != -1 {
+		if pattern, ok := localeCurrencyPatterns[locale[:pos]]; ok {
+			return pattern
+		}
+	}
+	return localeCurrencyPatterns[defaultNumberLocale]
+}
+
+// getCurrencySymbol returns the symbol of the currency for the given locale,
+// the currency code is returned for unknown currencies
+func getCurrencySymbol(currency string, locale string) string {
+	info, ok := currencies[currency]
+	if !ok {
+		return currency
+	}
+	if territoryCurrencies[getLocaleTerritory(locale)] == currency {
+		return info.LocalSymbol
+	}
+	return info.Symbol
+}
+
+// getCurrencyDigits returns the default number of fraction digits of the currency
+func getCurrencyDigits(currency string) int {
+	if info, ok := currencies[currency]; ok {
+		return info.Digits
+	}
+	return 2
+}
+
+// isCurrencyCode returns true if the value is formally a valid ISO 4217 code (three uppercase letters)
+func isCurrencyCode(value string) bool {
+	if len(value) != 3 {
+		return false
+	}
+	for _, ch := range value {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,260 @@
+package reportbro
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions contains the options to read CSV data with ReadCSV
+type CSVOptions struct {
+	// Delimiter separates the fields, default is ','
+	Delimiter rune
+	// DecimalComma must be set if numbers use a comma as decimal separator (and dot as group separator), e.g. 1.234,56
+	DecimalComma bool
+	// Header maps column names of the header row to field names of the array parameter, columns
+	// not contained are matched to fields with the same name (case insensitive)
+	Header map[string]string
+	// Columns contains the field names of the columns in case the CSV data has no header row
+	Columns []string
+}
+
+// DataImportError is returned by ReadCSV and ReadJSONLines in case a value cannot be converted
+// to the type of its field
+type DataImportError struct {
+	Line   int
+	Column int
+	Field  string
+	Value  string
+	Err    error
+}
+
+func (self *DataImportError) Error() string {
+	if self.Column > 0 {
+		return fmt.Sprintf("line %d, column %d (%s): %v", self.Line, self.Column, self.Field, self.Err)
+	} else if self.Field == "" {
+		return fmt.Sprintf("line %d: %v", self.Line, self.Err)
+	}
+	return fmt.Sprintf("line %d, field %s: %v", self.Line, self.Field, self.Err)
+}
+
+func (self *DataImportError) Unwrap() error {
+	return self.Err
+}
+
+// dataImporter converts imported values to the types of the fields of an array parameter
+type dataImporter struct {
+	fields      map[string]interface{}
+	fieldNames  map[string]string
+	decimalMode bool
+	locale      string
+}
+
+func (self *dataImporter) init(reportDefinition map[string]interface{}, parameterName string, decimalComma bool) error {
+	var parameter *Parameter
+	if parameters, ok := reportDefinition["parameters"].([]interface{}); ok {
+		for _, item := range parameters {
+			if data, ok := item.(map[string]interface{}); ok && GetStringValue(data, "name") == parameterName {
+				param := NewParameter(&report{}, data)
+				parameter = &param
+				break
+			}
+		}
+	}
+	if parameter == nil {
+		return fmt.Errorf("parameter %s does not exist", parameterName)
+	}
+	if parameter.Type != ParameterTypeArray {
+		return fmt.Errorf("parameter %s is not an array", parameterName)
+	}
+	self.fields = parameter.Fields
+	self.fieldNames = make(map[string]string, len(parameter.Fields))
+	for name := range parameter.Fields {
+		self.fieldNames[strings.ToLower(name)] = name
+	}
+	if documentProperties, ok := reportDefinition["documentProperties"].(map[string]interface{}); ok {
+		self.decimalMode = GetBoolValue(documentProperties, "decimalMode")
+	}
+	self.locale = "en"
+	if decimalComma {
+		self.locale = "de"
+	}
+	return nil
+}
+
+// getFieldName returns the name of the field for a column or key of the imported data
+func (self *dataImporter) getFieldName(name string) string {
+	if _, ok := self.fields[name]; ok {
+		return name
+	}
+	if fieldName, ok := self.fieldNames[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return fieldName
+	}
+	return name
+}
+
+// convert converts a string value to the type of the field, empty values are converted to nil.
+// Values of fields which do not exist or are not a string, number, boolean or date are not converted.
+func (self *dataImporter) convert(fieldName string, value string) (interface{}, error) {
+	field, ok := self.fields[fieldName].(Parameter)
+	if !ok {
+		return value, nil
+	}
+	if strings.TrimSpace(value) == "" && field.Type != ParameterTypeString {
+		return nil, nil
+	}
+	switch {
+	case field.Type == ParameterTypeNumber:
+		number, err := parseLocaleDecimal(value, self.locale)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", value)
+		}
+		if self.decimalMode {
+			return number, nil
+		}
+		return number.InexactFloat64(), nil
+	case field.Type == ParameterTypeBoolean:
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "1", "t", "true", "y", "yes":
+			return true, nil
+		case "0", "f", "false", "n", "no":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", value)
+	case field.Type.isDate():
+		if _, err := parseDateValue(value, field.Type, nil); err != nil {
+			return nil, fmt.Errorf("invalid %s %q", field.Type, value)
+		}
+		// the date is kept as string so it is parsed with the time zone of the report
+		return strings.TrimSpace(value), nil
+	}
+	return value, nil
+}
+
+func newDataImporter(reportDefinition map[string]interface{}, parameterName string, decimalComma bool) (*dataImporter, error) {
+	dataImporter := dataImporter{}
+	if err := dataImporter.init(reportDefinition, parameterName, decimalComma); err != nil {
+		return nil, err
+	}
+	return &dataImporter, nil
+}
+
+// ReadCSV reads the rows of the array parameter with the given name from CSV data. The columns are mapped
+// to the fields of the parameter by the header row (or options.Columns) and converted to the field types.
+// The result can be used as data of the parameter, a *DataImportError is returned for invalid values.
+func ReadCSV(reader io.Reader, reportDefinition map[string]interface{}, parameterName string, options CSVOptions) ([]interface{}, error) {
+	importer, err := newDataImporter(reportDefinition, parameterName, options.DecimalComma)
+	if err != nil {
+		return nil, err
+	}
+	csvReader := csv.NewReader(reader)
+	if options.Delimiter != 0 {
+		csvReader.Comma = options.Delimiter
+	}
+
+	columns := options.Columns
+	if len(columns) == 0 {
+		header, err := csvReader.Read()
+		if err == io.EOF {
+			return make([]interface{}, 0), nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(header) > 0 {
+			// remove byte order mark of files saved with excel
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+		columns = make([]string, len(header))
+		for i, name := range header {
+			if fieldName, ok := options.Header[name]; ok {
+				columns[i] = fieldName
+			} else {
+				columns[i] = name
+			}
+		}
+	}
+	fieldNames := make([]string, len(columns))
+	for i, name := range columns {
+		fieldNames[i] = importer.getFieldName(name)
+	}
+
+	rows := make([]interface{}, 0)
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(record))
+		for i, value := range record {
+			if i >= len(fieldNames) {
+				break
+			}
+			if row[fieldNames[i]], err = importer.convert(fieldNames[i], value); err != nil {
+				line, _ := csvReader.FieldPos(i)
+				return nil, &DataImportError{Line: line, Column: i + 1, Field: fieldNames[i], Value: value, Err: err}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ReadJSONLines reads the rows of the array parameter with the given name from JSON Lines data, i.e. one
+// JSON object per line. Keys are mapped to the fields of the parameter by name and values are converted
+// to the field types. A *DataImportError is returned for invalid values.
+func ReadJSONLines(reader io.Reader, reportDefinition map[string]interface{}, parameterName string) ([]interface{}, error) {
+	importer, err := newDataImporter(reportDefinition, parameterName, false)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	rows := make([]interface{}, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var item map[string]interface{}
+		if err := decoder.Decode(&item); err != nil {
+			return nil, &DataImportError{Line: line, Err: err}
+		}
+		row := make(map[string]interface{}, len(item))
+		for key, value := range item {
+			fieldName := importer.getFieldName(key)
+			var converted interface{}
+			var err error
+			switch v := value.(type) {
+			case string:
+				converted, err = importer.convert(fieldName, v)
+			case json.Number:
+				converted, err = importer.convert(fieldName, v.String())
+			case bool:
+				converted, err = importer.convert(fieldName, strconv.FormatBool(v))
+			default:
+				// null, objects and lists are used unchanged
+				converted = value
+			}
+			if err != nil {
+				return nil, &DataImportError{Line: line, Field: fieldName, Value: fmt.Sprint(value), Err: err}
+			}
+			row[fieldName] = converted
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
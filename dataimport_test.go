@@ -0,0 +1,139 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func newDataImportDefinition(t *testing.T, decimalMode bool) map[string]interface{} {
+	t.Helper()
+	var definition map[string]interface{}
+	js := `{"documentProperties":{"decimalMode":` + map[bool]string{false: "false", true: "true"}[decimalMode] + `},
+		"parameters":[{"id":1,"name":"items","type":"array","children":[
+			{"id":2,"name":"name","type":"string"},
+			{"id":3,"name":"amount","type":"number"},
+			{"id":4,"name":"paid","type":"boolean"},
+			{"id":5,"name":"date","type":"date"}]},
+		{"id":6,"name":"title","type":"string"}]}`
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	return definition
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		options CSVOptions
+		want    []interface{}
+	}{
+		{"header", "Name,AMOUNT,paid,date\nA,\"1,234.5\",yes,2024-03-05\n", CSVOptions{},
+			[]interface{}{map[string]interface{}{"name": "A", "amount": 1234.5, "paid": true, "date": "2024-03-05"}}},
+		{"header mapping and byte order mark", "\ufeffKunde;Betrag;bezahlt\nB;12;0\n", CSVOptions{Delimiter: ';', Header: map[string]string{"Kunde": "name", "Betrag": "amount", "bezahlt": "Paid"}},
+			[]interface{}{map[string]interface{}{"name": "B", "amount": 12.0, "paid": false}}},
+		{"decimal comma", "name;amount\nC;1.234,5\nD;-0,25\n", CSVOptions{Delimiter: ';', DecimalComma: true},
+			[]interface{}{map[string]interface{}{"name": "C", "amount": 1234.5}, map[string]interface{}{"name": "D", "amount": -0.25}}},
+		{"columns without header", "E,7,f\n", CSVOptions{Columns: []string{"name", "amount", "paid"}},
+			[]interface{}{map[string]interface{}{"name": "E", "amount": 7.0, "paid": false}}},
+		{"empty cells", "name,amount,paid,date\n,,,\n", CSVOptions{},
+			[]interface{}{map[string]interface{}{"name": "", "amount": nil, "paid": nil, "date": nil}}},
+		{"unknown column", "name,extra\nF,x\n", CSVOptions{},
+			[]interface{}{map[string]interface{}{"name": "F", "extra": "x"}}},
+		{"no rows", "", CSVOptions{}, []interface{}{}},
+	}
+	for _, test := range tests {
+		got, err := ReadCSV(strings.NewReader(test.csv), newDataImportDefinition(t, false), "items", test.options)
+		if err != nil {
+			t.Errorf("%s: ReadCSV() error %v", test.name, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: ReadCSV() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReadCSVDecimalMode(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader("amount\n0.1\n"), newDataImportDefinition(t, true), "items", CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount, ok := rows[0].(map[string]interface{})["amount"].(decimal.Decimal); !ok || amount.String() != "0.1" {
+		t.Errorf("amount = %#v, want decimal 0.1", rows[0].(map[string]interface{})["amount"])
+	}
+}
+
+func TestReadCSVInvalidValue(t *testing.T) {
+	tests := []struct {
+		csv     string
+		options CSVOptions
+		line    int
+		column  int
+		field   string
+	}{
+		{"name,amount\nA,1\nB,abc\n", CSVOptions{}, 3, 2, "amount"},
+		// a comma is no decimal separator in english numbers
+		{"name,amount\nA,\"1,5\"\n", CSVOptions{}, 2, 2, "amount"},
+		{"name;amount\nA;1.5,0\n", CSVOptions{Delimiter: ';', DecimalComma: true}, 2, 2, "amount"},
+		{"name,paid\nA,maybe\n", CSVOptions{}, 2, 2, "paid"},
+		{"date,name\n2024-13-45,A\n", CSVOptions{}, 2, 1, "date"},
+	}
+	for _, test := range tests {
+		_, err := ReadCSV(strings.NewReader(test.csv), newDataImportDefinition(t, false), "items", test.options)
+		var importError *DataImportError
+		if !errors.As(err, &importError) {
+			t.Errorf("ReadCSV(%q) error = %v, want DataImportError", test.csv, err)
+			continue
+		}
+		if importError.Line != test.line || importError.Column != test.column || importError.Field != test.field {
+			t.Errorf("ReadCSV(%q) error at line %d, column %d (%s), want line %d, column %d (%s)", test.csv,
+				importError.Line, importError.Column, importError.Field, test.line, test.column, test.field)
+		}
+	}
+}
+
+func TestReadCSVInvalidParameter(t *testing.T) {
+	for _, name := range []string{"missing", "title"} {
+		if _, err := ReadCSV(strings.NewReader("a\n1\n"), newDataImportDefinition(t, false), name, CSVOptions{}); err == nil {
+			t.Errorf("ReadCSV(%s) error = nil, want error", name)
+		}
+	}
+}
+
+func TestReadJSONLines(t *testing.T) {
+	data := `{"Name":"A","amount":12.5,"paid":true,"date":"2024-03-05"}
+
+{"name":"B","amount":"1,234.5","paid":"no","date":null,"tags":["x"]}
+`
+	got, err := ReadJSONLines(strings.NewReader(data), newDataImportDefinition(t, false), "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"name": "A", "amount": 12.5, "paid": true, "date": "2024-03-05"},
+		map[string]interface{}{"name": "B", "amount": 1234.5, "paid": false, "date": nil, "tags": []interface{}{"x"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadJSONLines() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		data  string
+		line  int
+		field string
+	}{
+		{"{\"amount\":1}\n{\"amount\":\"1,5\"}\n", 2, "amount"},
+		{"{\"name\":\"A\"}\n\n{\"name\":\n", 3, ""},
+	}
+	for _, test := range tests {
+		_, err := ReadJSONLines(strings.NewReader(test.data), newDataImportDefinition(t, false), "items")
+		var importError *DataImportError
+		if !errors.As(err, &importError) || importError.Line != test.line || importError.Field != test.field {
+			t.Errorf("ReadJSONLines(%q) error = %v, want error at line %d (%s)", test.data, err, test.line, test.field)
+		}
+	}
+}
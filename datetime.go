@@ -0,0 +1,452 @@
+package reportbro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+)
+
+// dateNames contains the localized month and weekday names of a locale (CLDR format context)
+type dateNames struct {
+	Months       [12]string
+	MonthsAbbr   [12]string
+	Weekdays     [7]string // starting with sunday like time.Weekday
+	WeekdaysAbbr [7]string
+	AM           string
+	PM           string
+}
+
+var localeDateNames = map[string]dateNames{
+	"en": {
+		Months:       [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		MonthsAbbr:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Weekdays:     [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		WeekdaysAbbr: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		AM:           "AM", PM: "PM",
+	},
+	"de": {
+		Months:       [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		MonthsAbbr:   [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		Weekdays:     [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		WeekdaysAbbr: [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		AM:           "AM", PM: "PM",
+	},
+	"de_at": {
+		Months:       [12]string{"Jänner", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		MonthsAbbr:   [12]string{"Jän.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sep.", "Okt.", "Nov.", "Dez."},
+		Weekdays:     [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		WeekdaysAbbr: [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		AM:           "AM", PM: "PM",
+	},
+	"fr": {
+		Months:       [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		MonthsAbbr:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Weekdays:     [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		WeekdaysAbbr: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		AM:           "AM", PM: "PM",
+	},
+	"it": {
+		Months:       [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		MonthsAbbr:   [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		Weekdays:     [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		WeekdaysAbbr: [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		AM:           "AM", PM: "PM",
+	},
+	"es": {
+		Months:       [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		MonthsAbbr:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		Weekdays:     [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		WeekdaysAbbr: [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		AM:           "a. m.", PM: "p. m.",
+	},
+	"pt": {
+		Months:       [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		MonthsAbbr:   [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		Weekdays:     [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		WeekdaysAbbr: [7]string{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+		AM:           "AM", PM: "PM",
+	},
+	"nl": {
+		Months:       [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		MonthsAbbr:   [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		Weekdays:     [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		WeekdaysAbbr: [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		AM:           "a.m.", PM: "p.m.",
+	},
+	"da": {
+		Months:       [12]string{"januar", "februar", "marts", "april", "maj", "juni", "juli", "august", "september", "oktober", "november", "december"},
+		MonthsAbbr:   [12]string{"jan.", "feb.", "mar.", "apr.", "maj", "jun.", "jul.", "aug.", "sep.", "okt.", "nov.", "dec."},
+		Weekdays:     [7]string{"søndag", "mandag", "tirsdag", "onsdag", "torsdag", "fredag", "lørdag"},
+		WeekdaysAbbr: [7]string{"søn.", "man.", "tirs.", "ons.", "tors.", "fre.", "lør."},
+		AM:           "AM", PM: "PM",
+	},
+	"sv": {
+		Months:       [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		MonthsAbbr:   [12]string{"jan.", "feb.", "mars", "apr.", "maj", "juni", "juli", "aug.", "sep.", "okt.", "nov.", "dec."},
+		Weekdays:     [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		WeekdaysAbbr: [7]string{"sön", "mån", "tis", "ons", "tors", "fre", "lör"},
+		AM:           "fm", PM: "em",
+	},
+	"nb": {
+		Months:       [12]string{"januar", "februar", "mars", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "desember"},
+		MonthsAbbr:   [12]string{"jan.", "feb.", "mar.", "apr.", "mai", "jun.", "jul.", "aug.", "sep.", "okt.", "nov.", "des."},
+		Weekdays:     [7]string{"søndag", "mandag", "tirsdag", "onsdag", "torsdag", "fredag", "lørdag"},
+		WeekdaysAbbr: [7]string{"søn.", "man.", "tir.", "ons.", "tor.", "fre.", "lør."},
+		AM:           "a.m.", PM: "p.m.",
+	},
+	"fi": {
+		Months:       [12]string{"tammikuuta", "helmikuuta", "maaliskuuta", "huhtikuuta", "toukokuuta", "kesäkuuta", "heinäkuuta", "elokuuta", "syyskuuta", "lokakuuta", "marraskuuta", "joulukuuta"},
+		MonthsAbbr:   [12]string{"tammik.", "helmik.", "maalisk.", "huhtik.", "toukok.", "kesäk.", "heinäk.", "elok.", "syysk.", "lokak.", "marrask.", "jouluk."},
+		Weekdays:     [7]string{"sunnuntaina", "maanantaina", "tiistaina", "keskiviikkona", "torstaina", "perjantaina", "lauantaina"},
+		WeekdaysAbbr: [7]string{"su", "ma", "ti", "ke", "to", "pe", "la"},
+		AM:           "ap.", PM: "ip.",
+	},
+	"pl": {
+		Months:       [12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"},
+		MonthsAbbr:   [12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+		Weekdays:     [7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+		WeekdaysAbbr: [7]string{"niedz.", "pon.", "wt.", "śr.", "czw.", "pt.", "sob."},
+		AM:           "AM", PM: "PM",
+	},
+	"cs": {
+		Months:       [12]string{"ledna", "února", "března", "dubna", "května", "června", "července", "srpna", "září", "října", "listopadu", "prosince"},
+		MonthsAbbr:   [12]string{"led", "úno", "bře", "dub", "kvě", "čvn", "čvc", "srp", "zář", "říj", "lis", "pro"},
+		Weekdays:     [7]string{"neděle", "pondělí", "úterý", "středa", "čtvrtek", "pátek", "sobota"},
+		WeekdaysAbbr: [7]string{"ne", "po", "út", "st", "čt", "pá", "so"},
+		AM:           "dop.", PM: "odp.",
+	},
+	"hu": {
+		Months:       [12]string{"január", "február", "március", "április", "május", "június", "július", "augusztus", "szeptember", "október", "november", "december"},
+		MonthsAbbr:   [12]string{"jan.", "febr.", "márc.", "ápr.", "máj.", "jún.", "júl.", "aug.", "szept.", "okt.", "nov.", "dec."},
+		Weekdays:     [7]string{"vasárnap", "hétfő", "kedd", "szerda", "csütörtök", "péntek", "szombat"},
+		WeekdaysAbbr: [7]string{"V", "H", "K", "Sze", "Cs", "P", "Szo"},
+		AM:           "de.", PM: "du.",
+	},
+	"ro": {
+		Months:       [12]string{"ianuarie", "februarie", "martie", "aprilie", "mai", "iunie", "iulie", "august", "septembrie", "octombrie", "noiembrie", "decembrie"},
+		MonthsAbbr:   [12]string{"ian.", "feb.", "mar.", "apr.", "mai", "iun.", "iul.", "aug.", "sept.", "oct.", "nov.", "dec."},
+		Weekdays:     [7]string{"duminică", "luni", "marți", "miercuri", "joi", "vineri", "sâmbătă"},
+		WeekdaysAbbr: [7]string{"dum.", "lun.", "mar.", "mie.", "joi", "vin.", "sâm."},
+		AM:           "a.m.", PM: "p.m.",
+	},
+	"tr": {
+		Months:       [12]string{"Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran", "Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık"},
+		MonthsAbbr:   [12]string{"Oca", "Şub", "Mar", "Nis", "May", "Haz", "Tem", "Ağu", "Eyl", "Eki", "Kas", "Ara"},
+		Weekdays:     [7]string{"Pazar", "Pazartesi", "Salı", "Çarşamba", "Perşembe", "Cuma", "Cumartesi"},
+		WeekdaysAbbr: [7]string{"Paz", "Pzt", "Sal", "Çar", "Per", "Cum", "Cmt"},
+		AM:           "ÖÖ", PM: "ÖS",
+	},
+	"ru": {
+		Months:       [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+		MonthsAbbr:   [12]string{"янв.", "февр.", "мар.", "апр.", "мая", "июн.", "июл.", "авг.", "сент.", "окт.", "нояб.", "дек."},
+		Weekdays:     [7]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"},
+		WeekdaysAbbr: [7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"},
+		AM:           "AM", PM: "PM",
+	},
+	"ca": {
+		Months:       [12]string{"de gener", "de febrer", "de març", "d’abril", "de maig", "de juny", "de juliol", "d’agost", "de setembre", "d’octubre", "de novembre", "de desembre"},
+		MonthsAbbr:   [12]string{"de gen.", "de febr.", "de març", "d’abr.", "de maig", "de juny", "de jul.", "d’ag.", "de set.", "d’oct.", "de nov.", "de des."},
+		Weekdays:     [7]string{"diumenge", "dilluns", "dimarts", "dimecres", "dijous", "divendres", "dissabte"},
+		WeekdaysAbbr: [7]string{"dg.", "dl.", "dt.", "dc.", "dj.", "dv.", "ds."},
+		AM:           "a. m.", PM: "p. m.",
+	},
+}
+
+// getDateNames returns the month and weekday names of the locale, the names of the
+// language are used if there are no names for the exact locale and English as fallback
+func getDateNames(locale string) dateNames {
+	locale = normalizeLocale(locale)
+	if names, ok := localeDateNames[locale]; ok {
+		return names
+	}
+	if pos := strings.Index(locale, "_"); pos != -1 {
+		locale = locale[:pos]
+	}
+	if locale == "no" || locale == "nn" {
+		locale = "nb"
+	}
+	if names, ok := localeDateNames[locale]; ok {
+		return names
+	}
+	return localeDateNames["en"]
+}
+
+// dateLayouts are the ISO-8601 layouts accepted for date and datetime values,
+// values without offset are interpreted in the time zone of the document
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// timeLayouts are the ISO-8601 layouts accepted for time values
+var timeLayouts = []string{
+	"15:04:05.999999999Z07:00",
+	"15:04Z07:00",
+	"15:04:05.999999999",
+	"15:04",
+}
+
+// parseDateValue converts the value of a date, datetime or time parameter to time.Time.
+// Strings are parsed as ISO-8601, other formats are parsed as fallback. Values without offset
+// are interpreted in the given location and datetime and time values are converted to the location.
+// Date values keep their year, month and day (and time if set) and are only moved into the location,
+// otherwise a date at midnight UTC would be shown as the previous day in zones west of UTC.
+// If location is nil values keep their offset and values without offset are in UTC.
+// Values of time parameters only contain the time of day (the date is January 1, year 0).
+func parseDateValue(value interface{}, parameterType ParameterType, location *time.Location) (time.Time, error) {
+	var rv time.Time
+	parseLocation := location
+	if parseLocation == nil {
+		parseLocation = time.UTC
+	}
+	switch v := value.(type) {
+	case time.Time:
+		rv = v
+	case *time.Time:
+		if v == nil {
+			return rv, fmt.Errorf("invalid date: nil")
+		}
+		rv = *v
+	case string:
+		s := strings.TrimSpace(v)
+		parsed := false
+		layouts := dateLayouts
+		if parameterType == ParameterTypeTime {
+			layouts = append(timeLayouts, dateLayouts...)
+		}
+		for _, layout := range layouts {
+			if t, err := time.ParseInLocation(layout, s, parseLocation); err == nil {
+				rv = t
+				parsed = true
+				break
+			}
+		}
+		if !parsed {
+			t, err := dateparse.ParseIn(s, parseLocation)
+			if err != nil {
+				return rv, err
+			}
+			rv = t
+		}
+	default:
+		return rv, fmt.Errorf("invalid date: %v", value)
+	}
+	if location != nil {
+		if parameterType == ParameterTypeDate {
+			rv = time.Date(rv.Year(), rv.Month(), rv.Day(), rv.Hour(), rv.Minute(), rv.Second(), rv.Nanosecond(), location)
+		} else {
+			rv = rv.In(location)
+		}
+	}
+	if parameterType == ParameterTypeTime {
+		rv = time.Date(0, time.January, 1, rv.Hour(), rv.Minute(), rv.Second(), rv.Nanosecond(), rv.Location())
+	}
+	return rv, nil
+}
+
+// getDateExpressionValue returns the value of a date used in expressions, dates are converted
+// to ISO-8601 strings so they can be compared with each other and with date strings
+func getDateExpressionValue(value time.Time) string {
+	if value.Year() == 0 && value.Month() == time.January && value.Day() == 1 {
+		return value.Format("15:04:05")
+	} else if value.Hour() == 0 && value.Minute() == 0 && value.Second() == 0 {
+		return value.Format("2006-01-02")
+	}
+	return value.Format("2006-01-02 15:04:05")
+}
+
+// getDefaultDatePattern returns the pattern used to format a date value if no pattern is set.
+// Date values only contain the time part if it is set (dates could contain a time before
+// datetime parameters were available).
+func getDefaultDatePattern(value time.Time, parameterType ParameterType) string {
+	switch parameterType {
+	case ParameterTypeDatetime:
+		return "yyyy-MM-dd HH:mm:ss"
+	case ParameterTypeTime:
+		return "HH:mm:ss"
+	}
+	if value.Second() != 0 {
+		return "yyyy-MM-dd HH:mm:ss"
+	} else if value.Hour() != 0 || value.Minute() != 0 {
+		return "yyyy-MM-dd HH:mm"
+	}
+	return "yyyy-MM-dd"
+}
+
+// formatDate formats the time with a CLDR (Unicode LDML) date pattern, e.g. "dd.MM.yyyy",
+// "EEEE, d. MMMM yyyy" or "h:mm a". Month and weekday names are localized for the given locale.
+// Text in single quotes is output literally, two single quotes represent a single quote.
+func formatDate(value time.Time, pattern string, locale string) string {
+	names := getDateNames(locale)
+	var sb strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); {
+		ch := runes[i]
+		if ch == '\'' {
+			if i+1 < len(runes) && runes[i+1] == '\'' {
+				sb.WriteRune('\'')
+				i += 2
+				continue
+			}
+			// quoted text ends at a single quote, two single quotes in quoted text are a quote
+			i++
+			for i < len(runes) {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						sb.WriteRune('\'')
+						i += 2
+						continue
+					}
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			i++
+			continue
+		}
+		if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')) {
+			sb.WriteRune(ch)
+			i++
+			continue
+		}
+		count := 1
+		for i+count < len(runes) && runes[i+count] == ch {
+			count++
+		}
+		sb.WriteString(formatDateField(value, ch, count, names))
+		i += count
+	}
+	return sb.String()
+}
+
+func padNumber(value int, width int) string {
+	s := strconv.Itoa(value)
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
+
+// formatDateField formats a single pattern field, e.g. "MMMM" is passed as ch='M' and count=4
+func formatDateField(value time.Time, ch rune, count int, names dateNames) string {
+	switch ch {
+	case 'G':
+		if value.Year() <= 0 {
+			return "BC"
+		}
+		return "AD"
+	case 'y', 'Y', 'u':
+		year := value.Year()
+		if ch == 'Y' {
+			year, _ = value.ISOWeek()
+		}
+		if count == 2 {
+			return padNumber(year%100, 2)
+		}
+		return padNumber(year, count)
+	case 'Q', 'q':
+		quarter := (int(value.Month())-1)/3 + 1
+		if count >= 3 {
+			return "Q" + strconv.Itoa(quarter)
+		}
+		return padNumber(quarter, count)
+	case 'M', 'L':
+		month := int(value.Month())
+		switch {
+		case count >= 5:
+			return string([]rune(names.Months[month-1])[:1])
+		case count == 4:
+			return names.Months[month-1]
+		case count == 3:
+			return names.MonthsAbbr[month-1]
+		}
+		return padNumber(month, count)
+	case 'w':
+		_, week := value.ISOWeek()
+		return padNumber(week, count)
+	case 'd':
+		return padNumber(value.Day(), count)
+	case 'D':
+		return padNumber(value.YearDay(), count)
+	case 'E', 'e', 'c':
+		weekday := int(value.Weekday())
+		if (ch == 'e' || ch == 'c') && count <= 2 {
+			// numeric day of week, monday is 1
+			return padNumber((weekday+6)%7+1, count)
+		}
+		switch {
+		case count >= 5:
+			return string([]rune(names.Weekdays[weekday])[:1])
+		case count == 4:
+			return names.Weekdays[weekday]
+		}
+		return names.WeekdaysAbbr[weekday]
+	case 'a':
+		if value.Hour() < 12 {
+			return names.AM
+		}
+		return names.PM
+	case 'h':
+		hour := value.Hour() % 12
+		if hour == 0 {
+			hour = 12
+		}
+		return padNumber(hour, count)
+	case 'H':
+		return padNumber(value.Hour(), count)
+	case 'K':
+		return padNumber(value.Hour()%12, count)
+	case 'k':
+		hour := value.Hour()
+		if hour == 0 {
+			hour = 24
+		}
+		return padNumber(hour, count)
+	case 'm':
+		return padNumber(value.Minute(), count)
+	case 's':
+		return padNumber(value.Second(), count)
+	case 'S':
+		fraction := padNumber(value.Nanosecond(), 9)
+		if count <= 9 {
+			return fraction[:count]
+		}
+		return fraction + strings.Repeat("0", count-9)
+	case 'z':
+		name, _ := value.Zone()
+		return name
+	case 'Z':
+		if count == 4 {
+			return "GMT" + value.Format("-07:00")
+		} else if count >= 5 {
+			return value.Format("Z07:00")
+		}
+		return value.Format("-0700")
+	case 'X', 'x':
+		_, offset := value.Zone()
+		if offset == 0 && ch == 'X' {
+			return "Z"
+		}
+		switch count {
+		case 1:
+			return value.Format("-07")
+		case 2, 4:
+			return value.Format("-0700")
+		}
+		return value.Format("-07:00")
+	}
+	// unknown pattern letters are output unchanged
+	return strings.Repeat(string(ch), count)
+}
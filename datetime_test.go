@@ -0,0 +1,92 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDateValue(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	tests := []struct {
+		value         interface{}
+		parameterType ParameterType
+		location      *time.Location
+		want          time.Time
+	}{
+		{"2024-03-05", ParameterTypeDate, nil, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-05", ParameterTypeDate, berlin, time.Date(2024, 3, 5, 0, 0, 0, 0, berlin)},
+		// date values keep their day in zones west of UTC
+		{"2024-03-05T00:00:00Z", ParameterTypeDate, newYork, time.Date(2024, 3, 5, 0, 0, 0, 0, newYork)},
+		{time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), ParameterTypeDate, newYork, time.Date(2024, 3, 5, 0, 0, 0, 0, newYork)},
+		{"2024-03-05 14:30", ParameterTypeDate, nil, time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)},
+		{"2024-03-05T12:00:00Z", ParameterTypeDatetime, berlin, time.Date(2024, 3, 5, 13, 0, 0, 0, berlin)},
+		{"2024-03-05T00:30:00Z", ParameterTypeDatetime, newYork, time.Date(2024, 3, 4, 19, 30, 0, 0, newYork)},
+		{"2024-07-01T08:15:00+02:00", ParameterTypeDatetime, nil, time.Date(2024, 7, 1, 8, 15, 0, 0, time.FixedZone("", 2*60*60))},
+		{"2024-07-01 08:15:00", ParameterTypeDatetime, berlin, time.Date(2024, 7, 1, 8, 15, 0, 0, berlin)},
+		{"08:15", ParameterTypeTime, nil, time.Date(0, 1, 1, 8, 15, 0, 0, time.UTC)},
+		{"08:15:30.5", ParameterTypeTime, nil, time.Date(0, 1, 1, 8, 15, 30, 500000000, time.UTC)},
+		{"March 5, 2024", ParameterTypeDate, nil, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		got, err := parseDateValue(test.value, test.parameterType, test.location)
+		if err != nil {
+			t.Errorf("parseDateValue(%v, %v): %v", test.value, test.parameterType, err)
+			continue
+		}
+		if got.String() != test.want.String() {
+			t.Errorf("parseDateValue(%v, %v) = %v, want %v", test.value, test.parameterType, got, test.want)
+		}
+	}
+}
+
+func TestParseDateValueInvalid(t *testing.T) {
+	for _, value := range []interface{}{"not a date", 12, (*time.Time)(nil)} {
+		if got, err := parseDateValue(value, ParameterTypeDate, nil); err == nil {
+			t.Errorf("parseDateValue(%v) = %v, want error", value, got)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	value := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+	tests := []struct {
+		pattern string
+		locale  string
+		want    string
+	}{
+		{"yyyy-MM-dd", "en", "2024-03-05"},
+		{"dd.MM.yy HH:mm:ss", "de", "05.03.24 14:07:09"},
+		{"EEEE, d. MMMM yyyy", "de", "Dienstag, 5. März 2024"},
+		{"EEE, MMM d, yyyy h:mm a", "en", "Tue, Mar 5, 2024 2:07 PM"},
+		{"d MMMM yyyy", "fr", "5 mars 2024"},
+		{"'week day' E", "en", "week day Tue"},
+		{"HH 'o''clock'", "en", "14 o'clock"},
+	}
+	for _, test := range tests {
+		if got := formatDate(value, test.pattern, test.locale); got != test.want {
+			t.Errorf("formatDate(%q, %s) = %q, want %q", test.pattern, test.locale, got, test.want)
+		}
+	}
+}
+
+func TestInvalidTimeZone(t *testing.T) {
+	var definition map[string]interface{}
+	js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","timeZone":"Mars/Olympus_Mons"},
+		"parameters":[],"styles":[],"docElements":[],"version":2}`
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	report := NewReport(definition, map[string]interface{}{}, false, "", nil)
+	if _, err := report.GeneratePDF(false); err == nil || !strings.Contains(err.Error(), "errorMsgInvalidTimeZone") {
+		t.Errorf("err = %v, want errorMsgInvalidTimeZone", err)
+	}
+}
@@ -0,0 +1,211 @@
+package reportbro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"text/scanner"
+
+	"github.com/PaesslerAG/gval"
+	"github.com/shopspring/decimal"
+)
+
+// toDecimal converts a number or number string to a decimal, false is returned if the
+// value is not a number. Floats are converted with their shortest representation so
+// e.g. 0.1 becomes exactly 0.1.
+func toDecimal(value interface{}) (decimal.Decimal, bool) {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return v, true
+	case *decimal.Decimal:
+		if v != nil {
+			return *v, true
+		}
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return decimal.NewFromFloat(v), true
+		}
+	case float32:
+		if !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0) {
+			return decimal.NewFromFloat32(v), true
+		}
+	case json.Number:
+		if d, err := decimal.NewFromString(string(v)); err == nil {
+			return d, true
+		}
+	case string:
+		if d, err := decimal.NewFromString(v); err == nil {
+			return d, true
+		}
+	default:
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return decimal.NewFromInt(rv.Int()), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return decimal.NewFromBigInt(new(big.Int).SetUint64(rv.Uint()), 0), true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// parseLocaleDecimal parses a number string to a decimal, the string can either be in plain format
+// or formatted with the group and decimal separators of the locale (see isLocaleNumber).
+func parseLocaleDecimal(value string, locale string) (decimal.Decimal, error) {
+	value = strings.TrimSpace(value)
+	rv, err := decimal.NewFromString(value)
+	if err == nil {
+		return rv, nil
+	}
+	if isLocaleNumber(value, locale) {
+		if rv, err2 := decimal.NewFromString(normalizeNumberString(value, locale)); err2 == nil {
+			return rv, nil
+		}
+	}
+	return decimal.Zero, err
+}
+
+// decimalDivisionPrecision is the number of fraction digits of quotients in decimal mode, e.g. of averages
+const decimalDivisionPrecision = 16
+
+// divideDecimal divides a by b with decimalDivisionPrecision fraction digits, the quotient
+// is rounded with the given rounding mode. b must not be zero.
+func divideDecimal(a decimal.Decimal, b decimal.Decimal, roundingMode RoundingMode) decimal.Decimal {
+	// the quotient is truncated towards zero, the remainder is less than b * unit
+	quotient, remainder := a.QuoRem(b, decimalDivisionPrecision)
+	if remainder.IsZero() {
+		return quotient
+	}
+	unit := decimal.New(1, -decimalDivisionPrecision)
+	negative := a.Sign() != b.Sign()
+	roundUp := false
+	switch roundingMode {
+	case RoundingModeUp:
+		roundUp = true
+	case RoundingModeDown:
+		roundUp = false
+	case RoundingModeCeiling:
+		roundUp = !negative
+	case RoundingModeFloor:
+		roundUp = negative
+	default:
+		// compare the remainder with half of the unit to find ties
+		switch remainder.Abs().Mul(decimal.NewFromInt(2)).Cmp(b.Abs().Mul(unit)) {
+		case 1:
+			roundUp = true
+		case 0:
+			switch roundingMode {
+			case RoundingModeHalfUp:
+				roundUp = true
+			case RoundingModeHalfDown:
+				roundUp = false
+			default:
+				roundUp = !quotient.Shift(decimalDivisionPrecision).Mod(decimal.NewFromInt(2)).IsZero()
+			}
+		}
+	}
+	if roundUp {
+		if negative {
+			return quotient.Sub(unit)
+		}
+		return quotient.Add(unit)
+	}
+	return quotient
+}
+
+// decimalLanguages contains the languages to evaluate expressions in decimal mode by rounding mode
+var decimalLanguages = newDecimalLanguages()
+
+func newDecimalLanguages() map[RoundingMode]gval.Language {
+	languages := make(map[RoundingMode]gval.Language)
+	for roundingMode := RoundingModeHalfEven; roundingMode <= RoundingModeFloor; roundingMode++ {
+		languages[roundingMode] = newDecimalLanguage(roundingMode)
+	}
+	return languages
+}
+
+// getDecimalLanguage returns the language to evaluate expressions in decimal mode, quotients are
+// rounded with the rounding mode
+func getDecimalLanguage(roundingMode RoundingMode) gval.Language {
+	if language, ok := decimalLanguages[roundingMode]; ok {
+		return language
+	}
+	return decimalLanguages[RoundingModeHalfEven]
+}
+
+// newDecimalLanguage returns a language to evaluate expressions in decimal mode, number literals are parsed
+// to decimals and arithmetic operators and comparisons work with decimals so calculations are exact.
+func newDecimalLanguage(roundingMode RoundingMode) gval.Language {
+	return gval.Full(
+		gval.PrefixExtension(scanner.Int, parseDecimalLiteral),
+		gval.PrefixExtension(scanner.Float, parseDecimalLiteral),
+		gval.PrefixOperator("-", func(c context.Context, v interface{}) (interface{}, error) {
+			d, ok := toDecimal(v)
+			if !ok {
+				return nil, fmt.Errorf("unexpected %v(%T) expected number", v, v)
+			}
+			return d.Neg(), nil
+		}),
+		decimalOperator("+", func(a, b decimal.Decimal) (interface{}, error) { return a.Add(b), nil }),
+		decimalOperator("-", func(a, b decimal.Decimal) (interface{}, error) { return a.Sub(b), nil }),
+		decimalOperator("*", func(a, b decimal.Decimal) (interface{}, error) { return a.Mul(b), nil }),
+		decimalOperator("/", func(a, b decimal.Decimal) (interface{}, error) {
+			if b.IsZero() {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return divideDecimal(a, b, roundingMode), nil
+		}),
+		decimalOperator("%", func(a, b decimal.Decimal) (interface{}, error) {
+			if b.IsZero() {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return a.Mod(b), nil
+		}),
+		decimalOperator("**", func(a, b decimal.Decimal) (interface{}, error) { return a.Pow(b), nil }),
+		decimalOperator(">", func(a, b decimal.Decimal) (interface{}, error) { return a.GreaterThan(b), nil }),
+		decimalOperator(">=", func(a, b decimal.Decimal) (interface{}, error) { return a.GreaterThanOrEqual(b), nil }),
+		decimalOperator("<", func(a, b decimal.Decimal) (interface{}, error) { return a.LessThan(b), nil }),
+		decimalOperator("<=", func(a, b decimal.Decimal) (interface{}, error) { return a.LessThanOrEqual(b), nil }),
+		decimalOperator("==", func(a, b decimal.Decimal) (interface{}, error) { return a.Equal(b), nil }),
+		decimalOperator("!=", func(a, b decimal.Decimal) (interface{}, error) { return !a.Equal(b), nil }),
+	)
+}
+
+func parseDecimalLiteral(c context.Context, p *gval.Parser) (gval.Evaluable, error) {
+	d, err := decimal.NewFromString(p.TokenText())
+	if err != nil {
+		return nil, err
+	}
+	return p.Const(d), nil
+}
+
+// decimalOperator returns an infix operator for decimals. Operands which are not decimals are
+// handled like in the default language, i.e. "+" concatenates strings and "==" compares any values.
+func decimalOperator(name string, f func(a, b decimal.Decimal) (interface{}, error)) gval.Language {
+	return gval.InfixOperator(name, func(a, b interface{}) (interface{}, error) {
+		_, aIsString := a.(string)
+		_, bIsString := b.(string)
+		if !aIsString || !bIsString {
+			x, ok1 := toDecimal(a)
+			y, ok2 := toDecimal(b)
+			if ok1 && ok2 {
+				return f(x, y)
+			}
+		}
+		switch name {
+		case "+":
+			if a != nil && b != nil {
+				return fmt.Sprintf("%v%v", a, b), nil
+			}
+		case "==":
+			return reflect.DeepEqual(a, b), nil
+		case "!=":
+			return !reflect.DeepEqual(a, b), nil
+		}
+		return nil, fmt.Errorf("invalid operation (%T) %s (%T)", a, name, b)
+	})
+}
@@ -0,0 +1,61 @@
+package reportbro
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDivideDecimal(t *testing.T) {
+	tests := []struct {
+		a            string
+		b            string
+		roundingMode RoundingMode
+		want         string
+	}{
+		{"10", "4", RoundingModeHalfEven, "2.5"},
+		{"2", "3", RoundingModeHalfEven, "0.6666666666666667"},
+		{"2", "3", RoundingModeDown, "0.6666666666666666"},
+		{"-2", "3", RoundingModeFloor, "-0.6666666666666667"},
+		{"-2", "3", RoundingModeCeiling, "-0.6666666666666666"},
+		{"1", "3", RoundingModeUp, "0.3333333333333334"},
+		// ties at the last fraction digit
+		{"0.0000000000000005", "2", RoundingModeHalfEven, "0.0000000000000002"},
+		{"0.0000000000000005", "2", RoundingModeHalfUp, "0.0000000000000003"},
+		{"0.0000000000000005", "2", RoundingModeHalfDown, "0.0000000000000002"},
+		{"-0.0000000000000005", "2", RoundingModeHalfUp, "-0.0000000000000003"},
+		{"0.0000000000000015", "2", RoundingModeHalfEven, "0.0000000000000008"},
+	}
+	for _, test := range tests {
+		got := divideDecimal(decimal.RequireFromString(test.a), decimal.RequireFromString(test.b), test.roundingMode)
+		if got.String() != test.want {
+			t.Errorf("divideDecimal(%s, %s, %v) = %s, want %s", test.a, test.b, test.roundingMode, got, test.want)
+		}
+	}
+}
+
+func TestEvaluateDecimalExpression(t *testing.T) {
+	tests := []struct {
+		expr   string
+		locale string
+		data   map[string]interface{}
+		want   string
+	}{
+		{"a + b", "en", map[string]interface{}{"a": "1,234.50", "b": 0.1}, "1234.6"},
+		{"a + b", "de", map[string]interface{}{"a": "1.234,50", "b": "0,1"}, "1234.6"},
+		{"a / 3", "en", map[string]interface{}{"a": "2"}, "0.6666666666666667"},
+		{"a * 2", "de", map[string]interface{}{"a": "2,5"}, "5"},
+	}
+	for _, test := range tests {
+		ctx := Context{PatternLocale: test.locale, RoundingMode: RoundingModeHalfEven}
+		got := ctx.evaluateDecimalExpression(test.expr, test.data)
+		if d, ok := got.(decimal.Decimal); !ok || d.String() != test.want {
+			t.Errorf("evaluateDecimalExpression(%q, %v) in %s = %v, want %s", test.expr, test.data, test.locale, got, test.want)
+		}
+	}
+	// a number string which is not valid in the locale stays a string
+	ctx := Context{PatternLocale: "en", RoundingMode: RoundingModeHalfEven}
+	if got := ctx.evaluateDecimalExpression("a + b", map[string]interface{}{"a": "1,5", "b": "x"}); got != "1,5x" {
+		t.Errorf("evaluateDecimalExpression(\"a + b\") = %v, want 1,5x", got)
+	}
+}
@@ -8,21 +8,22 @@ import (
 	_ "image/jpeg"
 	_ "image/png"
 	"log"
+	"math"
 	"mime"
+	"net/http"
 	"os"
 	"reflect"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
-	"github.com/dustin/go-humanize"
-	"github.com/jinzhu/now"
 	"github.com/jung-kurt/gofpdf"
 	"github.com/jung-kurt/gofpdf/contrib/barcode"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 	"github.com/spf13/cast"
 	"github.com/vincent-petithory/dataurl"
-	"github.com/vjeantet/jodaTime"
 )
 
 type DocElementBaseProvider interface {
@@ -36,6 +37,7 @@ type DocElementBaseProvider interface {
 	isPrinted(ctx Context) bool
 	finishEmptyElement(offsetY float64)
 	setHeight(height float64)
+	restart(ctx Context, pdfDoc *FPDFRB) bool
 	addRows(rows []*TableRow, allowSplit bool, availableHeight float64, offsetY float64, containerHeight float64, ctx Context, pdfDoc *FPDFRB) int
 }
 
@@ -70,6 +72,8 @@ type DocElementBase struct {
 	TableElement           bool
 	BorderColor            Color
 	ZIndex                 int
+	KeepTogether           bool
+	KeepWithNext           bool
 }
 
 func (self *DocElementBase) base() *DocElementBase {
@@ -79,7 +83,7 @@ func (self *DocElementBase) base() *DocElementBase {
 func (self *DocElementBase) init(report *report, data map[string]interface{}) {
 	self.Report = report
 	self.ID = 0
-	self.Y = float64(GetIntValue(data, "y"))
+	self.Y = GetFloatValue(data, "y")
 	self.RenderY = 0
 	self.RenderBottom = 0
 	self.Bottom = self.Y
@@ -173,6 +177,14 @@ func (self DocElementBase) cleanup() {
 
 func (self *DocElementBase) setHeight(height float64) {}
 
+// restart resets the rendering state so the element is rendered again from the start on the next page,
+// false is returned if the element cannot be restarted
+func (self *DocElementBase) restart(ctx Context, pdfDoc *FPDFRB) bool {
+	self.FirstRenderElement = true
+	self.RenderingComplete = false
+	return true
+}
+
 func (self *DocElementBase) addRows(rows []*TableRow, allowSplit bool, availableHeight float64, offsetY float64, containerHeight float64, ctx Context, pdfDoc *FPDFRB) int {
 	return 0
 }
@@ -191,10 +203,12 @@ func (self *DocElement) init(report *report, data map[string]interface{}) {
 	self.DocElementBase.init(report, data)
 	self.ID = GetIntValue(data, "id")
 	self.ZIndex = GetIntValue(data, "zIndex")
-	self.X = float64(GetIntValue(data, "x"))
-	self.Width = float64(GetIntValue(data, "width"))
-	self.Height = float64(GetIntValue(data, "height"))
+	self.X = GetFloatValue(data, "x")
+	self.Width = GetFloatValue(data, "width")
+	self.Height = GetFloatValue(data, "height")
 	self.Bottom = self.Y + self.Height
+	self.KeepTogether = GetBoolValue(data, "keepTogether")
+	self.KeepWithNext = GetBoolValue(data, "keepWithNext")
 }
 
 func (self *DocElement) getNextRenderElement(offsetY float64, containerHeight float64, ctx Context, pdfDoc *FPDFRB) (DocElementBaseProvider, bool) {
@@ -331,6 +345,9 @@ func (self *ImageElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool)
 				imgData, _ := ctx.getData(sourceparameter.Name, nil)
 				if reflect.TypeOf(imgData) == reflect.TypeOf("") {
 					imgDataB64 = imgData.(string)
+				} else if imgBytes, ok := imgData.([]byte); ok && len(imgBytes) > 0 {
+					// raw image data (only possible if report data is passed directly from go code)
+					self.setImageData(imgBytes)
 				}
 			} else {
 				log.Println(Error{Message: "errorMsgInvalidImageSourceparameter", ObjectID: self.base().ID, Field: "source"})
@@ -352,7 +369,11 @@ func (self *ImageElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool)
 			sourceparameter := parameter.(map[string]interface{})[parameterName].(Parameter)
 			imgData, parameterExists := ctx.getData(sourceparameter.Name, nil)
 			if parameterExists {
-				imgDataB64 = imgData.(string)
+				if imgBytes, ok := imgData.([]byte); ok {
+					self.setImageData(imgBytes)
+				} else {
+					imgDataB64 = cast.ToString(imgData)
+				}
 			}
 		}
 	}
@@ -417,6 +438,21 @@ func (self *ImageElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool)
 	}
 }
 
+// setImageData sets raw image data, the image type is detected from the content
+func (self *ImageElement) setImageData(data []byte) {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		self.ImageType = "png"
+	case "image/jpeg":
+		self.ImageType = "jpg"
+	default:
+		self.ImageType = ""
+		log.Println(Error{Message: "errorMsgUnsupportedImageType", ObjectID: self.base().ID, Field: "source"})
+		return
+	}
+	self.ImageFP = data
+}
+
 func (self *ImageElement) renderPDF(containerOffsetX float64, containerOffsetY float64, pdfDoc *FPDFRB) {
 	x := self.X + containerOffsetX
 	y := self.RenderY + containerOffsetY
@@ -430,8 +466,12 @@ func (self *ImageElement) renderPDF(containerOffsetX float64, containerOffsetY f
 			ReadDpi:               false,
 			AllowNegativePosition: true,
 		}
-		dataURL, _ := dataurl.DecodeString(self.Image64)
-		image, _, err := image.DecodeConfig(bytes.NewReader([]byte(dataURL.Data)))
+		imgData := self.ImageFP
+		if self.Image64 != "" {
+			dataURL, _ := dataurl.DecodeString(self.Image64)
+			imgData = dataURL.Data
+		}
+		image, _, err := image.DecodeConfig(bytes.NewReader(imgData))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 		}
@@ -495,7 +535,7 @@ func (self *ImageElement) renderPDF(containerOffsetX float64, containerOffsetY f
 		options.ImageType = self.ImageType
 
 		if options.ImageType != "" {
-			pdfDoc.Fpdf.RegisterImageOptionsReader(self.ImageKey, options, bytes.NewReader(dataURL.Data))
+			pdfDoc.Fpdf.RegisterImageOptionsReader(self.ImageKey, options, bytes.NewReader(imgData))
 			pdfDoc.Fpdf.ImageOptions(self.ImageKey, imageX, imageY, imageWidth, imageHeight, false, options, 0, "")
 		}
 
@@ -647,7 +687,7 @@ func (self *BarCodeElement) renderPDF(containerOffsetX float64, containerOffsetY
 	if self.ImageKey != nil {
 		barcode.Barcode(pdfDoc.Fpdf, *self.ImageKey, x, y, self.Width, self.ImageHeight, false)
 		if self.DisplayValue != false {
-			pdfDoc.Fpdf.SetFont("courier", "B", 18)
+			pdfDoc.setFont("courier", "B", 18)
 			pdfDoc.Fpdf.SetTextColor(0, 0, 0)
 			contentWidth := pdfDoc.Fpdf.GetStringWidth(self.Content)
 			OffsetX := (self.Width - contentWidth) / 2
@@ -749,9 +789,17 @@ type TextElement struct {
 	DocElement
 	Content                          string
 	Eval                             bool
+	RichText                         bool
 	Style                            textStyle
 	Pattern                          string
+	Currency                         string
+	RoundingMode                     RoundingMode
 	Link                             string
+	Overflow                         TextOverflow
+	MaxLines                         int
+	MinFontSize                      float64
+	Orphans                          int
+	Widows                           int
 	CsCondition                      string
 	ConditionalStyle                 *textStyle
 	TextHeight                       float64
@@ -773,18 +821,32 @@ func (self *TextElement) init(report *report, data map[string]interface{}) {
 
 	self.Content = GetStringValue(data, "content")
 	self.Eval = GetBoolValue(data, "eval")
+	// content of evaluated expressions is always plain text
+	self.RichText = GetBoolValue(data, "richText") && !self.Eval
 	if GetIntValue(data, "styleId") != 0 {
 		if style, ok := report.Styles[cast.ToString(GetIntValue(data, "styleId"))]; ok {
 			self.Style = style
 		} else {
-			log.Println(Error{Message: fmt.Sprintf("Style for text element %s not found", self.ID)})
+			log.Println(Error{Message: fmt.Sprintf("Style for text element %d not found", self.ID)})
 		}
 	} else {
 		self.Style = NewTextStyle(data, "")
 	}
 	self.PrintIf = GetStringValue(data, "printIf")
 	self.Pattern = GetStringValue(data, "pattern")
+	self.Currency = GetStringValue(data, "currency")
+	if roundingMode := GetStringValue(data, "roundingMode"); roundingMode != "" {
+		self.RoundingMode = GetRoundingMode(roundingMode)
+	}
 	self.Link = GetStringValue(data, "link")
+	self.Overflow = GetTextOverflow(GetStringValue(data, "overflow"))
+	self.MaxLines = GetIntValue(data, "maxLines")
+	self.MinFontSize = GetFloatValue(data, "minFontSize")
+	if self.MinFontSize <= 0 {
+		self.MinFontSize = 4
+	}
+	self.Orphans = GetIntValue(data, "orphans")
+	self.Widows = GetIntValue(data, "widows")
 	self.CsCondition = GetStringValue(data, "cs_condition")
 	if self.CsCondition != "" {
 		if GetStringValue(data, "cs_styleId") != "" {
@@ -792,7 +854,7 @@ func (self *TextElement) init(report *report, data map[string]interface{}) {
 				self.ConditionalStyle = &val
 			}
 			if self.ConditionalStyle == nil {
-				log.Println(Error{Message: fmt.Sprintf("Conditional style for text element %s not found", self.ID)})
+				log.Println(Error{Message: fmt.Sprintf("Conditional style for text element %d not found", self.ID)})
 			}
 		} else {
 			style := NewTextStyle(data, "cs_")
@@ -807,9 +869,10 @@ func (self *TextElement) init(report *report, data map[string]interface{}) {
 		self.AlwaysPrintOnSamePage = false
 	} else {
 		self.RemoveEmptyElement = GetBoolValue(data, "removeEmptyElement")
-		self.AlwaysPrintOnSamePage = GetBoolValue(data, "alwaysPrintOnSamePage")
+		// text which does not grow has a fixed size and is never split
+		self.AlwaysPrintOnSamePage = GetBoolValue(data, "alwaysPrintOnSamePage") || self.KeepTogether || self.Overflow != TextOverflowGrow
 	}
-	self.Height = float64(GetIntValue(data, "height"))
+	self.Height = GetFloatValue(data, "height")
 	self.SpreadsheetHide = GetBoolValue(data, "spreadsheet_hide")
 	self.SpreadsheetColumn = StringPointer(cast.ToString(GetIntValue(data, "spreadsheet_column")))
 	self.SpreadsheetColspan = GetIntValue(data, "spreadsheet_colspan")
@@ -837,54 +900,50 @@ func (self *TextElement) isPrinted(ctx Context) bool {
 func (self *TextElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool) {
 	var content interface{}
 
+	if self.CsCondition != "" {
+		if cast.ToBool(ctx.evaluateExpression(self.CsCondition, self.ID, "cs_condition")) {
+			self.UsedStyle = self.ConditionalStyle
+		} else {
+			self.UsedStyle = &self.Style
+		}
+	} else {
+		self.UsedStyle = &self.Style
+	}
+
+	ctx.currency = self.Currency
+	ctx.roundingMode = self.RoundingMode
+	ctx.isRenderable = nil
+	if pdfDoc != nil && pdfDoc.Fpdf != nil {
+		style := self.UsedStyle
+		ctx.isRenderable = func(text string) bool {
+			return pdfDoc.canRenderText(text, style.Font, style.FontStyle, style.FallbackFonts)
+		}
+	}
 	if self.Eval {
 		content = ctx.evaluateExpression(self.Content, self.ID, "content")
 
-		if self.Pattern != "" {
-			if (reflect.TypeOf(content) == reflect.TypeOf(0)) || (reflect.TypeOf(content) == reflect.TypeOf(0.0)) {
-				usedPattern := self.Pattern
-				patternHasCurrency := strings.Contains(usedPattern, "$")
-				if usedPattern != "" {
-					usedPattern = strings.Replace(usedPattern, "0", "#", -1)
-					usedPattern = strings.Replace(usedPattern, " ", "", -1)
-					if patternHasCurrency {
-						usedPattern = strings.Replace(usedPattern, "$", "", -1)
-						content = humanize.FormatFloat(usedPattern, cast.ToFloat64(content))
-						content = ctx.PatternCurrencySymbol + cast.ToString(content)
-					} else {
-						content = humanize.FormatFloat(usedPattern, cast.ToFloat64(content))
-					}
-				}
+		if self.Pattern != "" || self.Currency != "" {
+			if (reflect.TypeOf(content) == reflect.TypeOf(0)) || (reflect.TypeOf(content) == reflect.TypeOf(0.0)) || (reflect.TypeOf(content) == reflect.TypeOf(decimal.Decimal{})) {
+				content = ctx.formatNumber(content, self.Pattern, nil, self.ID)
 			} else if reflect.TypeOf(content) == reflect.TypeOf(time.Time{}) {
-				usedPattern := self.Pattern
-				if usedPattern != "" {
-					t, err := now.Parse(cast.ToString(content))
-					if err == nil {
-						date := jodaTime.Format(usedPattern, t)
-						content = date
-					}
-				}
+				content = ctx.formatDate(content, self.Pattern, ParameterTypeDatetime)
 			}
 		}
 		content = cast.ToString(content)
+	} else if self.RichText {
+		// parameters are filled in when parsing the rich text so parameter values are not parsed as html
+		content = self.Content
 	} else {
 		content = ctx.fillParameters(self.Content, self.ID, "content", self.Pattern)
 	}
+	fillParameters := func(text string) string {
+		return ctx.fillParameters(text, self.ID, "content", self.Pattern)
+	}
 
 	if self.Link != "" {
 		self.Link = ctx.fillParameters(self.Link, self.ID, "link", "")
 	}
 
-	if self.CsCondition != "" {
-		if cast.ToBool(ctx.evaluateExpression(self.CsCondition, self.ID, "cs_condition")) {
-			self.UsedStyle = self.ConditionalStyle
-		} else {
-			self.UsedStyle = &self.Style
-		}
-	} else {
-		self.UsedStyle = &self.Style
-	}
-
 	if getDataType(self) == DataTypeTableTextElement {
 		if self.UsedStyle.VerticalAlignment != VerticalAlignmentTop && self.AlwaysPrintOnSamePage == false {
 			self.AlwaysPrintOnSamePage = true
@@ -895,20 +954,57 @@ func (self *TextElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool) {
 
 	self.TextLines = make([]TextLine, 0)
 	if pdfDoc.Fpdf != nil {
-		pdfDoc.Fpdf.SetFont(self.UsedStyle.Font, self.UsedStyle.FontStyle, self.UsedStyle.FontSize)
+		// another font is used if the font of the style has no glyphs for the text, e.g. for chinese text,
+		// unless the style declares its own fallback fonts. Arabic text is rendered with its presentation
+		// forms so the font must contain these glyphs.
+		text := shapeArabicText(cast.ToString(content))
+		if len(self.UsedStyle.FallbackFonts) == 0 {
+			if font := pdfDoc.getFallbackFont(text, self.UsedStyle.Font, self.UsedStyle.FontStyle); font != self.UsedStyle.Font {
+				style := *self.UsedStyle
+				style.Font = font
+				self.UsedStyle = &style
+			}
+		}
+		// right-to-left text cannot be encoded for the core fonts, a TrueType font
+		// of the additional fonts directory is required
+		if hasRTLText(text) && !pdfDoc.canRenderText(text, self.UsedStyle.Font, self.UsedStyle.FontStyle, self.UsedStyle.FallbackFonts) {
+			log.Println(Error{Message: "errorMsgMissingFont", ObjectID: self.ID, Field: "content", Info: self.UsedStyle.Font})
+		}
+		pdfDoc.setFont(self.UsedStyle.Font, self.UsedStyle.FontStyle, self.UsedStyle.FontSize)
+		pdfDoc.setFallbackFonts(self.UsedStyle.FallbackFonts)
 		lines := make([]TextLine, 0)
 		if content != "" {
-			tmpLines := pdfDoc.Fpdf.SplitLines([]byte(cast.ToString(content)), availableWidth)
-			for split := 0; split < len(tmpLines); split++ {
-				lines = append(lines, NewTextLine(string(tmpLines[split]), availableWidth, self.UsedStyle, self.Link))
+			var hyphenator *hyphenator
+			if self.UsedStyle.Hyphenate {
+				language := self.UsedStyle.Language
+				if language == "" {
+					language = ctx.PatternLocale
+				}
+				hyphenator = getHyphenator(language)
 			}
+			lines = self.getTextLines(cast.ToString(content), availableWidth, hyphenator, fillParameters, pdfDoc)
+			if self.Overflow == TextOverflowShrink {
+				// the font size is reduced in steps of 0.5 until the text fits
+				fontSize := self.UsedStyle.FontSize
+				for !self.isTextFitting(lines) && self.UsedStyle.FontSize > self.MinFontSize {
+					style := *self.UsedStyle
+					style.FontSize = math.Max(style.FontSize-0.5, self.MinFontSize)
+					style.fontScale = style.FontSize / fontSize
+					self.UsedStyle = &style
+					pdfDoc.setFont(self.UsedStyle.Font, self.UsedStyle.FontStyle, self.UsedStyle.FontSize)
+					pdfDoc.setFallbackFonts(self.UsedStyle.FallbackFonts)
+					lines = self.getTextLines(cast.ToString(content), availableWidth, hyphenator, fillParameters, pdfDoc)
+				}
+			}
+			lines = self.truncateTextLines(lines, pdfDoc)
 			content = strings.Replace(cast.ToString(content), "\n", " \n ", -1)
 		}
 
 		self.LineHeight = self.UsedStyle.base().FontSize * self.UsedStyle.base().LineSpacing
 		self.LinesCount = len(lines)
-		if self.LinesCount > 0 {
-			self.TextHeight = float64((len(lines)-1))*self.LineHeight + self.UsedStyle.base().FontSize
+		self.TextHeight = 0
+		for i := range lines {
+			self.TextHeight += lines[i].getHeight(i == 0)
 		}
 		self.LineIndex = 0
 		for _, line := range lines {
@@ -920,6 +1016,9 @@ func (self *TextElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool) {
 			self.setHeight(self.Height)
 		}
 	} else {
+		if self.RichText {
+			content = getRichTextPlainText(cast.ToString(content), self.UsedStyle, fillParameters)
+		}
 		self.Content = cast.ToString(content)
 		// set textLines so isPrinted can check for empty element when rendering spreadsheet
 		if content != "" {
@@ -929,6 +1028,87 @@ func (self *TextElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool) {
 	}
 }
 
+// getTextLines splits the content into lines which fit into the given width, the font of the used style must be set
+func (self *TextElement) getTextLines(content string, width float64, hyphenator *hyphenator, fillParameters func(string) string, pdfDoc *FPDFRB) []TextLine {
+	if self.RichText {
+		lines := getRichTextLines(content, self.UsedStyle, fillParameters, width, hyphenator, self.Link, pdfDoc)
+		setParagraphSpacing(lines, self.UsedStyle)
+		return lines
+	}
+	lines := make([]TextLine, 0)
+	for _, lineBreak := range newLineBreaker(pdfDoc, width, self.UsedStyle, hyphenator).split(content) {
+		line := NewTextLine(lineBreak.text, width, self.UsedStyle, self.Link)
+		line.ParagraphEnd = lineBreak.paragraphEnd
+		line.Indent = lineBreak.indent
+		line.RTL = lineBreak.rtl
+		lines = append(lines, line)
+	}
+	setParagraphSpacing(lines, self.UsedStyle)
+	return lines
+}
+
+// setParagraphSpacing sets the space between paragraphs for the first line of each paragraph
+// except the first one
+func setParagraphSpacing(lines []TextLine, style *textStyle) {
+	for i := 1; i < len(lines); i++ {
+		if lines[i-1].ParagraphEnd {
+			lines[i].SpaceBefore = style.SpaceAfter + style.SpaceBefore
+		}
+	}
+}
+
+// getAvailableTextHeight returns the height for the text of elements which do not grow,
+// 0 if the height is not limited
+func (self *TextElement) getAvailableTextHeight() float64 {
+	if self.Overflow == TextOverflowGrow {
+		return 0
+	}
+	return math.Max(self.Height-self.UsedStyle.PaddingTop-self.UsedStyle.PaddingBottom, 0)
+}
+
+// isTextFitting returns true if the lines do not exceed the max lines and the height of the element
+func (self *TextElement) isTextFitting(lines []TextLine) bool {
+	if self.MaxLines > 0 && len(lines) > self.MaxLines {
+		return false
+	}
+	availableHeight := self.getAvailableTextHeight()
+	if availableHeight == 0 {
+		return true
+	}
+	textHeight := 0.0
+	for i := range lines {
+		textHeight += lines[i].getHeight(i == 0)
+	}
+	return textHeight <= availableHeight+0.001
+}
+
+// truncateTextLines removes the lines exceeding the max lines or the height of the element, at least one line
+// is kept. An ellipsis is added to the last line for ellipsis overflow and for text which could not be shrunk enough.
+func (self *TextElement) truncateTextLines(lines []TextLine, pdfDoc *FPDFRB) []TextLine {
+	count := len(lines)
+	if self.MaxLines > 0 && self.MaxLines < count {
+		count = self.MaxLines
+	}
+	if availableHeight := self.getAvailableTextHeight(); availableHeight > 0 {
+		textHeight := 0.0
+		for i := 0; i < count; i++ {
+			textHeight += lines[i].getHeight(i == 0)
+			if i > 0 && textHeight > availableHeight+0.001 {
+				count = i
+				break
+			}
+		}
+	}
+	if count == len(lines) {
+		return lines
+	}
+	lines = lines[:count]
+	if self.Overflow == TextOverflowEllipsis || self.Overflow == TextOverflowShrink {
+		lines[count-1].addEllipsis(pdfDoc)
+	}
+	return lines
+}
+
 func (self *TextElement) setHeight(height float64) {
 	self.Height = height
 	self.SpaceTop = 0.0
@@ -953,7 +1133,9 @@ func (self *TextElement) setHeight(height float64) {
 
 func (self *TextElement) getNextRenderElement(offsetY float64, containerHeight float64, ctx Context, pdfDoc *FPDFRB) (DocElementBaseProvider, bool) {
 	availableHeight := containerHeight - offsetY
-	if self.AlwaysPrintOnSamePage && self.FirstRenderElement && self.TotalHeight > availableHeight && offsetY != 0 {
+	if self.AlwaysPrintOnSamePage && self.FirstRenderElement && self.TotalHeight > availableHeight && offsetY != 0 &&
+		(!self.KeepTogether || self.TotalHeight <= containerHeight) {
+		// text which is kept together is split in case it does not fit on an empty page either
 		return nil, false
 	}
 	lines := make([]TextLine, 0)
@@ -969,13 +1151,12 @@ func (self *TextElement) getNextRenderElement(offsetY float64, containerHeight f
 		textOffsetY = spaceTop
 	}
 	if self.SpaceTop == 0 {
+		startIndex := self.LineIndex
+		blockHeights := make([]float64, 0)
 		firstLine := true
 		for self.LineIndex < self.LinesCount {
 			lastLine := (self.LineIndex >= self.LinesCount-1)
-			lineHeight := self.LineHeight
-			if firstLine && self.UsedStyle != nil {
-				lineHeight = self.UsedStyle.FontSize
-			}
+			lineHeight := self.TextLines[self.LineIndex].getHeight(firstLine)
 			tmpHeight := lineHeight
 			if self.LineIndex == 0 {
 				tmpHeight += self.UsedStyle.PaddingTop
@@ -987,12 +1168,26 @@ func (self *TextElement) getNextRenderElement(offsetY float64, containerHeight f
 				break
 			}
 			lines = append(lines, self.TextLines[self.LineIndex])
+			blockHeights = append(blockHeights, tmpHeight)
 			remainingHeight -= tmpHeight
 			blockHeight += tmpHeight
 			textHeight += lineHeight
 			self.LineIndex++
 			firstLine = false
 		}
+		if self.LineIndex < self.LinesCount {
+			// move lines to the next container for widow and orphan control
+			breakIndex := self.getPageBreakLineIndex(startIndex, self.LineIndex, offsetY)
+			for self.LineIndex > breakIndex {
+				self.LineIndex--
+				lines = lines[:len(lines)-1]
+				tmpHeight := blockHeights[len(blockHeights)-1]
+				blockHeights = blockHeights[:len(blockHeights)-1]
+				remainingHeight += tmpHeight
+				blockHeight -= tmpHeight
+				textHeight -= self.TextLines[self.LineIndex].getHeight(len(lines) == 0)
+			}
+		}
 	}
 	if self.LineIndex >= self.LinesCount && self.SpaceBottom > 0 {
 		spaceBottom := pyMin(self.SpaceBottom, remainingHeight)
@@ -1043,6 +1238,45 @@ func (self *TextElement) getNextRenderElement(offsetY float64, containerHeight f
 	return textBlockElem, renderingComplete
 }
 
+// getPageBreakLineIndex returns the index of the first line in the next container so at least Orphans lines
+// of a paragraph are kept at the end of the container and at least Widows lines start the next container.
+// Paragraphs are separated by blank lines. The break index is returned if no lines can be moved.
+func (self *TextElement) getPageBreakLineIndex(startIndex int, breakIndex int, offsetY float64) int {
+	if (self.Orphans <= 1 && self.Widows <= 1) || breakIndex == 0 ||
+		self.TextLines[breakIndex-1].isBlank() || self.TextLines[breakIndex].isBlank() {
+		return breakIndex
+	}
+	paragraphStart := breakIndex - 1
+	for paragraphStart > startIndex && !self.TextLines[paragraphStart-1].isBlank() {
+		paragraphStart--
+	}
+	paragraphEnd := breakIndex + 1
+	for paragraphEnd < self.LinesCount && !self.TextLines[paragraphEnd].isBlank() {
+		paragraphEnd++
+	}
+	index := breakIndex
+	if paragraphEnd-index < self.Widows {
+		index = paragraphEnd - self.Widows
+	}
+	if index-paragraphStart < self.Orphans {
+		index = paragraphStart
+	}
+	if index <= startIndex && (startIndex > 0 || offsetY == 0) {
+		// the container would be empty, the text is already split or starts at the top of the container
+		return breakIndex
+	}
+	return index
+}
+
+func (self *TextElement) restart(ctx Context, pdfDoc *FPDFRB) bool {
+	self.LineIndex = 0
+	if !self.TableElement {
+		// restore space above and below the text
+		self.setHeight(self.Height)
+	}
+	return self.DocElementBase.restart(ctx, pdfDoc)
+}
+
 func (self *TextElement) isFirstRenderElement() bool {
 	return self.FirstRenderElement
 }
@@ -1099,25 +1333,21 @@ func (self *TextBlockElement) renderPDF(containerOffsetX float64, containerOffse
 	}
 	y += self.TextOffsetY
 
-	underline := self.Style.base().Underline
-	lastLineIndex := len(self.Lines) - 1
-	// underline for justified text is drawn manually to have a single line for the
-	// whole text. each word is rendered individually,
-	// therefor we can"t use the underline style of the rendered text
-	if self.Style.base().HorizontalAlignment == HorizontalAlignmentJustify && lastLineIndex > 0 {
-		underline = false
-		pdfDoc.Fpdf.SetDrawColor(self.Style.base().TextColor.R, self.Style.base().TextColor.G, self.Style.base().TextColor.B)
-	}
-	if underline {
-		self.Style.base().FontStyle += "U"
-	}
-	pdfDoc.Fpdf.SetFont(self.Style.base().Font, self.Style.base().FontStyle, self.Style.base().FontSize)
+	// underline is drawn manually to have a single line for the whole text, words of justified
+	// text are rendered individually and the underline style of gofpdf ignores letter spacing
+	pdfDoc.Fpdf.SetDrawColor(self.Style.base().TextColor.R, self.Style.base().TextColor.G, self.Style.base().TextColor.B)
+	pdfDoc.setFont(self.Style.base().Font, self.Style.base().getFontStyle(true), self.Style.base().FontSize)
+	pdfDoc.setFallbackFonts(self.Style.base().FallbackFonts)
 	pdfDoc.Fpdf.SetTextColor(self.Style.base().TextColor.R, self.Style.base().TextColor.G, self.Style.base().TextColor.B)
 
 	for i, line := range self.Lines {
-		lastLine := (i == lastLineIndex)
-		line.renderPDF(self.X+containerOffsetX+self.Style.base().PaddingLeft, y, lastLine, pdfDoc)
-		y += self.LineHeight
+		// the text of a line is aligned to the bottom of the line height, lines of rich text
+		// can have different heights
+		lineHeight := line.getHeight(i == 0)
+		// the last line of a paragraph is not justified, the text block can end within a paragraph
+		// in case the text continues on the next page
+		line.renderPDF(self.X+containerOffsetX+self.Style.base().PaddingLeft, y+lineHeight-line.getFontSize(), line.ParagraphEnd, pdfDoc)
+		y += lineHeight
 	}
 }
 
@@ -1128,10 +1358,23 @@ func NewTextBlockElement(report *report, x float64, y float64, renderY float64,
 }
 
 type TextLine struct {
-	Text  string
-	Width float64
-	Style Style
-	Link  string
+	Text         string
+	Width        float64
+	Style        Style
+	Link         string
+	ParagraphEnd bool
+	// Fragments contains the styled parts of a rich text line
+	Fragments []textFragment
+	// Marker is the bullet or number of the first line of a list item
+	Marker *textFragment
+	// Indent is the space in front of the line, e.g. the first line indent of a paragraph
+	Indent float64
+	// FontSize is the largest font size of a rich text line, 0 for plain text
+	FontSize float64
+	// SpaceBefore is the space between the previous paragraph and the first line of a paragraph
+	SpaceBefore float64
+	// RTL is set for lines of right-to-left paragraphs, the text is stored in logical order
+	RTL bool
 }
 
 func (self *TextLine) init(text string, width float64, style Style, link string) {
@@ -1141,92 +1384,195 @@ func (self *TextLine) init(text string, width float64, style Style, link string)
 	self.Link = link
 }
 
+// getFontSize returns the font size which determines the height of the line
+func (self *TextLine) getFontSize() float64 {
+	if self.FontSize > 0 {
+		return self.FontSize
+	}
+	return self.Style.base().FontSize
+}
+
+// getHeight returns the height of the line, line spacing and paragraph spacing are not applied
+// to the first line of a text block
+func (self *TextLine) getHeight(firstLine bool) float64 {
+	if firstLine {
+		return self.getFontSize()
+	}
+	return self.getFontSize()*self.Style.base().LineSpacing + self.SpaceBefore
+}
+
+// getHorizontalAlignment returns the alignment of the line, lines of right-to-left paragraphs
+// are aligned right if the style has no alignment
+func (self *TextLine) getHorizontalAlignment() HorizontalAlignment {
+	if self.RTL && self.Style.base().StartAlignment {
+		return HorizontalAlignmentRight
+	}
+	return self.Style.base().HorizontalAlignment
+}
+
+// getTextWidth returns the width of the text of the line including letter spacing, the font of the line style must be set
+func (self *TextLine) getTextWidth(text string, pdfDoc *FPDFRB) float64 {
+	return pdfDoc.getTextWidth(text, self.Style.base().LetterSpacing)
+}
+
+// isBlank returns true for empty lines which separate paragraphs
+func (self *TextLine) isBlank() bool {
+	return strings.TrimSpace(self.Text) == "" && self.Marker == nil
+}
+
+// addEllipsis shortens the line so an ellipsis can be appended, the font of the line style must be set
+func (self *TextLine) addEllipsis(pdfDoc *FPDFRB) {
+	// the last line of truncated text is not justified
+	self.ParagraphEnd = true
+	if self.Fragments != nil {
+		self.addRichTextEllipsis(pdfDoc)
+		return
+	}
+	text := strings.TrimRight(self.Text, " ")
+	for text != "" && self.getTextWidth(text+ellipsis, pdfDoc) > self.Width-self.Indent {
+		_, size := utf8.DecodeLastRuneInString(text)
+		text = strings.TrimRight(text[:len(text)-size], " ")
+	}
+	self.Text = text + ellipsis
+}
+
 func (self *TextLine) renderPDF(x float64, y float64, lastLine bool, pdfDoc *FPDFRB) {
+	if self.Fragments != nil {
+		self.renderRichTextPDF(x, y, lastLine, pdfDoc)
+		return
+	}
+	// the indent is on the right side of right-to-left lines
+	if !self.RTL {
+		x += self.Indent
+	}
+	availableWidth := self.Width - self.Indent
+	letterSpacing := self.Style.base().LetterSpacing
+	if letterSpacing != 0 {
+		pdfDoc.setLetterSpacing(letterSpacing)
+		defer pdfDoc.setLetterSpacing(0)
+	}
 	renderY := y + self.Style.base().FontSize*0.8
 	lineWidth := 0.0
 	offsetX := 0.0
-	if self.Style.base().HorizontalAlignment == HorizontalAlignmentJustify {
-		if lastLine {
-			pdfDoc.Fpdf.SetFont(self.Style.base().Font, self.Style.base().FontStyle, self.Style.base().FontSize)
-			pdfDoc.Fpdf.Text(x, renderY, pdfDoc.Tr(self.Text))
+	// the text is rendered in visual order
+	text := getVisualText(self.Text, self.RTL)
+	horizontalAlignment := self.getHorizontalAlignment()
+	if horizontalAlignment == HorizontalAlignmentJustify {
+		// words are separated by spaces only, non-breaking spaces are part of a word
+		words := strings.Split(text, " ")
+		if lastLine || len(words) < 2 {
+			lineWidth = self.getTextWidth(text, pdfDoc)
+			if self.RTL {
+				offsetX = availableWidth - lineWidth
+			}
+			pdfDoc.renderText(x+offsetX, renderY, text, letterSpacing)
 		} else {
-			words := strings.Split(self.Text, "")
 			wordWidth := make([]float64, 0)
 			totalWordWidth := 0.0
 			for _, word := range words {
-				tmpWidth := pdfDoc.Fpdf.GetStringWidth(word)
+				tmpWidth := self.getTextWidth(word, pdfDoc)
 				wordWidth = append(wordWidth, tmpWidth)
 				totalWordWidth += tmpWidth
 			}
-			countSpaces := len(words) - 1
-			wordSpacing := 0.0
-			if countSpaces > 0 {
-				wordSpacing = ((self.Width - totalWordWidth) / float64(countSpaces))
-			}
+			// the remaining space of the line is distributed between the words
+			wordSpacing := ((availableWidth - totalWordWidth) / float64(len(words)-1))
 			wordX := x
-			pdfDoc.Fpdf.SetFont(self.Style.base().Font, self.Style.base().FontStyle, self.Style.base().FontSize)
 			for i, word := range words {
-				pdfDoc.Fpdf.Text(wordX, renderY, pdfDoc.Tr(word))
+				pdfDoc.renderText(wordX, renderY, word, letterSpacing)
 				wordX += wordWidth[i] + wordSpacing
 			}
-
-			textWidth := 0.0
-			if self.Style.base().Underline {
-				if len(words) == 1 {
-					textWidth = wordWidth[0]
-				} else {
-					textWidth = self.Width
-				}
-
-				underlinePosition := x    // pdfDoc.Fpdf.CurrentFont["up"]
-				underlineThickness := 1.0 // pdfDoc.Fpdf.CurrentFont["ut"]
-				renderY += -underlinePosition / 1000.0 * self.Style.base().FontSize
-				underlineWidth := underlineThickness / 1000.0 * self.Style.base().FontSize
-				pdfDoc.Fpdf.SetLineWidth(underlineWidth)
-				pdfDoc.Fpdf.Line(x, renderY, x+textWidth, renderY)
-			}
-
-			if len(words) > 1 {
-				lineWidth = self.Width
-			} else if len(words) > 0 {
-				lineWidth = wordWidth[0]
-			}
+			lineWidth = availableWidth
 		}
 	} else {
-		if self.Style.base().HorizontalAlignment != HorizontalAlignmentLeft {
-			lineWidth = pdfDoc.Fpdf.GetStringWidth(self.Text)
-			space := self.Width - lineWidth
-			if self.Style.base().HorizontalAlignment == HorizontalAlignmentCenter {
-				offsetX = (space / 2)
-			} else if self.Style.base().HorizontalAlignment == HorizontalAlignmentRight {
-				offsetX = space
-			}
+		text = strings.Replace(text, `\n`, "\n", -1)
+		lineWidth = self.getTextWidth(text, pdfDoc)
+		space := availableWidth - lineWidth
+		if horizontalAlignment == HorizontalAlignmentCenter {
+			offsetX = (space / 2)
+		} else if horizontalAlignment == HorizontalAlignmentRight {
+			offsetX = space
 		}
-		self.Text = strings.Replace(self.Text, `\n`, "\n", -1)
-		pdfDoc.Fpdf.Text(x+offsetX, renderY, pdfDoc.Tr(self.Text))
+		pdfDoc.renderText(x+offsetX, renderY, text, letterSpacing)
 	}
 
-	if self.Style.base().Strikethrough {
-		if lineWidth == 0.0 {
-			lineWidth = pdfDoc.Fpdf.GetStringWidth(self.Text)
+	if (self.Style.base().Underline || self.Style.base().Strikethrough) && lineWidth > 0 {
+		metrics := pdfDoc.getFontMetrics(self.Style.base().Font, self.Style.base().FontStyle)
+		if self.Style.base().Underline {
+			pdfDoc.renderUnderline(x+offsetX, renderY, lineWidth, metrics, self.Style.base().FontSize)
+		}
+		if self.Style.base().Strikethrough {
+			pdfDoc.renderStrikethrough(x+offsetX, renderY, lineWidth, metrics, self.Style.base().FontSize)
 		}
-		// use underline thickness
-		strikethroughThickness := 1.0 // pdfDoc.Fpdf.CurrentFont["ut"]
-		renderY = y + self.Style.base().FontSize*0.5
-		strikethroughWidth := strikethroughThickness / 1000.0 * self.Style.base().FontSize
-		pdfDoc.Fpdf.SetLineWidth(strikethroughWidth)
-		pdfDoc.Fpdf.Line(x+offsetX, renderY, x+offsetX+lineWidth, renderY)
 	}
 
 	if self.Link != "" {
-		if lineWidth == 0.0 {
-			lineWidth = pdfDoc.Fpdf.GetStringWidth(self.Text)
-		}
 		linkID := pdfDoc.Fpdf.AddLink()
 		pdfDoc.Fpdf.Link(x+offsetX, y, lineWidth, self.Style.base().FontSize, linkID)
 	}
 }
 
+// renderRichTextPDF renders the fragments of a rich text line with their own styles
+func (self *TextLine) renderRichTextPDF(x float64, y float64, lastLine bool, pdfDoc *FPDFRB) {
+	renderY := y + self.FontSize*0.8
+	if self.Marker != nil {
+		// the marker is placed in front of the list item text, i.e. on the right side of right-to-left text
+		self.Marker.style.setFont(pdfDoc)
+		pdfDoc.Fpdf.SetTextColor(self.Marker.style.textColor.R, self.Marker.style.textColor.G, self.Marker.style.textColor.B)
+		markerX := x + self.Indent - self.Style.base().FontSize*richTextListIndent
+		if self.RTL {
+			markerX = x + self.Width - self.Indent + self.Style.base().FontSize*richTextListIndent -
+				getRichTextWidth(self.Marker.text, self.Marker.style, pdfDoc)
+		}
+		pdfDoc.renderText(markerX, renderY, self.Marker.text, self.Marker.style.letterSpacing)
+	}
+	if !self.RTL {
+		x += self.Indent
+	}
+	availableWidth := self.Width - self.Indent
+	fragments := getVisualFragments(self.Fragments, self.RTL, pdfDoc)
+	lineWidth := 0.0
+	spaces := 0
+	for _, fragment := range fragments {
+		lineWidth += fragment.width
+		if fragment.space {
+			spaces++
+		}
+	}
+	offsetX := 0.0
+	spaceWidth := 0.0
+	switch self.getHorizontalAlignment() {
+	case HorizontalAlignmentCenter:
+		offsetX = (availableWidth - lineWidth) / 2
+	case HorizontalAlignmentRight:
+		offsetX = availableWidth - lineWidth
+	case HorizontalAlignmentJustify:
+		if !lastLine && spaces > 0 {
+			// the remaining space of the line is distributed between the words
+			spaceWidth = (availableWidth - lineWidth) / float64(spaces)
+			lineWidth = availableWidth
+		} else if self.RTL {
+			offsetX = availableWidth - lineWidth
+		}
+	}
+	fragmentX := x + offsetX
+	positions := make([]float64, len(fragments))
+	widths := make([]float64, len(fragments))
+	for i, fragment := range fragments {
+		width := fragment.width
+		if fragment.space {
+			width += spaceWidth
+		}
+		fragment.renderPDF(fragmentX, y, renderY, width, self.FontSize, pdfDoc)
+		positions[i] = fragmentX
+		widths[i] = width
+		fragmentX += width
+	}
+	renderRichTextDecorations(fragments, positions, widths, renderY, pdfDoc)
+	if self.Link != "" && lineWidth > 0 {
+		pdfDoc.Fpdf.LinkString(x+offsetX, y, lineWidth, self.FontSize, self.Link)
+	}
+}
+
 func NewTextLine(text string, width float64, style Style, link string) TextLine {
 	textLine := TextLine{}
 	textLine.init(text, width, style, link)
@@ -1273,6 +1619,7 @@ type TableRow struct {
 	PrintIfResult            bool
 	PrevRow                  *TableRow
 	NextRow                  *TableRow
+	RunningValues            map[string]interface{}
 }
 
 func (self *TableRow) init(report *report, tableBand *TableBandElement, columns []int, ctx Context, prevRow *TableRow) {
@@ -1411,6 +1758,14 @@ func (self *TableRow) createRenderElements(offsetY float64, containerHeight floa
 
 func (self *TableRow) renderPDF(containerOffsetX float64, containerOffsetY float64, pdfDoc *FPDFRB) {
 	x := containerOffsetX
+	if self.TableBand != nil && self.TableBand.MirrorColumns {
+		// the first column is printed on the right side
+		for i := len(self.RenderElements) - 1; i >= 0; i-- {
+			self.RenderElements[i].renderPDF(x, containerOffsetY, pdfDoc)
+			x += self.RenderElements[i].base().Width
+		}
+		return
+	}
 	for _, renderElement := range self.RenderElements {
 		renderElement.renderPDF(x, containerOffsetY, pdfDoc)
 		x += renderElement.base().Width
@@ -1510,10 +1865,28 @@ func (self *TableBlockElement) addRows(rows []*TableRow, allowSplit bool, availa
 	return rowsAdded
 }
 
+// addRow adds the row even if the block is already complete, used for rows where
+// space was reserved in advance (e.g. the carried forward row)
+func (self *TableBlockElement) addRow(row *TableRow, offsetY float64, containerHeight float64, ctx Context, pdfDoc *FPDFRB) {
+	row.createRenderElements(offsetY, containerHeight, ctx, pdfDoc)
+	self.Rows = append(self.Rows, row)
+	self.Height += row.Height
+	self.RenderBottom += row.Height
+}
+
 func (self *TableBlockElement) isEmpty() bool {
 	return (len(self.Rows) == 0)
 }
 
+func (self *TableBlockElement) hasContentRows() bool {
+	for _, row := range self.Rows {
+		if row.TableBand != nil && row.TableBand.BandType == BandTypeContent {
+			return true
+		}
+	}
+	return false
+}
+
 func (self *TableBlockElement) renderPDF(containerOffsetX float64, containerOffsetY float64, pdfDoc *FPDFRB) {
 	y := containerOffsetY
 	for _, row := range self.Rows {
@@ -1550,7 +1923,11 @@ func (self *TableBlockElement) renderPDF(containerOffsetX float64, containerOffs
 			// add half borderWidth so border is drawn inside right column and can be aligned with
 			// borders of other elements outside the table
 			x := x1
-			for _, column := range columns[:len(columns)-1] {
+			for i := range columns[:len(columns)-1] {
+				column := columns[i]
+				if self.Rows[0].TableBand != nil && self.Rows[0].TableBand.MirrorColumns {
+					column = columns[len(columns)-1-i]
+				}
 				x += column.base().Width
 				pdfDoc.Fpdf.Line(x, y1, x, y2)
 			}
@@ -1574,15 +1951,148 @@ type TableElement struct {
 	PrintFooter            bool
 	ContentRows            []*TableBandElement
 	BorderWidth            float64
+	MirrorColumns          bool
 	RemoveEmptyElement     bool
 	SpreadsheetAddEmptyRow bool
 	DataSourceparameter    *Parameter
 	RowParameters          map[string]interface{}
-	Rows                   []interface{}
+	Rows                   *dataRows
 	PreparedRows           []*TableRow
-	RowCount               int
 	RowIndex               int
 	PrevContentRows        []*TableRow
+	Groups                 []*tableGroup
+	CarriedForward         *TableBandElement
+	BroughtForward         *TableBandElement
+	RunningVariables       []string
+	RunningAggregates      []*runningAggregate
+	RunningParameters      map[string]interface{}
+	RunningValues          map[string]interface{}
+	PageRunningValues      map[string]interface{}
+}
+
+// runningAggregate accumulates the value of a running variable, e.g. ${running.sum(amount)},
+// while the data rows of a table are processed
+type runningAggregate struct {
+	report         *report
+	Variable       string
+	Type           ParameterType
+	FieldParameter *Parameter
+	Count          int
+	Total          decimal.Decimal
+	Min            decimal.Decimal
+	Max            decimal.Decimal
+	First          interface{}
+	Last           interface{}
+	// values of aggregates which cannot be accumulated (median and distinct count)
+	Values []interface{}
+}
+
+func (self *runningAggregate) init(report *report, variable string, parameterType ParameterType, fieldParameter *Parameter) {
+	self.report = report
+	self.Variable = variable
+	self.Type = parameterType
+	self.FieldParameter = fieldParameter
+}
+
+func (self *runningAggregate) add(row map[string]interface{}) {
+	var value interface{} = row
+	if self.FieldParameter != nil {
+		value = row[self.FieldParameter.Name]
+	}
+	if value == nil {
+		return
+	}
+	if self.Count == 0 {
+		self.First = value
+	}
+	self.Last = value
+	self.Count++
+	if self.Type == ParameterTypeMedian || self.Type == ParameterTypeCountDistinct {
+		self.Values = append(self.Values, value)
+		return
+	}
+	if self.FieldParameter != nil && self.Type != ParameterTypeCount && self.Type != ParameterTypeFirst && self.Type != ParameterTypeLast {
+		number := self.report.getAggregateNumber(value, self.FieldParameter)
+		if self.Count == 1 || number.LessThan(self.Min) {
+			self.Min = number
+		}
+		if self.Count == 1 || number.GreaterThan(self.Max) {
+			self.Max = number
+		}
+		self.Total = self.Total.Add(number)
+	}
+}
+
+func (self *runningAggregate) value() interface{} {
+	switch self.Type {
+	case ParameterTypeCount:
+		return self.report.numberValue(decimal.NewFromInt(int64(self.Count)))
+	case ParameterTypeSum:
+		return self.report.numberValue(self.Total)
+	case ParameterTypeCountDistinct:
+		return self.report.aggregateValues(self.Type, self.Values, true, self.FieldParameter)
+	}
+	if self.Count == 0 {
+		// no values yet, same result as for aggregates of an empty list
+		return nil
+	}
+	switch self.Type {
+	case ParameterTypeAverage:
+		return self.report.numberValue(divideDecimal(self.Total, decimal.NewFromInt(int64(self.Count)), self.report.documentProperties.RoundingMode))
+	case ParameterTypeMin:
+		return self.report.numberValue(self.Min)
+	case ParameterTypeMax:
+		return self.report.numberValue(self.Max)
+	case ParameterTypeFirst:
+		return self.First
+	case ParameterTypeLast:
+		return self.Last
+	case ParameterTypeMedian:
+		return self.report.aggregateValues(self.Type, self.Values, true, self.FieldParameter)
+	}
+	return nil
+}
+
+func newRunningAggregate(report *report, variable string, parameterType ParameterType, fieldParameter *Parameter) *runningAggregate {
+	runningAggregate := runningAggregate{}
+	runningAggregate.init(report, variable, parameterType, fieldParameter)
+	return &runningAggregate
+}
+
+// parseAggregateVariable splits a variable like sum(amount) into aggregate and field name
+func parseAggregateVariable(variable string) (string, string) {
+	if pos := strings.Index(variable, "("); pos != -1 && strings.HasSuffix(variable, ")") {
+		return strings.TrimSpace(variable[:pos]), strings.TrimSpace(variable[pos+1 : len(variable)-1])
+	}
+	return strings.TrimSpace(variable), ""
+}
+
+func getAggregateType(variable string) ParameterType {
+	aggregate, _ := parseAggregateVariable(variable)
+	return getParameterType(aggregate)
+}
+
+// newVariableContext returns parameters and data of a map parameter with the given name which can be
+// pushed to the context to make computed variables available, e.g. ${group.count}
+func newVariableContext(name string, variables map[string]interface{}, values map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	children := make([]interface{}, 0)
+	for _, variable := range variables {
+		children = append(children, variable)
+	}
+	mapParameter := Parameter{Name: name, Type: ParameterTypeMap, Children: children, Fields: variables}
+	return map[string]interface{}{name: mapParameter}, map[string]interface{}{name: values}
+}
+
+// tableGroup is the range of data rows which have the same group expression value
+// for a content band with group expression. Parameters and Data contain the group variables,
+// aggregates are only used for bands after a group where the variables are accumulated row by row.
+type tableGroup struct {
+	StartRow   int
+	EndRow     int
+	Value      string
+	Parameters map[string]interface{}
+	Data       map[string]interface{}
+	aggregates []*runningAggregate
 }
 
 func (self *TableElement) init(report *report, data map[string]interface{}) {
@@ -1610,6 +2120,29 @@ func (self *TableElement) init(report *report, data map[string]interface{}) {
 	if footer {
 		self.Footer = NewTableBandElement(data["footerData"].(map[string]interface{}), BandTypeFooter, false)
 	}
+	// optional bands printed at the bottom of a page (carried forward) and at the top of the
+	// next page (brought forward) when the table is split, e.g. to show running totals
+	self.CarriedForward = nil
+	if bandData, ok := data["carriedForwardData"].(map[string]interface{}); ok && GetBoolValue(data, "carriedForward") {
+		self.CarriedForward = NewTableBandElement(bandData, BandTypeCarriedForward, false)
+	}
+	self.BroughtForward = nil
+	if bandData, ok := data["broughtForwardData"].(map[string]interface{}); ok && GetBoolValue(data, "broughtForward") {
+		self.BroughtForward = NewTableBandElement(bandData, BandTypeBroughtForward, false)
+	}
+	// columns are printed from right to left, e.g. for tables with right-to-left text
+	self.MirrorColumns = GetBoolValue(data, "mirrorColumns")
+	self.RunningVariables = make([]string, 0)
+	for _, band := range append([]*TableBandElement{self.header, self.Footer, self.CarriedForward, self.BroughtForward}, self.ContentRows...) {
+		if band != nil {
+			band.MirrorColumns = self.MirrorColumns
+			for _, variable := range band.RunningVariables {
+				if !inArray(variable, self.RunningVariables) {
+					self.RunningVariables = append(self.RunningVariables, variable)
+				}
+			}
+		}
+	}
 	if self.header != nil {
 		self.PrintHeader = true
 	}
@@ -1626,11 +2159,11 @@ func (self *TableElement) init(report *report, data map[string]interface{}) {
 	self.SpreadsheetAddEmptyRow = GetBoolValue(data, "spreadsheet_addEmptyRow")
 	self.DataSourceparameter = nil
 	self.RowParameters = map[string]interface{}{}
-	self.Rows = make([]interface{}, 0)
-	self.RowCount = 0
+	self.Rows = newStaticDataRows()
 	self.RowIndex = -1
 	self.PreparedRows = make([]*TableRow, 0)
 	self.PrevContentRows = make([]*TableRow, len(self.ContentRows))
+	self.Groups = make([]*tableGroup, len(self.ContentRows))
 	self.Width = 0
 	if self.header != nil {
 		self.Height += self.header.Height
@@ -1683,28 +2216,28 @@ func (self *TableElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool)
 			log.Println(Error{Message: "errorMsgMissingData", ObjectID: self.ID, Field: "data_source"})
 		}
 
-		if rows, ok := rows.([]interface{}); ok {
-			self.Rows = rows
-		} else {
+		var ok bool
+		if self.Rows, ok = newDataRows(rows); !ok {
 			log.Println(Error{Message: "errorMsgInvalidDataSource", ObjectID: self.ID, Field: "data_source"})
 		}
 	} else {
 		// there is no data source parameter so we create a static table (faked by one empty data row)
-		self.Rows = make([]interface{}, 1)
-		self.Rows[0] = map[string]interface{}{}
+		self.Rows = newStaticDataRows()
 	}
 
-	self.RowCount = len(self.Rows)
 	self.RowIndex = 0
+	self.Groups = make([]*tableGroup, len(self.ContentRows))
+	self.initRunningAggregates()
 
 	if onlyVerify {
 		if self.PrintHeader {
 			tableRow := NewTableRow(self.Report, self.header, self.Columns, ctx, nil)
 			tableRow.prepare(ctx, nil, 0, true)
 		}
-		for self.RowIndex < self.RowCount {
+		// rows of a stream are not verified because they can only be read once
+		for self.Rows.stream == nil && self.Rows.has(self.RowIndex) {
 			// push data context of current row so values of current row can be accessed
-			ctx.pushContext(self.RowParameters, self.Rows[self.RowIndex].(map[string]interface{}))
+			ctx.pushContext(self.RowParameters, self.Rows.get(self.RowIndex))
 			for _, contentRow := range self.ContentRows {
 				tableRow := NewTableRow(self.Report, contentRow, self.Columns, ctx, nil)
 				tableRow.prepare(ctx, nil, self.RowIndex, true)
@@ -1735,35 +2268,74 @@ func (self *TableElement) getNextRenderElement(offsetY float64, containerHeight
 	batchSize := 10
 	remainingBatchSize := batchSize
 
+	// add brought forward row with running values of the last row on the previous page
+	// in case the table continues from a previous page
+	if self.BroughtForward != nil && !self.FirstRenderElement && !self.hasPreparedBand(BandTypeBroughtForward) {
+		tableRow := self.prepareRow(self.BroughtForward, self.PageRunningValues, -1, ctx, pdfDoc)
+		if tableRow.isPrinted(ctx) {
+			self.PreparedRows = append([]*TableRow{tableRow}, self.PreparedRows...)
+		}
+	}
+
 	// add header in case it is not already available in prepared rows (from previous page)
 	if self.PrintHeader && (len(self.PreparedRows) == 0 || self.PreparedRows[0].TableBand.BandType != BandTypeHeader) {
-		tableRow := NewTableRow(self.Report, self.header, self.Columns, ctx, nil)
-		tableRow.prepare(ctx, pdfDoc, 0, false)
+		tableRow := self.prepareRow(self.header, self.RunningValues, 0, ctx, pdfDoc)
 		self.PreparedRows = append([]*TableRow{tableRow}, self.PreparedRows...)
 		if !self.header.RepeatHeader {
 			self.PrintHeader = false
 		}
 	}
 
-	for self.RowIndex < self.RowCount {
+	for self.Rows.has(self.RowIndex) {
+		// group variables must be computed before the row context is pushed because
+		// the group rows are evaluated with their own data context
+		groupData := make([]map[string]interface{}, len(self.ContentRows))
+		groupParameters := make([]map[string]interface{}, len(self.ContentRows))
+		for i, contentRow := range self.ContentRows {
+			if len(contentRow.GroupVariables) == 0 {
+				continue
+			}
+			if contentRow.BeforeGroup {
+				group := self.updateGroup(i, contentRow, ctx)
+				groupParameters[i], groupData[i] = group.Parameters, group.Data
+			} else {
+				group := self.addGroupRow(i, contentRow, ctx)
+				groupParameters[i], groupData[i] = group.Parameters, group.Data
+			}
+		}
+		runningValues := self.addRunningRow(self.Rows.get(self.RowIndex))
 		// push data context of current row so values of current row can be accessed
-		ctx.pushContext(self.RowParameters, self.Rows[self.RowIndex].(map[string]interface{}))
+		ctx.pushContext(self.RowParameters, self.Rows.get(self.RowIndex))
+		if runningValues != nil {
+			ctx.pushContext(newVariableContext("running", self.RunningParameters, runningValues))
+		}
 		for i, contentRow := range self.ContentRows {
 			var prevRow *TableRow
 			if self.PrevContentRows[i] != nil {
 				prevRow = self.PrevContentRows[i]
 			}
+			if groupData[i] != nil {
+				ctx.pushContext(groupParameters[i], groupData[i])
+			}
 			tableRow := NewTableRow(self.Report, contentRow, self.Columns, ctx, prevRow)
 			tableRow.prepare(ctx, pdfDoc, self.RowIndex, false)
+			tableRow.RunningValues = runningValues
+			if groupData[i] != nil {
+				ctx.popContext()
+			}
 			self.PreparedRows = append(self.PreparedRows, tableRow)
 			self.PrevContentRows[i] = tableRow
 		}
+		if runningValues != nil {
+			ctx.popContext()
+		}
 		ctx.popContext()
 		remainingBatchSize--
 		self.RowIndex++
+		self.releaseRows()
 		if remainingBatchSize == 0 {
 			remainingBatchSize = batchSize
-			if self.RowIndex < self.RowCount || self.PrintFooter {
+			if self.Rows.has(self.RowIndex) || self.PrintFooter {
 				self.updateRenderElement(renderElement, offsetY, containerHeight, ctx, pdfDoc)
 				if renderElement.Complete {
 					break
@@ -1772,9 +2344,8 @@ func (self *TableElement) getNextRenderElement(offsetY float64, containerHeight
 		}
 	}
 
-	if self.RowIndex >= self.RowCount && self.PrintFooter {
-		tableRow := NewTableRow(self.Report, self.Footer, self.Columns, ctx, nil)
-		tableRow.prepare(ctx, pdfDoc, 0, false)
+	if !self.Rows.has(self.RowIndex) && self.PrintFooter {
+		tableRow := self.prepareRow(self.Footer, self.RunningValues, 0, ctx, pdfDoc)
 		self.PreparedRows = append(self.PreparedRows, tableRow)
 		self.PrintFooter = false
 	}
@@ -1783,6 +2354,13 @@ func (self *TableElement) getNextRenderElement(offsetY float64, containerHeight
 
 	if self.isRenderingComplete() {
 		self.RenderingComplete = true
+	} else if self.CarriedForward != nil && !renderElement.isEmpty() {
+		// table continues on next page, space for the carried forward row was reserved
+		// when the rows were added
+		tableRow := self.prepareRow(self.CarriedForward, self.PageRunningValues, -1, ctx, pdfDoc)
+		if tableRow.isPrinted(ctx) {
+			renderElement.addRow(tableRow, offsetY, containerHeight, ctx, pdfDoc)
+		}
 	}
 
 	if renderElement.isEmpty() {
@@ -1798,7 +2376,7 @@ func (self *TableElement) updateRenderElement(renderElement DocElementBaseProvid
 	availableHeight := containerHeight - offsetY
 	filteredRows := make([]*TableRow, 0)
 	rowsForNextUpdate := make([]*TableRow, 0)
-	allRowsProcessed := (self.RowIndex >= self.RowCount)
+	allRowsProcessed := !self.Rows.has(self.RowIndex)
 	for _, preparedRow := range self.PreparedRows {
 		if preparedRow.TableBand != nil && preparedRow.TableBand.BandType == BandTypeContent {
 			if preparedRow.NextRow != nil || allRowsProcessed {
@@ -1819,18 +2397,41 @@ func (self *TableElement) updateRenderElement(renderElement DocElementBaseProvid
 			// make sure header row is not printed alone on a page
 			addRowCount = 2
 		}
+		if addRowCount > len(filteredRows) {
+			addRowCount = len(filteredRows)
+		}
 		// allow splitting multiple rows (header + content or footer) in case we are already at top
 		// of the container and there is not enough space for both rows
 		allowSplit := (offsetY == 0)
 		height := availableHeight - renderElement.base().Height
-
-		if addRowCount > len(filteredRows) {
-			addRowCount = len(filteredRows)
+		pageHeight := containerHeight
+		if self.CarriedForward != nil && !(allRowsProcessed && !self.PrintFooter && getRowsHeight(filteredRows) <= height) {
+			// space for the carried forward row is reserved unless the remaining rows complete the table on this page
+			carriedForwardHeight := self.getCarriedForwardHeight(filteredRows[:addRowCount], ctx, pdfDoc)
+			height -= carriedForwardHeight
+			pageHeight -= carriedForwardHeight
+		}
+		groupRowCount, groupPrepared := self.getKeptGroupRowCount(filteredRows, allRowsProcessed, pageHeight)
+		if !groupPrepared {
+			// wait until all rows of the group are prepared
+			break
 		}
+		if groupRowCount > 0 && (offsetY != 0 || renderElement.(*TableBlockElement).hasContentRows()) {
+			// the group is moved to the next page if it does not fit, it is only split if
+			// it starts at the top of the page
+			addRowCount = groupRowCount
+			allowSplit = false
+		}
+
 		rowsAdded := renderElement.addRows(filteredRows[:addRowCount], allowSplit, height, offsetY, containerHeight, ctx, pdfDoc)
 		if rowsAdded == 0 {
 			break
 		}
+		for _, row := range filteredRows[:rowsAdded] {
+			if row.RunningValues != nil && row.TableBand.BandType == BandTypeContent {
+				self.PageRunningValues = row.RunningValues
+			}
+		}
 		filteredRows = filteredRows[rowsAdded:]
 		self.FirstRenderElement = false
 	}
@@ -1841,8 +2442,261 @@ func (self *TableElement) updateRenderElement(renderElement DocElementBaseProvid
 	}
 }
 
+// getCarriedForwardHeight returns the height of the carried forward row printed after the given rows
+// in case the page ends after them, 0 is returned if the row is not printed because of its printIf expression
+func (self *TableElement) getCarriedForwardHeight(rows []*TableRow, ctx Context, pdfDoc *FPDFRB) float64 {
+	runningValues := self.PageRunningValues
+	for _, row := range rows {
+		if row.RunningValues != nil && row.TableBand.BandType == BandTypeContent {
+			runningValues = row.RunningValues
+		}
+	}
+	tableRow := self.prepareRow(self.CarriedForward, runningValues, -1, ctx, pdfDoc)
+	if !tableRow.isPrinted(ctx) {
+		return 0
+	}
+	return tableRow.Height
+}
+
+func getRowsHeight(rows []*TableRow) float64 {
+	height := 0.0
+	for _, row := range rows {
+		height += row.Height
+	}
+	return height
+}
+
+// getKeptGroupRowCount returns the number of rows from the start of the given rows up to the end of a group
+// whose header band should be kept together with its rows. Leading header rows are included. 0 is returned if
+// the rows do not start with such a group or the group does not fit into the given height. prepared is false
+// if the end of the group is not known yet because the following rows are not prepared.
+func (self *TableElement) getKeptGroupRowCount(rows []*TableRow, allRowsProcessed bool, maxHeight float64) (int, bool) {
+	start := 0
+	for start < len(rows) && rows[start].TableBand.BandType != BandTypeContent {
+		start++
+	}
+	if start == len(rows) {
+		return 0, true
+	}
+	band := rows[start].TableBand
+	if !band.KeepTogether || band.GroupExpression == "" || !band.BeforeGroup {
+		return 0, true
+	}
+	height := 0.0
+	for i, row := range rows {
+		if i > start && (row.TableBand == band || row.TableBand.BandType == BandTypeFooter) {
+			return i, true
+		}
+		height += row.Height
+		if height > maxHeight {
+			// group is larger than a page and must be split anyway
+			return 0, true
+		}
+	}
+	if allRowsProcessed {
+		return len(rows), true
+	}
+	return 0, false
+}
+
+// restart is not supported for tables, data rows can be streamed and are only read once
+func (self *TableElement) restart(ctx Context, pdfDoc *FPDFRB) bool {
+	return false
+}
+
+func (self *TableElement) hasPreparedBand(bandType BandType) bool {
+	for _, row := range self.PreparedRows {
+		if row.TableBand != nil && row.TableBand.BandType == bandType {
+			return true
+		}
+	}
+	return false
+}
+
+// updateGroup returns the group of the current row for a content band before a group. All following rows
+// with the same group expression value are added to a new group, the group variables are computed from
+// all rows of the group because the band is printed before the rows. Therefore all rows of a group
+// are kept in memory if the rows are read from a RowSource.
+func (self *TableElement) updateGroup(bandIndex int, band *TableBandElement, ctx Context) *tableGroup {
+	group := self.Groups[bandIndex]
+	if group != nil && self.RowIndex >= group.StartRow && self.RowIndex <= group.EndRow {
+		return group
+	}
+	group = &tableGroup{StartRow: self.RowIndex, EndRow: self.RowIndex, Value: self.getGroupValue(band, self.RowIndex, ctx)}
+	for self.Rows.has(group.EndRow+1) && self.getGroupValue(band, group.EndRow+1, ctx) == group.Value {
+		group.EndRow++
+	}
+	group.Parameters, group.Data = self.getGroupContext(band, group)
+	self.Groups[bandIndex] = group
+	return group
+}
+
+// addGroupRow adds the current row to the group of a content band after a group and returns the group.
+// A new group is started if the group expression value differs from the previous row. The group
+// variables are accumulated so they contain all rows of the group when the band is printed
+// at the last row of the group, the rows do not have to be kept in memory.
+func (self *TableElement) addGroupRow(bandIndex int, band *TableBandElement, ctx Context) *tableGroup {
+	value := self.getGroupValue(band, self.RowIndex, ctx)
+	group := self.Groups[bandIndex]
+	if group == nil || group.Value != value || group.EndRow != self.RowIndex-1 {
+		group = &tableGroup{StartRow: self.RowIndex, Value: value, aggregates: make([]*runningAggregate, 0)}
+		variables := make(map[string]interface{}, 0)
+		for _, variable := range band.GroupVariables {
+			variableParameter, fieldParameter, ok := self.getVariableParameter(variable)
+			if !ok {
+				log.Println(Error{Message: "errorMsgInvalidGroupVariable", ObjectID: band.ID, Field: "group_expression", Info: variable})
+				continue
+			}
+			variables[variable] = variableParameter
+			group.aggregates = append(group.aggregates, newRunningAggregate(self.Report, variable, getAggregateType(variable), fieldParameter))
+		}
+		group.Parameters, group.Data = newVariableContext("group", variables, nil)
+		self.Groups[bandIndex] = group
+	}
+	group.EndRow = self.RowIndex
+	values := make(map[string]interface{}, 0)
+	for _, aggregate := range group.aggregates {
+		aggregate.add(self.Rows.get(self.RowIndex))
+		values[aggregate.Variable] = aggregate.value()
+	}
+	group.Data = map[string]interface{}{"group": values}
+	return group
+}
+
+func (self *TableElement) getGroupValue(band *TableBandElement, rowIndex int, ctx Context) string {
+	ctx.pushContext(self.RowParameters, self.Rows.get(rowIndex))
+	value := cast.ToString(ctx.evaluateExpression(band.GroupExpression, band.ID, "group_expression"))
+	ctx.popContext()
+	return value
+}
+
+// getGroupContext computes the group variables used in the band and returns parameters and data
+// of the "group" map which is pushed as context when preparing the band, e.g. ${group.count},
+// ${group.sum(amount)} or ${group.first(name)}
+func (self *TableElement) getGroupContext(band *TableBandElement, group *tableGroup) (map[string]interface{}, map[string]interface{}) {
+	variables := make(map[string]interface{}, 0)
+	values := make(map[string]interface{}, 0)
+	for _, variable := range band.GroupVariables {
+		variableParameter, fieldParameter, ok := self.getVariableParameter(variable)
+		if !ok {
+			log.Println(Error{Message: "errorMsgInvalidGroupVariable", ObjectID: band.ID, Field: "group_expression", Info: variable})
+			continue
+		}
+		fieldName := ""
+		if fieldParameter != nil {
+			fieldName = fieldParameter.Name
+		}
+		groupValues := make([]interface{}, 0)
+		for rowIndex := group.StartRow; rowIndex <= group.EndRow; rowIndex++ {
+			row := self.Rows.get(rowIndex)
+			if fieldName == "" {
+				groupValues = append(groupValues, row)
+			} else {
+				groupValues = append(groupValues, row[fieldName])
+			}
+		}
+		variables[variable] = variableParameter
+		values[variable] = self.Report.aggregateValues(getAggregateType(variable), groupValues, fieldParameter != nil && fieldParameter.Nullable, fieldParameter)
+	}
+	return newVariableContext("group", variables, values)
+}
+
+// getVariableParameter returns the parameter used to format an aggregate variable, e.g. sum(amount), and
+// the row parameter of the aggregated field. The variable parameter gets the pattern of the field.
+func (self *TableElement) getVariableParameter(variable string) (Parameter, *Parameter, bool) {
+	aggregate, fieldName := parseAggregateVariable(variable)
+	parameterType := getParameterType(aggregate)
+	if !parameterType.isAggregate() || (fieldName == "" && parameterType != ParameterTypeCount) {
+		return Parameter{}, nil, false
+	}
+	variableParameter := Parameter{Name: variable, Type: parameterType, Nullable: true}
+	if fieldName == "" {
+		return variableParameter, nil, true
+	}
+	fieldParameter, ok := self.RowParameters[fieldName].(Parameter)
+	if !ok {
+		return Parameter{}, nil, false
+	}
+	variableParameter.Pattern = fieldParameter.Pattern
+	variableParameter.PatternHasCurrency = fieldParameter.PatternHasCurrency
+	variableParameter.Currency = fieldParameter.Currency
+	if parameterType == ParameterTypeFirst || parameterType == ParameterTypeLast {
+		variableParameter.Type = fieldParameter.Type
+	}
+	return variableParameter, &fieldParameter, true
+}
+
+// initRunningAggregates creates the accumulators for all running variables used in the table
+func (self *TableElement) initRunningAggregates() {
+	self.RunningAggregates = make([]*runningAggregate, 0)
+	self.RunningParameters = make(map[string]interface{}, 0)
+	self.RunningValues = nil
+	self.PageRunningValues = nil
+	for _, variable := range self.RunningVariables {
+		variableParameter, fieldParameter, ok := self.getVariableParameter(variable)
+		parameterType := getAggregateType(variable)
+		if !ok || parameterType == ParameterTypeMedian || parameterType == ParameterTypeCountDistinct {
+			log.Println(Error{Message: "errorMsgInvalidRunningVariable", ObjectID: self.ID, Field: "running", Info: variable})
+			continue
+		}
+		aggregate := newRunningAggregate(self.Report, variable, parameterType, fieldParameter)
+		self.RunningAggregates = append(self.RunningAggregates, aggregate)
+		self.RunningParameters[variable] = variableParameter
+	}
+	if len(self.RunningAggregates) > 0 {
+		self.RunningValues = self.getRunningValues()
+	}
+}
+
+// addRunningRow adds the data row to all running variables and returns the running values after the row
+func (self *TableElement) addRunningRow(row map[string]interface{}) map[string]interface{} {
+	if len(self.RunningAggregates) == 0 {
+		return nil
+	}
+	for _, aggregate := range self.RunningAggregates {
+		aggregate.add(row)
+	}
+	self.RunningValues = self.getRunningValues()
+	return self.RunningValues
+}
+
+func (self *TableElement) getRunningValues() map[string]interface{} {
+	values := make(map[string]interface{}, len(self.RunningAggregates))
+	for _, aggregate := range self.RunningAggregates {
+		values[aggregate.Variable] = aggregate.value()
+	}
+	return values
+}
+
+// prepareRow creates and prepares a table row, the running variables with the given values are available
+// as ${running.sum(amount)} etc.
+func (self *TableElement) prepareRow(band *TableBandElement, runningValues map[string]interface{}, rowIndex int, ctx Context, pdfDoc *FPDFRB) *TableRow {
+	if runningValues != nil {
+		ctx.pushContext(newVariableContext("running", self.RunningParameters, runningValues))
+	}
+	tableRow := NewTableRow(self.Report, band, self.Columns, ctx, nil)
+	tableRow.prepare(ctx, pdfDoc, rowIndex, false)
+	tableRow.RunningValues = runningValues
+	if runningValues != nil {
+		ctx.popContext()
+	}
+	return tableRow
+}
+
 func (self *TableElement) isRenderingComplete() bool {
-	return ((!self.PrintHeader || (self.header != nil && self.header.RepeatHeader)) && !self.PrintFooter && self.RowIndex >= self.RowCount && len(self.PreparedRows) == 0)
+	return ((!self.PrintHeader || (self.header != nil && self.header.RepeatHeader)) && !self.PrintFooter && !self.Rows.has(self.RowIndex) && len(self.PreparedRows) == 0)
+}
+
+// releaseRows releases the rows of a streamed data source which were already processed,
+// rows of the current groups are kept because they are needed for the group variables
+func (self *TableElement) releaseRows() {
+	index := self.RowIndex
+	for _, group := range self.Groups {
+		if group != nil && group.EndRow >= self.RowIndex && group.StartRow < index {
+			index = group.StartRow
+		}
+	}
+	self.Rows.release(index)
 }
 
 func (self *TableElement) renderSpreadsheet() {
@@ -1902,6 +2756,9 @@ func NewTableElement(report *report, data map[string]interface{}) *TableElement
 	return &tableElement
 }
 
+var regexGroupVariable = regexp.MustCompile(`\$\{\s*group\.([^}]+)\}`)
+var regexRunningVariable = regexp.MustCompile(`\$\{\s*running\.([^}]+)\}`)
+
 type TableBandElement struct {
 	ID                       int
 	Height                   float64
@@ -1913,11 +2770,15 @@ type TableBandElement struct {
 	GroupExpression          string
 	PrintIf                  string
 	BeforeGroup              bool
+	KeepTogether             bool
+	MirrorColumns            bool
+	GroupVariables           []string
+	RunningVariables         []string
 }
 
 func (self *TableBandElement) init(data map[string]interface{}, bandType BandType, beforeGroup bool) {
 	self.ID = GetIntValue(data, "id")
-	self.Height = float64(GetIntValue(data, "height"))
+	self.Height = GetFloatValue(data, "height")
 	self.BandType = bandType
 	if bandType == BandTypeHeader {
 		self.RepeatHeader = GetBoolValue(data, "repeatHeader")
@@ -1932,6 +2793,34 @@ func (self *TableBandElement) init(data map[string]interface{}, bandType BandTyp
 	self.GroupExpression = GetStringValue(data, "groupExpression")
 	self.PrintIf = GetStringValue(data, "printIf")
 	self.BeforeGroup = beforeGroup
+	// a group header band with keepTogether is printed on the same page as all rows of the group
+	self.KeepTogether = GetBoolValue(data, "keepTogether")
+	self.GroupVariables = make([]string, 0)
+	if self.GroupExpression != "" {
+		// collect group variables (e.g. ${group.sum(amount)}) used in the band so they
+		// can be computed for each group
+		self.GroupVariables = self.getVariables(regexGroupVariable)
+	}
+	self.RunningVariables = self.getVariables(regexRunningVariable)
+}
+
+// getVariables returns all distinct variable names of the given regex used in column contents and conditions
+func (self *TableBandElement) getVariables(regex *regexp.Regexp) []string {
+	variables := make([]string, 0)
+	expressions := []string{self.PrintIf}
+	for _, column := range self.ColumnData {
+		if columnData, ok := column.(map[string]interface{}); ok {
+			expressions = append(expressions, GetStringValue(columnData, "content"), GetStringValue(columnData, "printIf"), GetStringValue(columnData, "cs_condition"))
+		}
+	}
+	for _, expression := range expressions {
+		for _, match := range regex.FindAllStringSubmatch(expression, -1) {
+			if !inArray(match[1], variables) {
+				variables = append(variables, match[1])
+			}
+		}
+	}
+	return variables
 }
 
 func NewTableBandElement(data map[string]interface{}, bandType BandType, beforeGroup bool) *TableBandElement {
@@ -2160,6 +3049,11 @@ func (self *FrameElement) getNextRenderElement(offsetY float64, containerHeight
 	return renderElement, self.RenderingComplete
 }
 
+func (self *FrameElement) restart(ctx Context, pdfDoc *FPDFRB) bool {
+	self.prepare(ctx, pdfDoc, false)
+	return self.DocElementBase.restart(ctx, pdfDoc)
+}
+
 func (self *FrameElement) renderSpreadsheet(row int, col int, ctx Context, renderer Renderer) (int, int) {
 	if self.SpreadsheetColumn != 0 {
 		col = self.SpreadsheetColumn - 1
@@ -2198,7 +3092,7 @@ type SectionBandElement struct {
 func (self *SectionBandElement) init(report *report, data map[string]interface{}, bandType BandType, containers *containers) {
 	self.ID = GetStringValue(data, "id")
 	self.Width = report.documentProperties.pageWidth - report.documentProperties.marginBottom - report.documentProperties.marginRight
-	self.Height = float64(GetIntValue(data, "height"))
+	self.Height = GetFloatValue(data, "height")
 	self.BandType = bandType
 	if bandType == BandTypeHeader {
 		self.RepeatHeader = GetBoolValue(data, "repeatHeader")
@@ -2209,7 +3103,12 @@ func (self *SectionBandElement) init(report *report, data map[string]interface{}
 	}
 	self.ShrinkToContentHeight = GetBoolValue(data, "shrinkToContentHeight")
 
-	self.Container = NewContainer(GetStringValue(data, "linkedContainerId"), containers, report)
+	containerID := GetStringValue(data, "linkedContainerId")
+	if containerID == "" {
+		containerID = cast.ToString(GetIntValue(data, "linkedContainerId"))
+	}
+	// init the container in place (like frames) so elements are added to the container of the band
+	self.Container.init(containerID, containers, report)
 	self.Container.Width = self.Width
 	self.Container.Height = self.Height
 	self.Container.AllowPageBreak = false
@@ -2228,7 +3127,7 @@ func (self *SectionBandElement) createRenderElements(offsetY float64, containerH
 		self.RenderingComplete = false
 	} else {
 		if self.PrepareContainer {
-			// self.Container.prepare(ctx, pdfDoc)
+			self.Container.prepare(ctx, pdfDoc, false)
 			self.RenderedBandHeight = 0
 		} else {
 			self.RenderedBandHeight += self.Container.UsedBandHeight
@@ -2331,7 +3230,7 @@ func (self *SectionBlockElement) renderPDF(containerOffsetX float64, containerOf
 	y := self.RenderY + containerOffsetY
 	for _, band := range self.Bands {
 		for _, element := range band.Elements {
-			element.renderPDF(containerOffsetX, containerOffsetY, pdfDoc)
+			element.renderPDF(containerOffsetX, y, pdfDoc)
 		}
 		y += band.Height
 	}
@@ -2352,9 +3251,8 @@ type SectionElement struct {
 	PrintHeader         bool
 	DataSourceparameter *Parameter
 	RowParameters       map[string]interface{}
-	RowCount            int
 	RowIndex            int
-	Rows                map[int]interface{}
+	Rows                *dataRows
 }
 
 func (self *SectionElement) initSectionElement(report *report, data map[string]interface{}, containers *containers) {
@@ -2390,7 +3288,7 @@ func (self *SectionElement) initSectionElement(report *report, data map[string]i
 
 	self.DataSourceparameter = nil
 	self.RowParameters = make(map[string]interface{}, 0)
-	self.RowCount = 0
+	self.Rows = newStaticDataRows()
 	self.RowIndex = -1
 }
 
@@ -2411,25 +3309,25 @@ func (self *SectionElement) prepare(ctx Context, pdfDoc *FPDFRB, onlyVerify bool
 	}
 
 	rows, parameterExists := ctx.getData(self.DataSourceparameter.Name, nil)
-	self.Rows = rows.(map[int]interface{})
 	if parameterExists == false {
 		// raise ReportBroError(Error("errorMsgMissingData", self.ID, "data_source"))
 	}
 
-	// if not isinstance(self.rows, list):
-	//     raise ReportBroError(
-	//         Error("errorMsgInvalidDataSource", self.ID, "data_source"))
+	var ok bool
+	if self.Rows, ok = newDataRows(rows); !ok {
+		log.Println(Error{Message: "errorMsgInvalidDataSource", ObjectID: self.ID, Field: "data_source"})
+	}
 
-	self.RowCount = len(self.Rows)
 	self.RowIndex = 0
 
 	if onlyVerify {
 		if self.Header != nil {
 			self.Header.prepare(ctx, nil, true)
 		}
-		for self.RowIndex < self.RowCount {
+		// rows of a stream are not verified because they can only be read once
+		for self.Rows.stream == nil && self.Rows.has(self.RowIndex) {
 			// push data context of current row so values of current row can be accessed
-			ctx.pushContext(self.RowParameters, self.Rows[self.RowIndex].(map[string]interface{}))
+			ctx.pushContext(self.RowParameters, self.Rows.get(self.RowIndex))
 			self.Content.prepare(ctx, nil, true)
 			ctx.popContext()
 			self.RowIndex += 1
@@ -2456,9 +3354,9 @@ func (self *SectionElement) getNextRenderElement(offsetY float64, containerHeigh
 		}
 	}
 
-	for self.RowIndex < self.RowCount {
+	for self.Rows.has(self.RowIndex) {
 		// push data context of current row so values of current row can be accessed
-		ctx.pushContext(self.RowParameters, self.Rows[self.RowIndex].(map[string]interface{}))
+		ctx.pushContext(self.RowParameters, self.Rows.get(self.RowIndex))
 		self.Content.createRenderElements(offsetY+renderElement.Height, containerHeight, ctx, pdfDoc)
 		ctx.popContext()
 		renderElement.addSectionBand(*self.Content)
@@ -2466,6 +3364,7 @@ func (self *SectionElement) getNextRenderElement(offsetY float64, containerHeigh
 			return renderElement, false
 		}
 		self.RowIndex += 1
+		self.Rows.release(self.RowIndex)
 	}
 
 	if self.Footer != nil {
@@ -2482,6 +3381,22 @@ func (self *SectionElement) getNextRenderElement(offsetY float64, containerHeigh
 	return renderElement, true
 }
 
+func (self *SectionElement) restart(ctx Context, pdfDoc *FPDFRB) bool {
+	if self.Rows.stream != nil {
+		// rows of a stream can only be read once
+		return false
+	}
+	self.RowIndex = 0
+	self.PrintHeader = (self.Header != nil)
+	for _, band := range []*SectionBandElement{self.Header, self.Content, self.Footer} {
+		if band != nil {
+			band.RenderingComplete = false
+			band.PrepareContainer = true
+		}
+	}
+	return self.DocElementBase.restart(ctx, pdfDoc)
+}
+
 func (self *SectionElement) renderSpreadsheet(row int, col int, ctx Context, renderer Renderer) (int, int) {
 	if self.Header != nil {
 		row, _ = self.Header.Container.RenderSpreadsheet(row, col, ctx, renderer)
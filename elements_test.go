@@ -0,0 +1,291 @@
+package reportbro
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// sliceRowSource is a RowSource which returns the given rows
+type sliceRowSource struct {
+	rows []map[string]interface{}
+}
+
+func (self *sliceRowSource) Next() (map[string]interface{}, bool, error) {
+	if len(self.rows) == 0 {
+		return nil, false, nil
+	}
+	row := self.rows[0]
+	self.rows = self.rows[1:]
+	return row, true, nil
+}
+
+var pdfTextRegex = regexp.MustCompile(`\(((?:[^()\\]|\\.)*)\) Tj`)
+
+// getPDFTexts returns the texts of all text operators in the compressed content streams of a pdf document
+func getPDFTexts(t *testing.T, pdf []byte) []string {
+	t.Helper()
+	texts := make([]string, 0)
+	for _, pageTexts := range getPDFPageTexts(t, pdf) {
+		texts = append(texts, pageTexts...)
+	}
+	return texts
+}
+
+// getPDFPageTexts returns the texts of each compressed content stream, i.e. of each page
+func getPDFPageTexts(t *testing.T, pdf []byte) [][]string {
+	t.Helper()
+	pages := make([][]string, 0)
+	for _, stream := range regexp.MustCompile(`(?s)stream\r?\n(.*?)endstream`).FindAllSubmatch(pdf, -1) {
+		reader, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			continue
+		}
+		content, _ := io.ReadAll(reader)
+		texts := make([]string, 0)
+		for _, match := range pdfTextRegex.FindAllSubmatch(content, -1) {
+			texts = append(texts, string(match[1]))
+		}
+		if len(texts) > 0 {
+			pages = append(pages, texts)
+		}
+	}
+	return pages
+}
+
+func TestTableGroupVariables(t *testing.T) {
+	const parameters = `[{"id":1,"name":"orders","type":"array","children":[
+		{"id":2,"name":"customer","type":"string"},
+		{"id":3,"name":"amount","type":"number"}]}]`
+	band := func(id int, groupExpression string, content string) string {
+		return fmt.Sprintf(`{"id":%d,"height":20,"groupExpression":%q,"columnData":[
+			{"id":%d,"content":%q,"width":300,"height":20,"fontSize":10,"lineSpacing":1}]}`, id, groupExpression, id+1, content)
+	}
+	table := `{"id":100,"elementType":"table","containerId":"0_content","x":0,"y":0,"width":300,"height":20,
+		"dataSource":"${orders}","columns":1,"header":false,"footer":false,"contentDataRows":[` +
+		band(110, "${customer}", "${customer}: ${group.count} ${group.first(amount)}") + "," +
+		band(120, "", "${amount}") + "," +
+		band(130, "${customer}", "${group.sum(amount)} ${group.max(amount)} ${group.median(amount)}") + `]}`
+	js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","marginLeft":20,"marginTop":20,"marginRight":20,"marginBottom":20},
+		"parameters":` + parameters + `,"styles":[],"docElements":[` + table + `],"version":2}`
+	rows := []map[string]interface{}{
+		{"customer": "A", "amount": 1}, {"customer": "A", "amount": 4}, {"customer": "A", "amount": 2},
+		{"customer": "B", "amount": 10},
+		{"customer": "A", "amount": 5},
+	}
+	want := []string{"A: 3 1", "1", "4", "2", "7 4 2", "B: 1 10", "10", "10 10 10", "A: 1 5", "5", "5 5 5"}
+
+	arrayRows := make([]interface{}, 0)
+	for _, row := range rows {
+		arrayRows = append(arrayRows, row)
+	}
+	tests := []struct {
+		name   string
+		orders interface{}
+	}{
+		{"array", arrayRows},
+		{"row source", &sliceRowSource{rows: rows}},
+	}
+	for _, test := range tests {
+		var definition map[string]interface{}
+		if err := json.Unmarshal([]byte(js), &definition); err != nil {
+			t.Fatal(err)
+		}
+		report := NewReport(definition, map[string]interface{}{"orders": test.orders}, false, "", nil)
+		pdf, err := report.GeneratePDF(false)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := getPDFTexts(t, pdf); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: texts = %q, want %q", test.name, got, want)
+		}
+	}
+}
+
+func TestTableCarriedForward(t *testing.T) {
+	band := func(id int, content string, printIf string) string {
+		return fmt.Sprintf(`{"id":%d,"height":20,"printIf":%q,"columnData":[
+			{"id":%d,"content":%q,"width":300,"height":20,"fontSize":10,"lineSpacing":1}]}`, id, printIf, id+1, content)
+	}
+	// the content height of 800 points holds 40 rows of 20 points
+	newDefinition := func(carriedForward string, broughtForward string) map[string]interface{} {
+		table := `{"id":100,"elementType":"table","containerId":"0_content","x":0,"y":0,"width":300,"height":20,
+			"dataSource":"${items}","columns":1,"header":false,"footer":false,
+			"contentDataRows":[` + band(110, "${amount}", "") + `],
+			"carriedForward":true,"carriedForwardData":` + carriedForward + `,
+			"broughtForward":true,"broughtForwardData":` + broughtForward + `}`
+		js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","marginLeft":20,"marginTop":21,"marginRight":20,"marginBottom":21},
+			"parameters":[{"id":1,"name":"items","type":"array","children":[{"id":2,"name":"amount","type":"number"}]}],
+			"styles":[],"docElements":[` + table + `],"version":2}`
+		var definition map[string]interface{}
+		if err := json.Unmarshal([]byte(js), &definition); err != nil {
+			t.Fatal(err)
+		}
+		return definition
+	}
+	amounts := func(from int, to int) []string {
+		texts := make([]string, 0)
+		for i := from; i <= to; i++ {
+			texts = append(texts, fmt.Sprint(i))
+		}
+		return texts
+	}
+	concat := func(parts ...[]string) []string {
+		texts := make([]string, 0)
+		for _, part := range parts {
+			texts = append(texts, part...)
+		}
+		return texts
+	}
+
+	tests := []struct {
+		name           string
+		rowCount       int
+		carriedForward string
+		broughtForward string
+		want           [][]string
+	}{
+		{"running values", 50, band(120, "cf ${running.sum(amount)}", ""), band(130, "bf ${running.count}", ""),
+			[][]string{concat(amounts(1, 39), []string{"cf 780"}), concat([]string{"bf 39"}, amounts(40, 50))}},
+		{"without running variables", 50, band(120, "continued", ""), band(130, "continuation", ""),
+			[][]string{concat(amounts(1, 39), []string{"continued"}), concat([]string{"continuation"}, amounts(40, 50))}},
+		// no space is reserved if the remaining rows complete the table
+		{"last page", 40, band(120, "cf ${running.sum(amount)}", ""), band(130, "bf", ""), [][]string{amounts(1, 40)}},
+		// no space is reserved for a band which is not printed
+		{"print if", 50, band(120, "cf ${running.count}", "${running.count} > 100"), band(130, "bf ${running.count}", "${running.count} > 100"),
+			[][]string{amounts(1, 40), amounts(41, 50)}},
+		{"print if on last pages", 100, band(120, "cf ${running.count}", "${running.count} > 50"), band(130, "bf ${running.count}", "${running.count} > 50"),
+			[][]string{amounts(1, 40), concat(amounts(41, 79), []string{"cf 79"}), concat([]string{"bf 79"}, amounts(80, 100))}},
+	}
+	for _, test := range tests {
+		items := make([]interface{}, 0)
+		for i := 1; i <= test.rowCount; i++ {
+			items = append(items, map[string]interface{}{"amount": i})
+		}
+		report := NewReport(newDefinition(test.carriedForward, test.broughtForward), map[string]interface{}{"items": items}, false, "", nil)
+		pdf, err := report.GeneratePDF(false)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := getPDFPageTexts(t, pdf); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: texts = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetPageBreakLineIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      string // x is a text line, - a blank line
+		orphans    int
+		widows     int
+		startIndex int
+		breakIndex int
+		offsetY    float64
+		want       int
+	}{
+		{"disabled", "xxxxxx", 1, 1, 0, 5, 10, 5},
+		{"widows", "xxxxxx", 0, 2, 0, 5, 10, 4},
+		{"widows keep orphans", "xxxxxx", 2, 3, 0, 4, 10, 3},
+		// the whole paragraph is moved to the next container
+		{"orphans", "xxxxxx", 2, 0, 0, 1, 10, 0},
+		{"orphans of second paragraph", "xxx-xxxx", 2, 0, 0, 5, 10, 4},
+		{"paragraph shorter than orphans and widows", "xxx", 2, 2, 0, 2, 10, 0},
+		{"widows move orphans", "xxxx", 2, 3, 0, 3, 10, 0},
+		// the lines cannot be moved because the container would be empty
+		{"top of container", "xxx", 2, 2, 0, 2, 0, 2},
+		{"top of container orphans", "xxxxxx", 2, 0, 0, 1, 0, 1},
+		{"continued text", "xxxxxxxx", 2, 0, 3, 4, 0, 4},
+		{"continued text with widows", "xxxxxxxx", 0, 3, 3, 7, 0, 5},
+		// paragraphs are separated by blank lines
+		{"break before blank line", "xxx-xxx", 2, 2, 0, 3, 10, 3},
+		{"break after blank line", "xxx-xxx", 2, 2, 0, 4, 10, 4},
+	}
+	for _, test := range tests {
+		element := TextElement{Orphans: test.orphans, Widows: test.widows, LinesCount: len(test.lines)}
+		for _, c := range test.lines {
+			line := TextLine{Text: "text"}
+			if c == '-' {
+				line.Text = ""
+			}
+			element.TextLines = append(element.TextLines, line)
+		}
+		if got := element.getPageBreakLineIndex(test.startIndex, test.breakIndex, test.offsetY); got != test.want {
+			t.Errorf("%s: getPageBreakLineIndex(%d, %d, %v) = %d, want %d", test.name, test.startIndex, test.breakIndex, test.offsetY, got, test.want)
+		}
+	}
+}
+
+func TestTableGroupKeepTogether(t *testing.T) {
+	// the content height of 800 points holds 40 rows of 20 points
+	newDefinition := func(keepTogether bool) map[string]interface{} {
+		table := fmt.Sprintf(`{"id":100,"elementType":"table","containerId":"0_content","x":0,"y":0,"width":300,"height":20,
+			"dataSource":"${items}","columns":1,"header":false,"footer":false,"contentDataRows":[
+			{"id":110,"height":20,"groupExpression":"${customer}","keepTogether":%t,"columnData":[
+				{"id":111,"content":"${customer}","width":300,"height":20,"fontSize":10,"lineSpacing":1}]},
+			{"id":120,"height":20,"columnData":[{"id":121,"content":"${amount}","width":300,"height":20,"fontSize":10,"lineSpacing":1}]}]}`, keepTogether)
+		js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","marginLeft":20,"marginTop":21,"marginRight":20,"marginBottom":21},
+			"parameters":[{"id":1,"name":"items","type":"array","children":[{"id":2,"name":"customer","type":"string"},{"id":3,"name":"amount","type":"number"}]}],
+			"styles":[],"docElements":[` + table + `],"version":2}`
+		var definition map[string]interface{}
+		if err := json.Unmarshal([]byte(js), &definition); err != nil {
+			t.Fatal(err)
+		}
+		return definition
+	}
+	// newItems returns the rows of groups with the given number of rows, the amounts are numbered consecutively
+	newItems := func(groups ...int) []interface{} {
+		items := make([]interface{}, 0)
+		for i, count := range groups {
+			for j := 0; j < count; j++ {
+				items = append(items, map[string]interface{}{"customer": string(rune('A' + i)), "amount": len(items) + 1})
+			}
+		}
+		return items
+	}
+	numbers := func(from int, to int) []string {
+		texts := make([]string, 0)
+		for i := from; i <= to; i++ {
+			texts = append(texts, fmt.Sprint(i))
+		}
+		return texts
+	}
+	group := func(name string, from int, to int) []string {
+		return append([]string{name}, numbers(from, to)...)
+	}
+	concat := func(parts ...[]string) []string {
+		texts := make([]string, 0)
+		for _, part := range parts {
+			texts = append(texts, part...)
+		}
+		return texts
+	}
+
+	tests := []struct {
+		name         string
+		keepTogether bool
+		groups       []int
+		want         [][]string
+	}{
+		{"split", false, []int{36, 5}, [][]string{concat(group("A", 1, 36), group("B", 37, 38)), numbers(39, 41)}},
+		{"keep together", true, []int{36, 5}, [][]string{group("A", 1, 36), group("B", 37, 41)}},
+		// a group which does not fit on a page is split
+		{"larger than a page", true, []int{2, 50, 1}, [][]string{concat(group("A", 1, 2), group("B", 3, 38)), concat(numbers(39, 52), group("C", 53, 53))}},
+		{"larger than a page at top", true, []int{50}, [][]string{group("A", 1, 39), numbers(40, 50)}},
+	}
+	for _, test := range tests {
+		report := NewReport(newDefinition(test.keepTogether), map[string]interface{}{"items": newItems(test.groups...)}, false, "", nil)
+		pdf, err := report.GeneratePDF(false)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := getPDFPageTexts(t, pdf); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: texts = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
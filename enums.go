@@ -141,17 +141,26 @@ func GetDocElementType(docElementType string) DocElementType {
 type ParameterType int
 
 const (
-	ParameterTypeNone        ParameterType = 0
-	ParameterTypeString      ParameterType = 1
-	ParameterTypeNumber      ParameterType = 2
-	ParameterTypeBoolean     ParameterType = 3
-	ParameterTypeDate        ParameterType = 4
-	ParameterTypeArray       ParameterType = 5
-	ParameterTypeSimpleArray ParameterType = 6
-	ParameterTypeMap         ParameterType = 7
-	ParameterTypeSum         ParameterType = 8
-	ParameterTypeAverage     ParameterType = 9
-	ParameterTypeImage       ParameterType = 10
+	ParameterTypeNone          ParameterType = 0
+	ParameterTypeString        ParameterType = 1
+	ParameterTypeNumber        ParameterType = 2
+	ParameterTypeBoolean       ParameterType = 3
+	ParameterTypeDate          ParameterType = 4
+	ParameterTypeArray         ParameterType = 5
+	ParameterTypeSimpleArray   ParameterType = 6
+	ParameterTypeMap           ParameterType = 7
+	ParameterTypeSum           ParameterType = 8
+	ParameterTypeAverage       ParameterType = 9
+	ParameterTypeImage         ParameterType = 10
+	ParameterTypeCount         ParameterType = 11
+	ParameterTypeCountDistinct ParameterType = 12
+	ParameterTypeMin           ParameterType = 13
+	ParameterTypeMax           ParameterType = 14
+	ParameterTypeMedian        ParameterType = 15
+	ParameterTypeFirst         ParameterType = 16
+	ParameterTypeLast          ParameterType = 17
+	ParameterTypeDatetime      ParameterType = 18
+	ParameterTypeTime          ParameterType = 19
 )
 
 var ParameterTypes = [...]string{
@@ -166,12 +175,50 @@ var ParameterTypes = [...]string{
 	"sum",
 	"average",
 	"image",
+	"count",
+	"count_distinct",
+	"min",
+	"max",
+	"median",
+	"first",
+	"last",
+	"datetime",
+	"time",
 }
 
 func (ParameterType ParameterType) String() string {
 	return ParameterTypes[ParameterType]
 }
 
+// isAggregate returns true for parameter types which are computed from the rows of an array parameter
+func (ParameterType ParameterType) isAggregate() bool {
+	switch ParameterType {
+	case ParameterTypeSum, ParameterTypeAverage, ParameterTypeCount, ParameterTypeCountDistinct,
+		ParameterTypeMin, ParameterTypeMax, ParameterTypeMedian, ParameterTypeFirst, ParameterTypeLast:
+		return true
+	}
+	return false
+}
+
+// isNumeric returns true for parameter types whose values are numbers
+func (ParameterType ParameterType) isNumeric() bool {
+	switch ParameterType {
+	case ParameterTypeNumber, ParameterTypeSum, ParameterTypeAverage, ParameterTypeCount, ParameterTypeCountDistinct,
+		ParameterTypeMin, ParameterTypeMax, ParameterTypeMedian:
+		return true
+	}
+	return false
+}
+
+// isDate returns true for parameter types whose values are stored as time.Time
+func (ParameterType ParameterType) isDate() bool {
+	switch ParameterType {
+	case ParameterTypeDate, ParameterTypeDatetime, ParameterTypeTime:
+		return true
+	}
+	return false
+}
+
 func getParameterType(ParameterType string) ParameterType {
 	switch ParameterType {
 	case ParameterTypeNone.String():
@@ -184,6 +231,10 @@ func getParameterType(ParameterType string) ParameterType {
 		return ParameterTypeBoolean
 	case ParameterTypeDate.String():
 		return ParameterTypeDate
+	case ParameterTypeDatetime.String():
+		return ParameterTypeDatetime
+	case ParameterTypeTime.String():
+		return ParameterTypeTime
 	case ParameterTypeArray.String():
 		return ParameterTypeArray
 	case ParameterTypeSimpleArray.String():
@@ -196,6 +247,20 @@ func getParameterType(ParameterType string) ParameterType {
 		return ParameterTypeAverage
 	case ParameterTypeImage.String():
 		return ParameterTypeImage
+	case ParameterTypeCount.String():
+		return ParameterTypeCount
+	case ParameterTypeCountDistinct.String():
+		return ParameterTypeCountDistinct
+	case ParameterTypeMin.String():
+		return ParameterTypeMin
+	case ParameterTypeMax.String():
+		return ParameterTypeMax
+	case ParameterTypeMedian.String():
+		return ParameterTypeMedian
+	case ParameterTypeFirst.String():
+		return ParameterTypeFirst
+	case ParameterTypeLast.String():
+		return ParameterTypeLast
 	}
 	return ParameterTypeNone
 }
@@ -206,12 +271,17 @@ const (
 	BandTypeHeader  BandType = 1
 	BandTypeContent BandType = 2
 	BandTypeFooter  BandType = 3
+	// table bands printed when a table is split across pages
+	BandTypeCarriedForward BandType = 4
+	BandTypeBroughtForward BandType = 5
 )
 
 var bandTypes = [...]string{
 	"header",
 	"content",
 	"footer",
+	"carried_forward",
+	"brought_forward",
 }
 
 func (bandType BandType) String() string {
@@ -226,6 +296,10 @@ func GetBandType(bandType string) BandType {
 		return BandTypeContent
 	case BandTypeFooter.String():
 		return BandTypeFooter
+	case BandTypeCarriedForward.String():
+		return BandTypeCarriedForward
+	case BandTypeBroughtForward.String():
+		return BandTypeBroughtForward
 	}
 	return BandTypeContent
 }
@@ -417,12 +491,138 @@ func GetVerticalAlignment(verticalAlignment string) VerticalAlignment {
 	return VerticalAlignmentTop
 }
 
-func getLocaleCurrency(locale string) string {
-	switch locale {
-	case "de", "es", "fr", "it":
-		return "EUR"
-	case "en-GB":
-		return "GBP"
+type CurrencyDisplay int
+
+const (
+	CurrencyDisplaySymbol CurrencyDisplay = 1
+	CurrencyDisplayCode   CurrencyDisplay = 2
+)
+
+var currencyDisplays = [...]string{
+	"symbol",
+	"code",
+}
+
+func (currencyDisplay CurrencyDisplay) String() string {
+	return currencyDisplays[currencyDisplay-1]
+}
+
+func GetCurrencyDisplay(currencyDisplay string) CurrencyDisplay {
+	switch currencyDisplay {
+	case CurrencyDisplaySymbol.String():
+		return CurrencyDisplaySymbol
+	case CurrencyDisplayCode.String():
+		return CurrencyDisplayCode
+	}
+	return CurrencyDisplaySymbol
+}
+
+type RoundingMode int
+
+const (
+	RoundingModeHalfEven RoundingMode = 1
+	RoundingModeHalfUp   RoundingMode = 2
+	RoundingModeHalfDown RoundingMode = 3
+	RoundingModeUp       RoundingMode = 4
+	RoundingModeDown     RoundingMode = 5
+	RoundingModeCeiling  RoundingMode = 6
+	RoundingModeFloor    RoundingMode = 7
+)
+
+var roundingModes = [...]string{
+	"half_even",
+	"half_up",
+	"half_down",
+	"up",
+	"down",
+	"ceiling",
+	"floor",
+}
+
+func (roundingMode RoundingMode) String() string {
+	return roundingModes[roundingMode-1]
+}
+
+func GetRoundingMode(roundingMode string) RoundingMode {
+	switch roundingMode {
+	case RoundingModeHalfEven.String():
+		return RoundingModeHalfEven
+	case RoundingModeHalfUp.String():
+		return RoundingModeHalfUp
+	case RoundingModeHalfDown.String():
+		return RoundingModeHalfDown
+	case RoundingModeUp.String():
+		return RoundingModeUp
+	case RoundingModeDown.String():
+		return RoundingModeDown
+	case RoundingModeCeiling.String():
+		return RoundingModeCeiling
+	case RoundingModeFloor.String():
+		return RoundingModeFloor
+	}
+	return RoundingModeHalfEven
+}
+
+type TextOverflow int
+
+const (
+	TextOverflowGrow     TextOverflow = 1
+	TextOverflowClip     TextOverflow = 2
+	TextOverflowEllipsis TextOverflow = 3
+	TextOverflowShrink   TextOverflow = 4
+)
+
+var textOverflows = [...]string{
+	"grow",
+	"clip",
+	"ellipsis",
+	"shrink",
+}
+
+func (textOverflow TextOverflow) String() string {
+	return textOverflows[textOverflow-1]
+}
+
+func GetTextOverflow(textOverflow string) TextOverflow {
+	switch textOverflow {
+	case TextOverflowGrow.String():
+		return TextOverflowGrow
+	case TextOverflowClip.String():
+		return TextOverflowClip
+	case TextOverflowEllipsis.String():
+		return TextOverflowEllipsis
+	case TextOverflowShrink.String():
+		return TextOverflowShrink
+	}
+	return TextOverflowGrow
+}
+
+type TextDirection int
+
+const (
+	TextDirectionAuto TextDirection = 1
+	TextDirectionLTR  TextDirection = 2
+	TextDirectionRTL  TextDirection = 3
+)
+
+var textDirections = [...]string{
+	"auto",
+	"ltr",
+	"rtl",
+}
+
+func (textDirection TextDirection) String() string {
+	return textDirections[textDirection-1]
+}
+
+func GetTextDirection(textDirection string) TextDirection {
+	switch textDirection {
+	case TextDirectionAuto.String():
+		return TextDirectionAuto
+	case TextDirectionLTR.String():
+		return TextDirectionLTR
+	case TextDirectionRTL.String():
+		return TextDirectionRTL
 	}
-	return "USD"
+	return TextDirectionAuto
 }
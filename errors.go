@@ -1,5 +1,7 @@
 package reportbro
 
+import "fmt"
+
 type reportBroError struct {
 	error
 	Error Error
@@ -20,5 +22,21 @@ type Error struct {
 	ObjectID int
 	Field    string
 	Info     interface{}
-	context  string
+	// Path is the path of the parameter data for errors of the data validation, e.g. items[2].amount
+	Path    string
+	context string
+}
+
+func (self Error) Error() string {
+	msg := self.Message
+	if self.Path != "" {
+		msg += " " + self.Path
+	}
+	if self.Field != "" {
+		msg += " (" + self.Field + ")"
+	}
+	if self.Info != nil {
+		msg += fmt.Sprintf(": %v", self.Info)
+	}
+	return msg
 }
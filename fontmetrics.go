@@ -0,0 +1,83 @@
+package reportbro
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// fontMetrics contains the position and thickness of text decorations in 1/1000 of the font size,
+// positions are relative to the baseline (negative values are below the baseline)
+type fontMetrics struct {
+	underlinePosition     float64
+	underlineThickness    float64
+	strikethroughPosition float64
+}
+
+// coreFontMetrics are the metrics of the core fonts, all core font definitions
+// use the same underline position and thickness
+var coreFontMetrics = fontMetrics{underlinePosition: -100, underlineThickness: 50, strikethroughPosition: 300}
+
+// getFontMetrics returns the metrics of a font, the metrics of TrueType fonts are read from the font file
+// when the font is loaded. The metrics of core fonts are used for fonts which are not available.
+func (self *FPDFRB) getFontMetrics(font string, style string) fontMetrics {
+	family := strings.ToLower(font)
+	if !isCoreFont(family) {
+		if trueTypeFont := self.loadFont(family, style); trueTypeFont != nil {
+			return trueTypeFont.metrics
+		}
+	}
+	return coreFontMetrics
+}
+
+// readTrueTypeMetrics reads the underline position and thickness from the post table and the strikethrough
+// position from the OS/2 table of a TrueType font. The values are converted from font units to 1/1000 of the
+// font size, values which are not available in the font are taken from the core fonts.
+func readTrueTypeMetrics(data []byte) (fontMetrics, error) {
+	metrics := coreFontMetrics
+	head, err := findTrueTypeTable(data, "head")
+	if err != nil {
+		return metrics, err
+	}
+	if len(head) < 20 {
+		return metrics, nil
+	}
+	unitsPerEm := float64(binary.BigEndian.Uint16(head[18:]))
+	if unitsPerEm == 0 {
+		return metrics, nil
+	}
+	toMetric := func(table []byte, offset int) float64 {
+		return float64(int16(binary.BigEndian.Uint16(table[offset:]))) * 1000 / unitsPerEm
+	}
+
+	post, err := findTrueTypeTable(data, "post")
+	if err != nil {
+		return metrics, err
+	}
+	if len(post) >= 12 && toMetric(post, 10) > 0 {
+		metrics.underlinePosition = toMetric(post, 8)
+		metrics.underlineThickness = toMetric(post, 10)
+	}
+	os2, err := findTrueTypeTable(data, "OS/2")
+	if err != nil {
+		return metrics, err
+	}
+	if len(os2) >= 30 && toMetric(os2, 28) > 0 {
+		metrics.strikethroughPosition = toMetric(os2, 28)
+	}
+	return metrics, nil
+}
+
+// renderUnderline draws an underline below text with the given baseline, the draw color must be set
+func (self *FPDFRB) renderUnderline(x float64, baselineY float64, width float64, metrics fontMetrics, fontSize float64) {
+	// the underline position is the top of the line
+	y := baselineY - (metrics.underlinePosition-metrics.underlineThickness/2)/1000.0*fontSize
+	self.Fpdf.SetLineWidth(metrics.underlineThickness / 1000.0 * fontSize)
+	self.Fpdf.Line(x, y, x+width, y)
+}
+
+// renderStrikethrough draws a line through text with the given baseline, the draw color must be set
+func (self *FPDFRB) renderStrikethrough(x float64, baselineY float64, width float64, metrics fontMetrics, fontSize float64) {
+	y := baselineY - metrics.strikethroughPosition/1000.0*fontSize
+	self.Fpdf.SetLineWidth(metrics.underlineThickness / 1000.0 * fontSize)
+	self.Fpdf.Line(x, y, x+width, y)
+}
@@ -0,0 +1,38 @@
+package reportbro
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadTrueTypeMetrics(t *testing.T) {
+	data, err := os.ReadFile("app/static/webfonts/fa-regular-400.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := readTrueTypeMetrics(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// post table: underlinePosition -63, underlineThickness 25, OS/2 table: yStrikeoutPosition 132 (unitsPerEm 512)
+	want := fontMetrics{underlinePosition: -63 * 1000 / 512.0, underlineThickness: 25 * 1000 / 512.0, strikethroughPosition: 132 * 1000 / 512.0}
+	if metrics != want {
+		t.Errorf("readTrueTypeMetrics() = %+v, want %+v", metrics, want)
+	}
+	if _, err := readTrueTypeMetrics(data[:20]); err == nil {
+		t.Error("readTrueTypeMetrics() of truncated font, want error")
+	}
+}
+
+func TestGetFontMetrics(t *testing.T) {
+	pdfDoc := newFPDFRB(documentProperties{PageFormat: PageFormatA4, Orientation: OrientationPortrait}, "app/static/webfonts")
+	if metrics := pdfDoc.getFontMetrics("Helvetica", "B"); metrics != coreFontMetrics {
+		t.Errorf("getFontMetrics(Helvetica) = %+v, want %+v", metrics, coreFontMetrics)
+	}
+	if metrics := pdfDoc.getFontMetrics("fa-regular-400", "U"); metrics.underlineThickness != 25*1000/512.0 {
+		t.Errorf("getFontMetrics(fa-regular-400).underlineThickness = %v, want %v", metrics.underlineThickness, 25*1000/512.0)
+	}
+	if metrics := pdfDoc.getFontMetrics("missing", ""); metrics != coreFontMetrics {
+		t.Errorf("getFontMetrics(missing) = %+v, want %+v", metrics, coreFontMetrics)
+	}
+}
@@ -0,0 +1,459 @@
+package reportbro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// coreFonts are the fonts which are available in every pdf viewer, the text of core fonts is cp1252 encoded
+var coreFonts = []string{"courier", "helvetica", "arial", "times", "symbol", "zapfdingbats"}
+
+// cp1252Characters contains the characters of the cp1252 encoding which differ from latin-1
+var cp1252Characters = map[rune]bool{
+	'€': true, '‚': true, 'ƒ': true, '„': true, '…': true, '†': true, '‡': true, 'ˆ': true, '‰': true,
+	'Š': true, '‹': true, 'Œ': true, 'Ž': true, '‘': true, '’': true, '“': true, '”': true, '•': true,
+	'–': true, '—': true, '˜': true, '™': true, 'š': true, '›': true, 'œ': true, 'ž': true, 'Ÿ': true,
+}
+
+// trueTypeFont is a font of a TrueType file in the additional fonts directory, glyphs contains the
+// characters which are mapped to a glyph of the font. The font is added to the document when it is used,
+// data contains the content of the font file until then. Fonts which cannot be added have failed set.
+type trueTypeFont struct {
+	family   string
+	style    string
+	fileName string
+	glyphs   glyphRanges
+	metrics  fontMetrics
+	data     []byte
+	added    bool
+	failed   bool
+}
+
+// hasGlyph returns true if the font can render the character, control characters do not need a glyph
+func (self *trueTypeFont) hasGlyph(c rune) bool {
+	return !self.failed && (self.glyphs.contains(c) || unicode.IsControl(c))
+}
+
+// glyphRange is a range of characters from start to end (inclusive) which are mapped to glyphs
+type glyphRange struct {
+	start rune
+	end   rune
+}
+
+// glyphRanges are the sorted and non-overlapping character ranges of a font
+type glyphRanges []glyphRange
+
+func (self glyphRanges) contains(c rune) bool {
+	i := sort.Search(len(self), func(i int) bool { return self[i].end >= c })
+	return i < len(self) && self[i].start <= c
+}
+
+// add adds the characters from start to end, ranges must be added in ascending order
+// and adjacent ranges are merged
+func (self glyphRanges) add(start rune, end rune) glyphRanges {
+	if n := len(self); n > 0 && start <= self[n-1].end+1 {
+		if end > self[n-1].end {
+			self[n-1].end = end
+		}
+		return self
+	}
+	return append(self, glyphRange{start: start, end: end})
+}
+
+func isCoreFont(font string) bool {
+	return inArray(strings.ToLower(font), coreFonts)
+}
+
+// fontRun is a part of a text which is rendered with a single font
+type fontRun struct {
+	text string
+	font string
+}
+
+// setFont sets the font for the following text, fallback fonts of a previous text are reset
+func (self *FPDFRB) setFont(font string, style string, size float64) {
+	self.FontFamily = font
+	self.FontStyle = style
+	self.FontSize = size
+	self.FallbackFonts = nil
+	self.selectFont(font, style, size)
+}
+
+// setFallbackFonts sets the fonts which are used in the given order for characters of the following text
+// which have no glyph in the font
+func (self *FPDFRB) setFallbackFonts(fonts []string) {
+	self.FallbackFonts = fonts
+}
+
+// selectFont sets the font of the pdf document. TrueType fonts are loaded from the additional fonts
+// directory when they are used for the first time, the core font helvetica is used if a font is not available.
+func (self *FPDFRB) selectFont(font string, style string, size float64) {
+	family := strings.ToLower(font)
+	if !isCoreFont(family) {
+		if trueTypeFont := self.loadFont(family, style); trueTypeFont != nil && self.addFont(trueTypeFont) {
+			self.Fpdf.SetFont(family, style, size)
+			self.UTF8Font = true
+			return
+		}
+		family = "helvetica"
+	}
+	self.Fpdf.SetFont(family, style, size)
+	self.UTF8Font = false
+}
+
+// addFont adds a TrueType font to the document, false is returned if the font file is invalid
+func (self *FPDFRB) addFont(font *trueTypeFont) bool {
+	if font.failed {
+		return false
+	}
+	if !font.added {
+		self.Fpdf.AddUTF8FontFromBytes(font.family, font.style, font.data)
+		err := self.Fpdf.Error()
+		if err == nil && self.Fpdf.GetFontDesc(font.family, font.style) == (gofpdf.FontDescType{}) {
+			// gofpdf does not return an error for fonts which cannot be parsed, the font is just not added
+			err = errInvalidFont
+		}
+		if err != nil {
+			log.Println(Error{Message: fmt.Sprintf("font %s could not be added: %v", font.fileName, err)})
+			// the document is continued without the font, it is not used for any character
+			self.Fpdf.ClearError()
+			font.failed = true
+			font.data = nil
+			return false
+		}
+		font.added = true
+		font.data = nil
+	}
+	return true
+}
+
+// loadFont returns the TrueType font with the given style, the file of the regular style is used
+// if there is no file for the style. Nil is returned if the font is not available.
+func (self *FPDFRB) loadFont(family string, style string) *trueTypeFont {
+	style = strings.Replace(strings.ToUpper(style), "U", "", -1)
+	if style == "IB" {
+		style = "BI"
+	}
+	key := family + style
+	if font, ok := self.Fonts[key]; ok {
+		return font
+	}
+	var font *trueTypeFont
+	fileNames := self.getFontFileNames()
+	fileName, ok := fileNames[strings.ToLower(key)]
+	if !ok {
+		fileName, ok = fileNames[family]
+	}
+	if ok {
+		data, err := os.ReadFile(filepath.Join(self.AdditionalFonts, fileName))
+		var glyphs glyphRanges
+		if err == nil {
+			glyphs, err = readTrueTypeGlyphs(data)
+		}
+		var metrics fontMetrics
+		if err == nil {
+			metrics, err = readTrueTypeMetrics(data)
+		}
+		if err == nil {
+			font = &trueTypeFont{family: family, style: style, fileName: fileName, glyphs: glyphs, metrics: metrics, data: data}
+		} else {
+			log.Println(Error{Message: fmt.Sprintf("font %s could not be loaded: %v", fileName, err)})
+		}
+	} else {
+		log.Println(Error{Message: fmt.Sprintf("font %s is not available, helvetica is used instead", family)})
+	}
+	// fonts which are not available are stored as well so the error is only logged once
+	self.Fonts[key] = font
+	return font
+}
+
+// getFontFileNames returns the TrueType files of the additional fonts directory by their
+// lowercase name without extension, e.g. "notosanscjk" or "notosanscjkb" for the bold style
+func (self *FPDFRB) getFontFileNames() map[string]string {
+	if self.FontFileNames != nil {
+		return self.FontFileNames
+	}
+	self.FontFileNames = make(map[string]string)
+	if self.AdditionalFonts != "" {
+		entries, _ := os.ReadDir(self.AdditionalFonts)
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(name), ".ttf") {
+				self.FontFileNames[strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))] = name
+			}
+		}
+	}
+	return self.FontFileNames
+}
+
+// hasGlyph returns true if the font with the given style can render the character, false is returned
+// for fonts which are not available or cannot be loaded so the character is rendered with another font
+func (self *FPDFRB) hasGlyph(font string, style string, c rune) bool {
+	family := strings.ToLower(font)
+	if !isCoreFont(family) {
+		trueTypeFont := self.loadFont(family, style)
+		return trueTypeFont != nil && trueTypeFont.hasGlyph(c)
+	}
+	return c < 0x80 || (c >= 0xA0 && c <= 0xFF) || cp1252Characters[c] || unicode.IsControl(c)
+}
+
+// canRenderText returns true if every character of the text has a glyph in the font or one of the fallback
+// fonts. Without fallback fonts the font of the additional fonts directory selected by getFallbackFont is used.
+func (self *FPDFRB) canRenderText(text string, font string, style string, fallbackFonts []string) bool {
+	if len(fallbackFonts) == 0 {
+		font = self.getFallbackFont(text, font, style)
+	}
+	fonts := append([]string{font}, fallbackFonts...)
+	for _, c := range text {
+		hasGlyph := false
+		for _, font := range fonts {
+			if self.hasGlyph(font, style, c) {
+				hasGlyph = true
+				break
+			}
+		}
+		if !hasGlyph {
+			return false
+		}
+	}
+	return true
+}
+
+// getFallbackFont returns the font which is used for the text, if the font has no glyph for some
+// characters the first font of the additional fonts directory which has all glyphs is used
+func (self *FPDFRB) getFallbackFont(text string, font string, style string) string {
+	missing := make([]rune, 0)
+	for _, c := range text {
+		if !self.hasGlyph(font, style, c) {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) == 0 {
+		return font
+	}
+	families := make([]string, 0)
+	for name := range self.getFontFileNames() {
+		families = append(families, name)
+	}
+	sort.Strings(families)
+	for _, family := range families {
+		if family == strings.ToLower(font) {
+			continue
+		}
+		hasGlyphs := true
+		for _, c := range missing {
+			if !self.hasGlyph(family, style, c) {
+				hasGlyphs = false
+				break
+			}
+		}
+		if hasGlyphs {
+			return family
+		}
+	}
+	return font
+}
+
+// getFontRuns splits the text into runs of the current font and its fallback fonts, each character is
+// rendered with the first font which has a glyph for it. Marks and joiners stay in the run of the previous
+// character, the current font is used for characters without glyph in all fonts.
+func (self *FPDFRB) getFontRuns(text string) []fontRun {
+	if len(self.FallbackFonts) == 0 {
+		return []fontRun{{text: text, font: self.FontFamily}}
+	}
+	fonts := append([]string{self.FontFamily}, self.FallbackFonts...)
+	runs := make([]fontRun, 0, 1)
+	var runText strings.Builder
+	runFont := self.FontFamily
+	for _, c := range text {
+		font := runFont
+		if runText.Len() == 0 || !(unicode.Is(unicode.Mn, c) || unicode.Is(unicode.Me, c) || unicode.Is(unicode.Cf, c)) {
+			font = self.FontFamily
+			for _, fallbackFont := range fonts {
+				if self.hasGlyph(fallbackFont, self.FontStyle, c) {
+					font = fallbackFont
+					break
+				}
+			}
+		}
+		if font != runFont && runText.Len() > 0 {
+			runs = append(runs, fontRun{text: runText.String(), font: runFont})
+			runText.Reset()
+		}
+		runFont = font
+		runText.WriteRune(c)
+	}
+	return append(runs, fontRun{text: runText.String(), font: runFont})
+}
+
+// getTextWidth returns the width of the text with the current font and its fallback fonts,
+// letter spacing is added after each character
+func (self *FPDFRB) getTextWidth(text string, letterSpacing float64) float64 {
+	width := 0.0
+	runs := self.getFontRuns(text)
+	for _, run := range runs {
+		if run.font != self.FontFamily {
+			self.selectFont(run.font, self.FontStyle, self.FontSize)
+		}
+		width += self.Fpdf.GetStringWidth(self.encodeText(run.text))
+		if run.font != self.FontFamily {
+			self.selectFont(self.FontFamily, self.FontStyle, self.FontSize)
+		}
+	}
+	if letterSpacing != 0 {
+		width += letterSpacing * float64(utf8.RuneCountInString(text))
+	}
+	return width
+}
+
+// renderText renders the text at the given baseline, runs of characters without glyph in the current font
+// are rendered with the fallback fonts. The letter spacing must be set with setLetterSpacing.
+func (self *FPDFRB) renderText(x float64, y float64, text string, letterSpacing float64) {
+	runs := self.getFontRuns(text)
+	if len(runs) == 1 && runs[0].font == self.FontFamily {
+		self.Fpdf.Text(x, y, self.encodeText(text))
+		return
+	}
+	for _, run := range runs {
+		self.selectFont(run.font, self.FontStyle, self.FontSize)
+		self.Fpdf.Text(x, y, self.encodeText(run.text))
+		x += self.Fpdf.GetStringWidth(self.encodeText(run.text)) + letterSpacing*float64(utf8.RuneCountInString(run.text))
+	}
+	self.selectFont(self.FontFamily, self.FontStyle, self.FontSize)
+}
+
+var errInvalidFont = errors.New("invalid TrueType font")
+
+// findTrueTypeTable returns the table with the given tag of a TrueType font, nil is returned if the font has no such table
+func findTrueTypeTable(data []byte, tag string) ([]byte, error) {
+	if len(data) < 12 {
+		return nil, errInvalidFont
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + i*16
+		if record+16 > len(data) {
+			return nil, errInvalidFont
+		}
+		if string(data[record:record+4]) == tag {
+			offset := int(binary.BigEndian.Uint32(data[record+8:]))
+			length := int(binary.BigEndian.Uint32(data[record+12:]))
+			if offset < 0 || length < 0 || offset+length > len(data) {
+				return nil, errInvalidFont
+			}
+			return data[offset : offset+length], nil
+		}
+	}
+	return nil, nil
+}
+
+// readTrueTypeGlyphs returns the characters which are mapped to a glyph by the unicode cmap table of a TrueType font
+func readTrueTypeGlyphs(data []byte) (glyphRanges, error) {
+	cmap, err := findTrueTypeTable(data, "cmap")
+	if err != nil {
+		return nil, err
+	}
+	if len(cmap) < 4 {
+		return nil, errors.New("TrueType font has no cmap table")
+	}
+
+	// a subtable with full unicode coverage (format 12) is preferred to the basic multilingual plane (format 4)
+	subtable := -1
+	subtableFormat := uint16(0)
+	numSubtables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numSubtables && 4+i*8+8 <= len(cmap); i++ {
+		platformID := binary.BigEndian.Uint16(cmap[4+i*8:])
+		encodingID := binary.BigEndian.Uint16(cmap[4+i*8+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[4+i*8+4:]))
+		if offset+2 > len(cmap) || !(platformID == 0 || (platformID == 3 && (encodingID == 1 || encodingID == 10))) {
+			continue
+		}
+		format := binary.BigEndian.Uint16(cmap[offset:])
+		if (format == 12 && subtableFormat != 12) || (format == 4 && subtableFormat == 0) {
+			subtable = offset
+			subtableFormat = format
+		}
+	}
+	if subtable == -1 {
+		return nil, errors.New("TrueType font has no unicode cmap")
+	}
+
+	glyphs := make(glyphRanges, 0)
+	table := cmap[subtable:]
+	if subtableFormat == 12 {
+		if len(table) < 16 {
+			return nil, errInvalidFont
+		}
+		// the groups are stored as ranges, the number of groups is limited by the table size
+		// and invalid ranges are ignored
+		groups := make(glyphRanges, 0)
+		numGroups := int(binary.BigEndian.Uint32(table[12:]))
+		for i := 0; i < numGroups && 16+i*12+12 <= len(table); i++ {
+			group := table[16+i*12:]
+			start := binary.BigEndian.Uint32(group)
+			end := binary.BigEndian.Uint32(group[4:])
+			startGlyph := binary.BigEndian.Uint32(group[8:])
+			if end > unicode.MaxRune {
+				end = unicode.MaxRune
+			}
+			// glyph 0 is the missing glyph
+			if startGlyph == 0 {
+				start++
+			}
+			if start <= end {
+				groups = append(groups, glyphRange{start: rune(start), end: rune(end)})
+			}
+		}
+		sort.Slice(groups, func(i, j int) bool { return groups[i].start < groups[j].start })
+		for _, group := range groups {
+			glyphs = glyphs.add(group.start, group.end)
+		}
+		return glyphs, nil
+	}
+
+	if len(table) < 14 {
+		return nil, errInvalidFont
+	}
+	segCount := int(binary.BigEndian.Uint16(table[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	idDeltas := startCodes + segCount*2
+	idRangeOffsets := idDeltas + segCount*2
+	if idRangeOffsets+segCount*2 > len(table) {
+		return nil, errInvalidFont
+	}
+	for i := 0; i < segCount; i++ {
+		end := int(binary.BigEndian.Uint16(table[endCodes+i*2:]))
+		start := int(binary.BigEndian.Uint16(table[startCodes+i*2:]))
+		idDelta := int(binary.BigEndian.Uint16(table[idDeltas+i*2:]))
+		idRangeOffset := int(binary.BigEndian.Uint16(table[idRangeOffsets+i*2:]))
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			glyph := 0
+			if idRangeOffset == 0 {
+				glyph = (c + idDelta) & 0xFFFF
+			} else {
+				pos := idRangeOffsets + i*2 + idRangeOffset + (c-start)*2
+				if pos+2 > len(table) {
+					continue
+				}
+				if glyph = int(binary.BigEndian.Uint16(table[pos:])); glyph != 0 {
+					glyph = (glyph + idDelta) & 0xFFFF
+				}
+			}
+			if glyph != 0 {
+				glyphs = glyphs.add(rune(c), rune(c))
+			}
+		}
+	}
+	return glyphs, nil
+}
@@ -0,0 +1,108 @@
+package reportbro
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newCmapFont returns a TrueType font which only contains a cmap table with a format 12 subtable of the given groups
+func newCmapFont(groups [][3]uint32) []byte {
+	subtable := make([]byte, 16+len(groups)*12)
+	binary.BigEndian.PutUint16(subtable, 12)
+	binary.BigEndian.PutUint32(subtable[4:], uint32(len(subtable)))
+	binary.BigEndian.PutUint32(subtable[12:], uint32(len(groups)))
+	for i, group := range groups {
+		binary.BigEndian.PutUint32(subtable[16+i*12:], group[0])
+		binary.BigEndian.PutUint32(subtable[16+i*12+4:], group[1])
+		binary.BigEndian.PutUint32(subtable[16+i*12+8:], group[2])
+	}
+	cmap := make([]byte, 12)
+	binary.BigEndian.PutUint16(cmap[2:], 1)
+	binary.BigEndian.PutUint16(cmap[4:], 3)
+	binary.BigEndian.PutUint16(cmap[6:], 10)
+	binary.BigEndian.PutUint32(cmap[8:], 12)
+	cmap = append(cmap, subtable...)
+	data := make([]byte, 28)
+	binary.BigEndian.PutUint16(data[4:], 1)
+	copy(data[12:], "cmap")
+	binary.BigEndian.PutUint32(data[20:], 28)
+	binary.BigEndian.PutUint32(data[24:], uint32(len(cmap)))
+	return append(data, cmap...)
+}
+
+func TestReadTrueTypeGlyphs(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups [][3]uint32
+		want   map[rune]bool
+	}{
+		{"ranges", [][3]uint32{{0x41, 0x5A, 1}, {0x4E00, 0x9FFF, 100}},
+			map[rune]bool{'@': false, 'A': true, 'Z': true, '[': false, '中': true, 0xA000: false}},
+		{"unsorted and adjacent", [][3]uint32{{0x61, 0x7A, 30}, {0x41, 0x60, 1}},
+			map[rune]bool{'A': true, '`': true, 'z': true, '{': false}},
+		{"missing glyph", [][3]uint32{{0x20, 0x7E, 0}},
+			map[rune]bool{' ': false, '!': true, '~': true}},
+		{"invalid ranges", [][3]uint32{{0x100, 0xFFFFFFFF, 1}, {0x50, 0x40, 1}},
+			map[rune]bool{0x45: false, 0x100: true, 0x10FFFF: true}},
+	}
+	for _, test := range tests {
+		glyphs, err := readTrueTypeGlyphs(newCmapFont(test.groups))
+		if err != nil {
+			t.Errorf("%s: readTrueTypeGlyphs() error %v", test.name, err)
+			continue
+		}
+		for c, want := range test.want {
+			if got := glyphs.contains(c); got != want {
+				t.Errorf("%s: contains(%U) = %v, want %v", test.name, c, got, want)
+			}
+		}
+	}
+	if _, err := readTrueTypeGlyphs(newCmapFont(nil)[:30]); err == nil {
+		t.Error("readTrueTypeGlyphs() of truncated font, want error")
+	}
+}
+
+func TestHasGlyph(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"broken.ttf": []byte("not a font"),
+		// the glyphs can be read but the font cannot be added to the document
+		"cmaponly.ttf": newCmapFont([][3]uint32{{0x41, 0x5A, 1}}),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pdfDoc := newFPDFRB(documentProperties{PageFormat: PageFormatA4, Orientation: OrientationPortrait}, dir)
+	pdfDoc.Fpdf.AddPage()
+	tests := []struct {
+		font string
+		c    rune
+		want bool
+	}{
+		{"Helvetica", 'A', true},
+		{"Helvetica", '€', true},
+		{"Helvetica", '中', false},
+		{"missing", 'A', false},
+		{"broken", 'A', false},
+		{"cmaponly", 'A', true},
+		{"cmaponly", 'a', false},
+	}
+	for _, test := range tests {
+		if got := pdfDoc.hasGlyph(test.font, "", test.c); got != test.want {
+			t.Errorf("hasGlyph(%s, %q) = %v, want %v", test.font, test.c, got, test.want)
+		}
+	}
+
+	// the document is continued with helvetica when a font cannot be added
+	pdfDoc.setFont("cmaponly", "", 10)
+	if pdfDoc.UTF8Font || pdfDoc.Fpdf.Err() {
+		t.Errorf("setFont(cmaponly): UTF8Font = %v, error = %v, want helvetica without error", pdfDoc.UTF8Font, pdfDoc.Fpdf.Error())
+	}
+	if pdfDoc.hasGlyph("cmaponly", "", 'A') {
+		t.Error("hasGlyph(cmaponly, 'A') = true after the font could not be added, want false")
+	}
+}
@@ -7,22 +7,39 @@ require (
 	github.com/PaesslerAG/gval v1.0.1
 	github.com/araddon/dateparse v0.0.0-20190622164848-0fb0a474d195
 	github.com/buger/jsonparser v0.0.0-20181115193947-bf1c66bbce23
-	github.com/dustin/go-humanize v1.0.0
 	github.com/go-chi/chi v4.0.2+incompatible
-	github.com/google/uuid v1.1.1
-	github.com/jinzhu/now v1.0.1
+	github.com/google/uuid v1.3.0
 	github.com/jung-kurt/gofpdf v1.5.4
 	github.com/lucasb-eyer/go-colorful v1.0.2
 	github.com/patrickmn/go-cache v2.1.0+incompatible
+	github.com/rivo/uniseg v0.4.7
 	github.com/satori/go.uuid v1.2.0
 	github.com/shomali11/util v0.0.0-20190608141102-c39c2521a2ab
+	github.com/shopspring/decimal v1.3.1
 	github.com/spf13/cast v1.3.0
 	github.com/vincent-petithory/dataurl v0.0.0-20160330182126-9a301d65acbb
-	github.com/vjeantet/jodaTime v0.0.0-20170816150230-be924ce213fb
+	modernc.org/sqlite v1.23.1
 )
 
 require (
 	github.com/boombuler/barcode v1.0.0 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
+	github.com/mattn/go-isatty v0.0.16 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/ruudk/golang-pdf417 v0.0.0-20181029194003-1af4ab5afa58 // indirect
+	golang.org/x/mod v0.3.0 // indirect
+	golang.org/x/sys v0.0.0-20220811171246-fbc7d0a398ab // indirect
+	golang.org/x/tools v0.0.0-20201124115921-2c860bdd6e78 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+	lukechampine.com/uint128 v1.2.0 // indirect
+	modernc.org/cc/v3 v3.40.0 // indirect
+	modernc.org/ccgo/v3 v3.16.13 // indirect
+	modernc.org/libc v1.22.5 // indirect
+	modernc.org/mathutil v1.5.0 // indirect
+	modernc.org/memory v1.5.0 // indirect
+	modernc.org/opt v0.1.3 // indirect
+	modernc.org/strutil v1.1.3 // indirect
+	modernc.org/token v1.0.1 // indirect
 )
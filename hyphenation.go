@@ -0,0 +1,146 @@
+package reportbro
+
+import (
+	"embed"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// hyphenationFiles contains the patterns and their licence notices (.lic.txt)
+//
+//go:embed hyphenation/*.txt
+var hyphenationFiles embed.FS
+
+// hyphenationLanguage contains the pattern file and the minimum number of characters
+// before and after a hyphen for a language
+type hyphenationLanguage struct {
+	name     string
+	leftMin  int
+	rightMin int
+}
+
+var hyphenationLanguages = map[string]hyphenationLanguage{
+	"en": {name: "en-us", leftMin: 2, rightMin: 3},
+	"de": {name: "de-1996", leftMin: 2, rightMin: 2},
+	"fr": {name: "fr", leftMin: 2, rightMin: 3},
+	"es": {name: "es", leftMin: 2, rightMin: 2},
+	"it": {name: "it", leftMin: 2, rightMin: 2},
+}
+
+var hyphenators = map[string]*hyphenator{}
+var hyphenatorsLock sync.Mutex
+
+// hyphenator finds the hyphenation points of words with the Knuth-Liang algorithm
+type hyphenator struct {
+	patterns         map[string][]uint8
+	exceptions       map[string][]int
+	maxPatternLength int
+	leftMin          int
+	rightMin         int
+}
+
+// init parses the patterns (e.g. "hy3ph") and exceptions (e.g. "ta-ble") separated by whitespace
+func (self *hyphenator) init(patterns string, exceptions string, leftMin int, rightMin int) {
+	self.leftMin = leftMin
+	self.rightMin = rightMin
+	self.patterns = make(map[string][]uint8)
+	for _, pattern := range strings.Fields(patterns) {
+		var letters strings.Builder
+		values := make([]uint8, 1, len(pattern)+1)
+		for _, c := range pattern {
+			if c >= '0' && c <= '9' {
+				values[len(values)-1] = uint8(c - '0')
+			} else {
+				letters.WriteRune(c)
+				values = append(values, 0)
+			}
+		}
+		self.patterns[letters.String()] = values
+		if length := len(values) - 1; length > self.maxPatternLength {
+			self.maxPatternLength = length
+		}
+	}
+	self.exceptions = make(map[string][]int)
+	for _, exception := range strings.Fields(exceptions) {
+		points := make([]int, 0)
+		for _, part := range strings.Split(exception, "-") {
+			length := utf8.RuneCountInString(part)
+			if len(points) > 0 {
+				length += points[len(points)-1]
+			}
+			points = append(points, length)
+		}
+		self.exceptions[strings.Replace(exception, "-", "", -1)] = points[:len(points)-1]
+	}
+}
+
+// getHyphenationPoints returns the positions (in runes) in the word where it can be hyphenated,
+// the word must only contain letters
+func (self *hyphenator) getHyphenationPoints(word string) []int {
+	word = strings.ToLower(word)
+	length := utf8.RuneCountInString(word)
+	if length < self.leftMin+self.rightMin {
+		return nil
+	}
+	if points, ok := self.exceptions[word]; ok {
+		return points
+	}
+
+	letters := []rune("." + word + ".")
+	// values[i] is the value for the position before letters[i]
+	values := make([]uint8, len(letters)+1)
+	for i := 0; i < len(letters); i++ {
+		for j := i + 1; j <= len(letters) && j-i <= self.maxPatternLength; j++ {
+			if pattern, ok := self.patterns[string(letters[i:j])]; ok {
+				for k, value := range pattern {
+					if value > values[i+k] {
+						values[i+k] = value
+					}
+				}
+			}
+		}
+	}
+	points := make([]int, 0)
+	for i := self.leftMin; i <= length-self.rightMin; i++ {
+		if values[i+1]%2 == 1 {
+			points = append(points, i)
+		}
+	}
+	return points
+}
+
+// getHyphenator returns the hyphenator for the language of a locale (e.g. "de" or "en_US"),
+// nil is returned if there are no patterns for the language
+func getHyphenator(locale string) *hyphenator {
+	language := normalizeLocale(locale)
+	if pos := strings.Index(language, "_"); pos != -1 {
+		language = language[:pos]
+	}
+	hyphenationLanguage, ok := hyphenationLanguages[language]
+	if !ok {
+		return nil
+	}
+
+	hyphenatorsLock.Lock()
+	defer hyphenatorsLock.Unlock()
+	if hyphenator, ok := hyphenators[language]; ok {
+		return hyphenator
+	}
+	patterns, err := hyphenationFiles.ReadFile("hyphenation/hyph-" + hyphenationLanguage.name + ".pat.txt")
+	if err != nil {
+		return nil
+	}
+	// exceptions are optional
+	exceptions, _ := hyphenationFiles.ReadFile("hyphenation/hyph-" + hyphenationLanguage.name + ".hyp.txt")
+	hyphenator := hyphenator{}
+	hyphenator.init(string(patterns), string(exceptions), hyphenationLanguage.leftMin, hyphenationLanguage.rightMin)
+	hyphenators[language] = &hyphenator
+	return &hyphenator
+}
+
+// isHyphenationLetter returns true for characters which are part of words handled by hyphenation patterns
+func isHyphenationLetter(c rune) bool {
+	return unicode.IsLetter(c) || c == '\'' || c == '’'
+}
@@ -0,0 +1,69 @@
+package reportbro
+
+import (
+	"strings"
+	"testing"
+)
+
+// hyphenate returns the word with hyphens inserted at its hyphenation points
+func hyphenate(hyphenator *hyphenator, word string) string {
+	letters := []rune(word)
+	var sb strings.Builder
+	start := 0
+	for _, point := range hyphenator.getHyphenationPoints(word) {
+		sb.WriteString(string(letters[start:point]) + "-")
+		start = point
+	}
+	sb.WriteString(string(letters[start:]))
+	return sb.String()
+}
+
+func TestHyphenatorPatterns(t *testing.T) {
+	hyphenator := hyphenator{}
+	hyphenator.init("hy3ph he2n hena4 hen5at 1na n2at 1tio 2io o2n", "ta-ble", 2, 3)
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"hyphenation", "hy-phen-ation"},
+		{"Hyphenation", "Hy-phen-ation"},
+		{"table", "ta-ble"},
+		{"hyph", "hyph"},
+	}
+	for _, test := range tests {
+		if got := hyphenate(&hyphenator, test.word); got != test.want {
+			t.Errorf("hyphenate(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestGetHyphenator(t *testing.T) {
+	tests := []struct {
+		locale string
+		word   string
+		want   string
+	}{
+		// exception list
+		{"en", "hyphenation", "hy-phen-a-tion"},
+		{"en_US", "computer", "com-puter"},
+		{"en", "project", "project"},
+		{"de", "Silbentrennung", "Sil-ben-tren-nung"},
+		{"de_AT", "Donaudampfschiff", "Do-nau-dampf-schiff"},
+		{"fr", "hyphénation", "hy-phé-na-tion"},
+		{"es", "separación", "se-pa-ra-ción"},
+		{"it", "sillabazione", "sil-la-ba-zio-ne"},
+	}
+	for _, test := range tests {
+		hyphenator := getHyphenator(test.locale)
+		if hyphenator == nil {
+			t.Errorf("getHyphenator(%s) = nil", test.locale)
+			continue
+		}
+		if got := hyphenate(hyphenator, test.word); got != test.want {
+			t.Errorf("hyphenate(%s, %q) = %q, want %q", test.locale, test.word, got, test.want)
+		}
+	}
+	if hyphenator := getHyphenator("ja"); hyphenator != nil {
+		t.Error("getHyphenator(ja) != nil, want nil for language without patterns")
+	}
+}
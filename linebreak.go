@@ -0,0 +1,274 @@
+package reportbro
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+const (
+	softHyphen = "\u00ad"
+	ellipsis   = "\u2026"
+)
+
+// lineBreakWord is a part of a paragraph which is kept on one line, space is set if the word
+// is separated by a space from the previous word
+type lineBreakWord struct {
+	text  string
+	space bool
+}
+
+// textLineBreak is a line of text created by lineBreaker, paragraphEnd is set for the last line
+// of a paragraph which is not justified and rtl for lines of right-to-left paragraphs
+type textLineBreak struct {
+	text         string
+	indent       float64
+	paragraphEnd bool
+	rtl          bool
+}
+
+// wordBreak is a position in a word where it can be split, hyphen is set if a hyphen must be added
+type wordBreak struct {
+	pos    int
+	next   int
+	hyphen bool
+}
+
+// lineBreaker splits text into lines which fit into the given width. Lines are broken at spaces,
+// non-breaking spaces keep words together. Words which do not fit are hyphenated at soft hyphens
+// or, if a hyphenator is set, at the hyphenation points of the language.
+// The first line of a paragraph is indented by the first line indent of the style,
+// the following lines by the hanging indent. Arabic text is shaped before it is split, the
+// text of the lines is kept in logical order.
+type lineBreaker struct {
+	pdfDoc          *FPDFRB
+	width           float64
+	letterSpacing   float64
+	firstLineIndent float64
+	hangingIndent   float64
+	textDirection   TextDirection
+	indent          float64
+	rtl             bool
+	hyphenator      *hyphenator
+	lines           []textLineBreak
+}
+
+func (self *lineBreaker) init(pdfDoc *FPDFRB, width float64, style *textStyle, hyphenator *hyphenator) {
+	self.pdfDoc = pdfDoc
+	self.width = width
+	if style != nil {
+		self.letterSpacing = style.LetterSpacing
+		self.firstLineIndent = style.FirstLineIndent
+		self.hangingIndent = style.HangingIndent
+		self.textDirection = style.TextDirection
+	}
+	self.hyphenator = hyphenator
+	self.lines = make([]textLineBreak, 0)
+}
+
+// split returns the lines of the text, the font must be set before
+func (self *lineBreaker) split(text string) []textLineBreak {
+	text = strings.TrimRight(strings.Replace(text, "\r", "", -1), "\n")
+	if text == "" {
+		return self.lines
+	}
+	for _, paragraph := range strings.Split(text, "\n") {
+		self.addParagraph(paragraph)
+	}
+	return self.lines
+}
+
+func (self *lineBreaker) addParagraph(text string) {
+	self.indent = self.firstLineIndent
+	self.rtl = isRTLParagraph(text, self.textDirection)
+	text = shapeArabicText(text)
+	line := ""
+	hasWords := false
+	for _, breakWord := range getLineBreakWords(text) {
+		word := breakWord.text
+		for {
+			candidate := self.join(line, hasWords, breakWord.space, strings.Replace(word, softHyphen, "", -1))
+			if self.fits(candidate) {
+				line = candidate
+				hasWords = true
+				break
+			}
+			if word == "" {
+				// spaces at the end of a line are not printed
+				break
+			}
+			if prefix, rest, ok := self.hyphenate(line, hasWords, breakWord.space, word); ok {
+				self.addLine(prefix, false)
+				line = ""
+				hasWords = false
+				word = rest
+				continue
+			}
+			if hasWords {
+				self.addLine(line, false)
+				line = ""
+				hasWords = false
+				continue
+			}
+			// word does not fit into an empty line, it is split at the last character which fits
+			prefix, rest := self.splitWord(strings.Replace(word, softHyphen, "", -1))
+			self.addLine(prefix, false)
+			word = rest
+		}
+	}
+	self.addLine(line, true)
+}
+
+func (self *lineBreaker) addLine(text string, paragraphEnd bool) {
+	self.lines = append(self.lines, textLineBreak{text: text, indent: self.indent, paragraphEnd: paragraphEnd, rtl: self.rtl})
+	self.indent = self.hangingIndent
+}
+
+// join appends a word to the line, the word is separated by a space unless it follows an ideograph
+func (self *lineBreaker) join(line string, hasWords bool, space bool, word string) string {
+	if !hasWords {
+		return word
+	}
+	if space {
+		return line + " " + word
+	}
+	return line + word
+}
+
+func (self *lineBreaker) fits(text string) bool {
+	return self.getWidth(text) <= self.width-self.indent
+}
+
+func (self *lineBreaker) getWidth(text string) float64 {
+	return self.pdfDoc.getTextWidth(text, self.letterSpacing)
+}
+
+// hyphenate returns the line with the first part of the word which still fits and the remaining part of the word
+func (self *lineBreaker) hyphenate(line string, hasWords bool, space bool, word string) (string, string, bool) {
+	breaks := self.getWordBreaks(word)
+	for i := len(breaks) - 1; i >= 0; i-- {
+		prefix := strings.Replace(word[:breaks[i].pos], softHyphen, "", -1)
+		if breaks[i].hyphen {
+			prefix += "-"
+		}
+		if candidate := self.join(line, hasWords, space, prefix); self.fits(candidate) {
+			return candidate, word[breaks[i].next:], true
+		}
+	}
+	return "", "", false
+}
+
+// getWordBreaks returns the positions where the word can be split. Soft hyphens take precedence
+// over hyphenation patterns, words are also split after a hyphen if hyphenation is enabled.
+func (self *lineBreaker) getWordBreaks(word string) []wordBreak {
+	breaks := make([]wordBreak, 0)
+	if strings.Contains(word, softHyphen) {
+		for pos := strings.Index(word, softHyphen); pos != -1; {
+			next := pos + len(softHyphen)
+			if pos > 0 && next < len(word) {
+				breaks = append(breaks, wordBreak{pos: pos, next: next, hyphen: true})
+			}
+			offset := strings.Index(word[next:], softHyphen)
+			if offset == -1 {
+				break
+			}
+			pos = next + offset
+		}
+		return breaks
+	}
+	if self.hyphenator == nil {
+		return breaks
+	}
+
+	// hyphenate each sequence of letters, e.g. "(self-explanatory)" -> "self" and "explanatory"
+	start := -1
+	for pos, c := range word + " " {
+		if isHyphenationLetter(c) {
+			if start == -1 {
+				start = pos
+			}
+			continue
+		}
+		if start != -1 {
+			letters := word[start:pos]
+			runeIndex := 0
+			points := self.hyphenator.getHyphenationPoints(letters)
+			for offset := range letters {
+				if len(points) > 0 && points[0] == runeIndex {
+					breaks = append(breaks, wordBreak{pos: start + offset, next: start + offset, hyphen: true})
+					points = points[1:]
+				}
+				runeIndex++
+			}
+			start = -1
+		}
+		if c == '-' && pos > 0 && pos+1 < len(word) {
+			breaks = append(breaks, wordBreak{pos: pos + 1, next: pos + 1, hyphen: false})
+		}
+	}
+	return breaks
+}
+
+// splitWord returns the longest prefix of the word which fits into an empty line (at least one character)
+// and the remaining part of the word
+func (self *lineBreaker) splitWord(word string) (string, string) {
+	end := 0
+	for pos, c := range word {
+		next := pos + utf8.RuneLen(c)
+		if end > 0 && !self.fits(word[:next]) {
+			break
+		}
+		end = next
+	}
+	return word[:end], word[end:]
+}
+
+func newLineBreaker(pdfDoc *FPDFRB, width float64, style *textStyle, hyphenator *hyphenator) *lineBreaker {
+	lineBreaker := lineBreaker{}
+	lineBreaker.init(pdfDoc, width, style, hyphenator)
+	return &lineBreaker
+}
+
+// getLineBreakWords splits a paragraph into words at spaces and at line break opportunities between
+// characters of words, e.g. between chinese or japanese ideographs which are not separated by spaces
+func getLineBreakWords(text string) []lineBreakWord {
+	words := make([]lineBreakWord, 0)
+	for i, part := range strings.Split(text, " ") {
+		for j, segment := range splitAtLineBreakOpportunities(part) {
+			words = append(words, lineBreakWord{text: segment, space: i > 0 && j == 0})
+		}
+	}
+	return words
+}
+
+// splitAtLineBreakOpportunities splits text without spaces at the line break opportunities of the
+// Unicode line breaking algorithm (UAX #14), e.g. between chinese or japanese ideographs or after a hyphen.
+// The kinsoku rules of the algorithm prevent breaks before closing punctuation and small kana and after
+// opening brackets, non-breaking spaces and word joiners keep the characters around them together.
+func splitAtLineBreakOpportunities(text string) []string {
+	parts := make([]string, 0, 1)
+	state := -1
+	for text != "" {
+		var segment string
+		segment, text, _, state = uniseg.FirstLineSegmentInString(text, state)
+		parts = append(parts, segment)
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "")
+	}
+	return parts
+}
+
+// isLineBreakOpportunity returns true if a line can be broken between two texts which are not
+// separated by a space, e.g. between two runs of rich text with different styles
+func isLineBreakOpportunity(text string, next string) bool {
+	pos := 0
+	for _, part := range splitAtLineBreakOpportunities(text + next) {
+		pos += len(part)
+		if pos >= len(text) {
+			return pos == len(text) && next != ""
+		}
+	}
+	return false
+}
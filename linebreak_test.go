@@ -0,0 +1,47 @@
+package reportbro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitAtLineBreakOpportunities(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{""}},
+		{"word", []string{"word"}},
+		{"well-known", []string{"well-", "known"}},
+		{"漢字です", []string{"漢", "字", "で", "す"}},
+		{"「日本」。", []string{"「日", "本」。"}},
+		{"ちょっと", []string{"ちょっ", "と"}},
+		{"100 €", []string{"100 €"}},
+		{"漢\u2060字", []string{"漢\u2060字"}},
+	}
+	for _, test := range tests {
+		if got := splitAtLineBreakOpportunities(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitAtLineBreakOpportunities(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestIsLineBreakOpportunity(t *testing.T) {
+	tests := []struct {
+		text string
+		next string
+		want bool
+	}{
+		{"word", "word", false},
+		{"漢", "字", true},
+		{"日本", "」", false},
+		{"「", "日", false},
+		{"well-", "known", true},
+		{"word", "", false},
+	}
+	for _, test := range tests {
+		if got := isLineBreakOpportunity(test.text, test.next); got != test.want {
+			t.Errorf("isLineBreakOpportunity(%q, %q) = %v, want %v", test.text, test.next, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,751 @@
+package reportbro
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// numberSymbols contains the locale specific symbols used to format numbers.
+// Only characters available in the built-in pdf fonts (cp1252) are used, e.g. a no-break space
+// instead of the narrow no-break space CLDR defines as group separator for some locales.
+type numberSymbols struct {
+	Decimal  string
+	Group    string
+	Minus    string
+	Plus     string
+	Percent  string
+	PerMille string
+	Exponent string
+}
+
+func newNumberSymbols(decimal string, group string) numberSymbols {
+	return numberSymbols{Decimal: decimal, Group: group, Minus: "-", Plus: "+", Percent: "%", PerMille: "‰", Exponent: "E"}
+}
+
+var (
+	symbolsDotComma     = newNumberSymbols(".", ",")
+	symbolsCommaDot     = newNumberSymbols(",", ".")
+	symbolsCommaSpace   = newNumberSymbols(",", "\u00a0")
+	symbolsDotQuote     = newNumberSymbols(".", "’")
+	symbolsDotSpace     = newNumberSymbols(".", "\u00a0")
+	symbolsCommaQuote   = newNumberSymbols(",", "’")
+	symbolsDotNoGroup   = newNumberSymbols(".", "")
+	defaultNumberLocale = "en"
+)
+
+// localeNumberSymbols maps a locale (language or language_TERRITORY) to its number symbols
+var localeNumberSymbols = map[string]numberSymbols{
+	"en":    symbolsDotComma,
+	"en_au": symbolsDotComma,
+	"en_ca": symbolsDotComma,
+	"en_gb": symbolsDotComma,
+	"en_ie": symbolsDotComma,
+	"en_in": symbolsDotComma,
+	"en_nz": symbolsDotComma,
+	"en_us": symbolsDotComma,
+	"en_za": symbolsCommaSpace,
+	"en_ch": symbolsDotQuote,
+	"de":    symbolsCommaDot,
+	"de_de": symbolsCommaDot,
+	"de_at": symbolsCommaSpace,
+	"de_ch": symbolsDotQuote,
+	"de_li": symbolsDotQuote,
+	"de_lu": symbolsCommaDot,
+	"fr":    symbolsCommaSpace,
+	"fr_fr": symbolsCommaSpace,
+	"fr_be": symbolsCommaSpace,
+	"fr_ca": symbolsCommaSpace,
+	"fr_ch": symbolsCommaSpace,
+	"fr_lu": symbolsCommaDot,
+	"it":    symbolsCommaDot,
+	"it_it": symbolsCommaDot,
+	"it_ch": symbolsDotQuote,
+	"es":    symbolsCommaDot,
+	"es_es": symbolsCommaDot,
+	"es_ar": symbolsCommaDot,
+	"es_cl": symbolsCommaDot,
+	"es_co": symbolsCommaDot,
+	"es_mx": symbolsDotComma,
+	"es_us": symbolsDotComma,
+	"pt":    symbolsCommaDot,
+	"pt_br": symbolsCommaDot,
+	"pt_pt": symbolsCommaSpace,
+	"nl":    symbolsCommaDot,
+	"nl_nl": symbolsCommaDot,
+	"nl_be": symbolsCommaDot,
+	"da":    symbolsCommaDot,
+	"sv":    symbolsCommaSpace,
+	"nb":    symbolsCommaSpace,
+	"nn":    symbolsCommaSpace,
+	"no":    symbolsCommaSpace,
+	"fi":    symbolsCommaSpace,
+	"is":    symbolsCommaDot,
+	"pl":    symbolsCommaSpace,
+	"cs":    symbolsCommaSpace,
+	"sk":    symbolsCommaSpace,
+	"sl":    symbolsCommaDot,
+	"hr":    symbolsCommaDot,
+	"bs":    symbolsCommaDot,
+	"sr":    symbolsCommaDot,
+	"mk":    symbolsCommaDot,
+	"hu":    symbolsCommaSpace,
+	"ro":    symbolsCommaDot,
+	"bg":    symbolsCommaSpace,
+	"ru":    symbolsCommaSpace,
+	"uk":    symbolsCommaSpace,
+	"be":    symbolsCommaSpace,
+	"lt":    symbolsCommaSpace,
+	"lv":    symbolsCommaSpace,
+	"et":    symbolsCommaSpace,
+	"el":    symbolsCommaDot,
+	"tr":    symbolsCommaDot,
+	"ca":    symbolsCommaDot,
+	"gl":    symbolsCommaDot,
+	"eu":    symbolsCommaDot,
+	"id":    symbolsCommaDot,
+	"vi":    symbolsCommaDot,
+	"ms":    symbolsDotComma,
+	"fil":   symbolsDotComma,
+	"ga":    symbolsDotComma,
+	"mt":    symbolsDotComma,
+	"sw":    symbolsDotComma,
+	"he":    symbolsDotComma,
+	"hi":    symbolsDotComma,
+	"th":    symbolsDotComma,
+	"ja":    symbolsDotComma,
+	"ko":    symbolsDotComma,
+	"zh":    symbolsDotComma,
+	"zh_cn": symbolsDotComma,
+	"zh_hk": symbolsDotComma,
+	"zh_tw": symbolsDotComma,
+	"rm":    symbolsDotQuote,
+	"wae":   symbolsCommaQuote,
+	"kk":    symbolsCommaSpace,
+	"hy":    symbolsCommaSpace,
+	"ka":    symbolsCommaSpace,
+	"sq":    symbolsCommaSpace,
+	"af":    symbolsCommaSpace,
+	"ps":    symbolsDotSpace,
+	"root":  symbolsDotNoGroup,
+}
+
+// normalizeLocale converts a locale like "de-CH" or "de_CH" to the key used for locale lookups
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "-", "_", -1))
+}
+
+// getNumberSymbols returns the number symbols for the given locale. If there are no symbols
+// for the exact locale the symbols of its language are used, false is returned if the
+// language is unknown as well.
+func getNumberSymbols(locale string) (numberSymbols, bool) {
+	locale = normalizeLocale(locale)
+	if symbols, ok := localeNumberSymbols[locale]; ok {
+		return symbols, true
+	}
+	if pos := strings.Index(locale, "_"); pos != -1 {
+		if symbols, ok := localeNumberSymbols[locale[:pos]]; ok {
+			return symbols, true
+		}
+	}
+	return localeNumberSymbols[defaultNumberLocale], false
+}
+
+// isValidLocale returns true if number symbols are available for the given locale
+func isValidLocale(locale string) bool {
+	_, ok := getNumberSymbols(locale)
+	return ok
+}
+
+// parseLocaleNumber parses a number string, the string can either be in plain format
+// (e.g. "1234.5") or formatted with the group and decimal separators of the locale (e.g. "1.234,5" for "de").
+// Strings which are not formatted like numbers of the locale are invalid (see isLocaleNumber).
+func parseLocaleNumber(value string, locale string) (float64, error) {
+	value = strings.TrimSpace(value)
+	rv, err := strconv.ParseFloat(value, 64)
+	if err == nil {
+		return rv, nil
+	}
+	if isLocaleNumber(value, locale) {
+		if rv, err2 := strconv.ParseFloat(normalizeNumberString(value, locale), 64); err2 == nil {
+			return rv, nil
+		}
+	}
+	return 0, err
+}
+
+// isLocaleNumber returns true if the string is a plain number or a number formatted with the separators
+// of the locale where the group separators are followed by groups of 3 digits (2 digits are allowed
+// before the last group for secondary grouping, e.g. "1,23,456"), e.g. "1,5" is not a number for "en".
+func isLocaleNumber(value string, locale string) bool {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return true
+	}
+	symbols, _ := getNumberSymbols(locale)
+	integerPart := value
+	if pos := strings.Index(value, symbols.Decimal); pos != -1 {
+		if symbols.Group != "" && strings.Contains(value[pos:], symbols.Group) {
+			return false
+		}
+		integerPart = value[:pos]
+	}
+	if symbols.Group != "" && strings.Contains(integerPart, symbols.Group) {
+		groups := strings.Split(integerPart, symbols.Group)
+		for i, group := range groups[1:] {
+			if len(group) != 3 && (len(group) != 2 || i == len(groups)-2) {
+				return false
+			}
+		}
+	}
+	_, err := strconv.ParseFloat(normalizeNumberString(value, locale), 64)
+	return err == nil
+}
+
+// normalizeNumberString removes the group separators of the locale from a number string
+// and replaces the decimal separator with "."
+func normalizeNumberString(value string, locale string) string {
+	symbols, _ := getNumberSymbols(locale)
+	normalized := strings.TrimSpace(value)
+	if symbols.Group != "" {
+		normalized = strings.Replace(normalized, symbols.Group, "", -1)
+		if symbols.Group == "\u00a0" {
+			// no-break space is often entered as normal space
+			normalized = strings.Replace(normalized, " ", "", -1)
+		}
+	}
+	return strings.Replace(normalized, symbols.Decimal, ".", 1)
+}
+
+const (
+	padBeforePrefix = iota
+	padAfterPrefix
+	padBeforeSuffix
+	padAfterSuffix
+)
+
+// currencyPlaceholder and currencyCodePlaceholder mark the position of the currency symbol
+// and the currency code in a prefix or suffix
+const (
+	currencyPlaceholder     = "¤"
+	currencyCodePlaceholder = "¤¤"
+)
+
+// NumberFormatter formats numbers with a CLDR (Unicode LDML) number pattern,
+// e.g. "#,##0.00", "0.0%", "¤ #,##0.00" or "#,##0.00;(#,##0.00)".
+// Supported pattern features are positive/negative subpatterns, primary and secondary
+// grouping sizes (e.g. "#,##,##0" for Indian grouping), minimum integer digits, minimum and maximum
+// fraction digits, percent and per mille, scientific notation (e.g. "0.###E0"), quoted literals
+// and padding (e.g. "*x#,##0"). The "$" character is treated as currency symbol like "¤" for
+// compatibility with patterns of the ReportBro Designer.
+//
+// If Currency is set to an ISO 4217 code "¤" is replaced by the symbol of the currency in the
+// locale (or by the code if CurrencyDisplay is CurrencyDisplayCode), "¤¤" is always replaced by the code
+// and the fraction digits of the currency are used. Otherwise "¤" is replaced by CurrencySymbol.
+// Symbols which are not available in the font of the text (e.g. "₹" in the built-in pdf fonts)
+// are replaced by the code as well.
+//
+// Numbers are rounded with RoundingMode, the default is half-even like in other CLDR formatters.
+type NumberFormatter struct {
+	Pattern         string
+	Locale          string
+	Currency        string
+	CurrencyDisplay CurrencyDisplay
+	CurrencySymbol  string
+	RoundingMode    RoundingMode
+
+	symbols           numberSymbols
+	positivePrefix    string
+	positiveSuffix    string
+	negativePrefix    string
+	negativeSuffix    string
+	minIntegerDigits  int
+	minFractionDigits int
+	maxFractionDigits int
+	primaryGrouping   int
+	secondaryGrouping int
+	multiplier        int
+	minExponentDigits int
+	exponentPlusSign  bool
+	padChar           string
+	padPosition       int
+	formatWidth       int
+	hasCurrency       bool
+	// isRenderable returns true if the font of the formatted text has glyphs for the text,
+	// the currency code is used instead of a symbol which cannot be rendered
+	isRenderable func(text string) bool
+}
+
+func (self *NumberFormatter) init(pattern string, locale string) {
+	self.Pattern = pattern
+	self.Locale = locale
+	self.symbols, _ = getNumberSymbols(locale)
+	self.CurrencyDisplay = CurrencyDisplaySymbol
+	self.RoundingMode = RoundingModeHalfEven
+	self.padPosition = -1
+
+	positivePattern := pattern
+	negativePattern := ""
+	if pos := findUnquoted(pattern, ';'); pos != -1 {
+		positivePattern = pattern[:pos]
+		negativePattern = pattern[pos+1:]
+	}
+	self.positivePrefix, self.positiveSuffix = self.parseSubpattern(positivePattern, true)
+	if negativePattern != "" {
+		self.negativePrefix, self.negativeSuffix = self.parseSubpattern(negativePattern, false)
+	} else {
+		self.negativePrefix = self.symbols.Minus + self.positivePrefix
+		self.negativeSuffix = self.positiveSuffix
+	}
+}
+
+// findUnquoted returns the index of the first occurrence of the given character outside
+// of quoted literals or -1 if not found
+func findUnquoted(pattern string, ch byte) int {
+	quoted := false
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\'' {
+			quoted = !quoted
+		} else if pattern[i] == ch && !quoted {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSubpattern parses prefix, number part and suffix of a subpattern. The number part
+// and padding is only used from the positive subpattern, for the negative subpattern
+// only prefix and suffix are relevant.
+func (self *NumberFormatter) parseSubpattern(pattern string, positive bool) (string, string) {
+	var prefix, suffix, number strings.Builder
+	// 0 = prefix, 1 = number, 2 = suffix
+	phase := 0
+	width := 0
+	padPos := -1
+	padChar := ""
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		if phase < 2 && (ch == '#' || ch == '0' || ch == ',' || ch == '.' || ch == '@' ||
+			(phase == 1 && (ch == 'E' || (ch >= '1' && ch <= '9') || (ch == '+' && i > 0 && runes[i-1] == 'E')))) {
+			phase = 1
+			number.WriteRune(ch)
+			width++
+			continue
+		}
+		if phase == 1 {
+			phase = 2
+		}
+		affix := &prefix
+		if phase == 2 {
+			affix = &suffix
+		}
+		switch ch {
+		case '\'':
+			// quoted literal, two single quotes represent a single quote
+			end := i + 1
+			if end < len(runes) && runes[end] == '\'' {
+				affix.WriteRune('\'')
+				width++
+				i = end
+				continue
+			}
+			for end < len(runes) && runes[end] != '\'' {
+				affix.WriteRune(runes[end])
+				width++
+				end++
+			}
+			i = end
+		case '*':
+			if i+1 < len(runes) {
+				padChar = string(runes[i+1])
+				if phase == 0 {
+					if prefix.Len() == 0 {
+						padPos = padBeforePrefix
+					} else {
+						padPos = padAfterPrefix
+					}
+				} else if suffix.Len() == 0 {
+					padPos = padBeforeSuffix
+				} else {
+					padPos = padAfterSuffix
+				}
+				i++
+			}
+		case '%':
+			self.multiplier = 100
+			affix.WriteString(self.symbols.Percent)
+			width++
+		case '‰':
+			self.multiplier = 1000
+			affix.WriteString(self.symbols.PerMille)
+			width++
+		case '¤', '$':
+			if ch == '¤' && i+1 < len(runes) && runes[i+1] == '¤' {
+				affix.WriteString(currencyCodePlaceholder)
+				for i+1 < len(runes) && runes[i+1] == '¤' {
+					i++
+				}
+			} else {
+				affix.WriteString(currencyPlaceholder)
+			}
+			self.hasCurrency = true
+			width++
+		case '-':
+			affix.WriteString(self.symbols.Minus)
+			width++
+		default:
+			affix.WriteRune(ch)
+			width++
+		}
+	}
+	if positive {
+		self.parseNumberPart(number.String())
+		if padChar != "" {
+			self.padChar = padChar
+			self.padPosition = padPos
+			self.formatWidth = width
+		}
+	}
+	return prefix.String(), suffix.String()
+}
+
+// parseNumberPart sets digit counts and grouping sizes from the number part of a pattern
+func (self *NumberFormatter) parseNumberPart(number string) {
+	if pos := strings.Index(number, "E"); pos != -1 {
+		exponent := number[pos+1:]
+		if strings.HasPrefix(exponent, "+") {
+			self.exponentPlusSign = true
+			exponent = exponent[1:]
+		}
+		self.minExponentDigits = strings.Count(exponent, "0")
+		if self.minExponentDigits == 0 {
+			self.minExponentDigits = 1
+		}
+		number = number[:pos]
+	}
+
+	integerPart := number
+	fractionPart := ""
+	if pos := strings.Index(number, "."); pos != -1 {
+		integerPart = number[:pos]
+		fractionPart = number[pos+1:]
+	}
+
+	integerDigits := strings.Replace(integerPart, ",", "", -1)
+	self.minIntegerDigits = strings.Count(integerDigits, "0") + strings.Count(integerDigits, "@")
+	for _, ch := range integerDigits {
+		if ch >= '1' && ch <= '9' {
+			self.minIntegerDigits++
+		}
+	}
+	self.minFractionDigits = 0
+	for _, ch := range fractionPart {
+		if ch != '#' {
+			self.minFractionDigits++
+		}
+	}
+	self.maxFractionDigits = len(fractionPart)
+
+	if pos := strings.LastIndex(integerPart, ","); pos != -1 {
+		self.primaryGrouping = len(integerPart) - pos - 1
+		if pos2 := strings.LastIndex(integerPart[:pos], ","); pos2 != -1 {
+			self.secondaryGrouping = pos - pos2 - 1
+		} else {
+			self.secondaryGrouping = self.primaryGrouping
+		}
+	}
+}
+
+// Format formats the given number with the pattern of the formatter
+func (self *NumberFormatter) Format(value float64) string {
+	return self.FormatDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// FormatDecimal formats a number given as decimal string (e.g. "-1234.5678"). Using a string
+// avoids rounding errors of binary floating point values when formatting exact decimal numbers.
+func (self *NumberFormatter) FormatDecimal(value string) string {
+	if self.hasCurrency && self.Currency != "" {
+		digits := getCurrencyDigits(self.Currency)
+		if self.minFractionDigits != digits || self.maxFractionDigits != digits {
+			// fraction digits are defined by the currency
+			formatter := *self
+			formatter.minFractionDigits = digits
+			formatter.maxFractionDigits = digits
+			return formatter.FormatDecimal(value)
+		}
+	}
+
+	negative, intDigits, fracDigits := splitDecimal(value)
+	if self.multiplier != 0 {
+		shift := 2
+		if self.multiplier == 1000 {
+			shift = 3
+		}
+		intDigits, fracDigits = shiftDecimal(intDigits, fracDigits, shift)
+	}
+
+	var number string
+	if self.minExponentDigits > 0 {
+		number = self.formatScientific(intDigits, fracDigits, negative)
+	} else {
+		intDigits, fracDigits = roundDecimal(intDigits, fracDigits, self.maxFractionDigits, self.RoundingMode, negative)
+		number = self.formatDigits(intDigits, fracDigits)
+	}
+	if negative && strings.Trim(intDigits+fracDigits, "0") == "" {
+		// do not display negative zero
+		negative = false
+	}
+
+	prefix, suffix := self.positivePrefix, self.positiveSuffix
+	if negative {
+		prefix, suffix = self.negativePrefix, self.negativeSuffix
+	}
+	if self.hasCurrency {
+		prefix = self.replaceCurrency(prefix, true)
+		suffix = self.replaceCurrency(suffix, false)
+	}
+	return self.pad(prefix, number, suffix)
+}
+
+// replaceCurrency replaces the currency placeholders of a prefix or suffix. A no-break space
+// is inserted between the number and an adjacent currency symbol or code consisting of letters,
+// e.g. "CHF 12.00" but "$12.00".
+func (self *NumberFormatter) replaceCurrency(affix string, isPrefix bool) string {
+	if self.Currency == "" {
+		affix = strings.Replace(affix, currencyCodePlaceholder, self.CurrencySymbol, -1)
+		return strings.Replace(affix, currencyPlaceholder, self.CurrencySymbol, -1)
+	}
+	symbol := self.Currency
+	if self.CurrencyDisplay != CurrencyDisplayCode {
+		symbol = getCurrencySymbol(self.Currency, self.Locale)
+		if self.isRenderable != nil && !self.isRenderable(symbol) {
+			symbol = self.Currency
+		}
+	}
+	code := self.Currency
+	if isPrefix && strings.HasSuffix(affix, currencyPlaceholder) {
+		lastSymbol, _ := utf8.DecodeLastRuneInString(symbol)
+		if strings.HasSuffix(affix, currencyCodePlaceholder) || unicode.IsLetter(lastSymbol) {
+			affix += "\u00a0"
+		}
+	} else if !isPrefix && strings.HasPrefix(affix, currencyPlaceholder) {
+		firstSymbol, _ := utf8.DecodeRuneInString(symbol)
+		if strings.HasPrefix(affix, currencyCodePlaceholder) || unicode.IsLetter(firstSymbol) {
+			affix = "\u00a0" + affix
+		}
+	}
+	affix = strings.Replace(affix, currencyCodePlaceholder, code, -1)
+	return strings.Replace(affix, currencyPlaceholder, symbol, -1)
+}
+
+// formatDigits applies minimum digits, grouping and locale symbols to already rounded digits
+func (self *NumberFormatter) formatDigits(intDigits string, fracDigits string) string {
+	intDigits = strings.TrimLeft(intDigits, "0")
+	for len(intDigits) < self.minIntegerDigits {
+		intDigits = "0" + intDigits
+	}
+	fracDigits = strings.TrimRight(fracDigits, "0")
+	for len(fracDigits) < self.minFractionDigits {
+		fracDigits += "0"
+	}
+	if intDigits == "" && fracDigits == "" {
+		// zero is printed even if the pattern has no required digits, e.g. "#,###"
+		intDigits = "0"
+	}
+
+	var sb strings.Builder
+	if self.primaryGrouping > 0 && len(intDigits) > self.primaryGrouping {
+		groups := make([]string, 0)
+		rest := intDigits
+		size := self.primaryGrouping
+		for len(rest) > size {
+			groups = append([]string{rest[len(rest)-size:]}, groups...)
+			rest = rest[:len(rest)-size]
+			size = self.secondaryGrouping
+		}
+		groups = append([]string{rest}, groups...)
+		sb.WriteString(strings.Join(groups, self.symbols.Group))
+	} else {
+		sb.WriteString(intDigits)
+	}
+	if fracDigits != "" {
+		sb.WriteString(self.symbols.Decimal)
+		sb.WriteString(fracDigits)
+	}
+	return sb.String()
+}
+
+// formatScientific formats the digits in scientific notation, the exponent is chosen so the
+// number of integer digits equals the minimum integer digits of the pattern
+func (self *NumberFormatter) formatScientific(intDigits string, fracDigits string, negative bool) string {
+	digits := strings.TrimLeft(intDigits+fracDigits, "0")
+	exponent := 0
+	if digits != "" {
+		// position of the decimal point relative to the first significant digit
+		exponent = len(intDigits) - (len(intDigits+fracDigits) - len(digits))
+		integerDigits := self.minIntegerDigits
+		if integerDigits < 1 {
+			integerDigits = 1
+		}
+		exponent -= integerDigits
+		for len(digits) < integerDigits {
+			digits += "0"
+		}
+		intDigits, fracDigits = digits[:integerDigits], digits[integerDigits:]
+		intDigits, fracDigits = roundDecimal(intDigits, fracDigits, self.maxFractionDigits, self.RoundingMode, negative)
+		if len(intDigits) > integerDigits {
+			// rounding added a digit, e.g. 9.99 -> 10.0
+			fracDigits = intDigits[integerDigits:] + fracDigits
+			intDigits = intDigits[:integerDigits]
+			fracDigits = strings.TrimRight(fracDigits, "0")
+			exponent++
+		}
+	} else {
+		intDigits, fracDigits = "0", ""
+	}
+
+	exponentSign := ""
+	if exponent < 0 {
+		exponentSign = self.symbols.Minus
+		exponent = -exponent
+	} else if self.exponentPlusSign {
+		exponentSign = self.symbols.Plus
+	}
+	exponentDigits := strconv.Itoa(exponent)
+	for len(exponentDigits) < self.minExponentDigits {
+		exponentDigits = "0" + exponentDigits
+	}
+
+	var sb strings.Builder
+	sb.WriteString(intDigits)
+	fracDigits = strings.TrimRight(fracDigits, "0")
+	for len(fracDigits) < self.minFractionDigits {
+		fracDigits += "0"
+	}
+	if fracDigits != "" {
+		sb.WriteString(self.symbols.Decimal)
+		sb.WriteString(fracDigits)
+	}
+	sb.WriteString(self.symbols.Exponent)
+	sb.WriteString(exponentSign)
+	sb.WriteString(exponentDigits)
+	return sb.String()
+}
+
+// pad fills the formatted number with the pad character up to the format width of the pattern
+func (self *NumberFormatter) pad(prefix string, number string, suffix string) string {
+	if self.padChar != "" {
+		length := utf8.RuneCountInString(prefix) + utf8.RuneCountInString(number) + utf8.RuneCountInString(suffix)
+		if length < self.formatWidth {
+			padding := strings.Repeat(self.padChar, self.formatWidth-length)
+			switch self.padPosition {
+			case padBeforePrefix:
+				prefix = padding + prefix
+			case padAfterPrefix:
+				prefix = prefix + padding
+			case padBeforeSuffix:
+				suffix = padding + suffix
+			case padAfterSuffix:
+				suffix = suffix + padding
+			}
+		}
+	}
+	return prefix + number + suffix
+}
+
+// splitDecimal splits a decimal string into sign, integer digits and fraction digits
+func splitDecimal(value string) (bool, string, string) {
+	value = strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(value, "-") {
+		negative = true
+		value = value[1:]
+	} else if strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+	intDigits := value
+	fracDigits := ""
+	if pos := strings.Index(value, "."); pos != -1 {
+		intDigits = value[:pos]
+		fracDigits = value[pos+1:]
+	}
+	if intDigits == "" {
+		intDigits = "0"
+	}
+	return negative, intDigits, fracDigits
+}
+
+// shiftDecimal moves the decimal point of the number to the right by the given number of digits
+func shiftDecimal(intDigits string, fracDigits string, shift int) (string, string) {
+	for len(fracDigits) < shift {
+		fracDigits += "0"
+	}
+	return intDigits + fracDigits[:shift], fracDigits[shift:]
+}
+
+// roundDecimal rounds the digits of a number to the given number of fraction digits
+// with the rounding mode, negative is needed for rounding towards ceiling or floor
+func roundDecimal(intDigits string, fracDigits string, fractionDigits int, roundingMode RoundingMode, negative bool) (string, string) {
+	if len(fracDigits) <= fractionDigits {
+		return intDigits, fracDigits
+	}
+	digits := []byte(intDigits + fracDigits[:fractionDigits])
+	rest := fracDigits[fractionDigits:]
+	if strings.Trim(rest, "0") == "" {
+		return intDigits, fracDigits[:fractionDigits]
+	}
+	roundUp := false
+	switch roundingMode {
+	case RoundingModeUp:
+		roundUp = true
+	case RoundingModeDown:
+		roundUp = false
+	case RoundingModeCeiling:
+		roundUp = !negative
+	case RoundingModeFloor:
+		roundUp = negative
+	default:
+		if rest[0] > '5' || (rest[0] == '5' && strings.Trim(rest[1:], "0") != "") {
+			roundUp = true
+		} else if rest[0] == '5' {
+			// tie
+			switch roundingMode {
+			case RoundingModeHalfUp:
+				roundUp = true
+			case RoundingModeHalfDown:
+				roundUp = false
+			default:
+				last := byte('0')
+				if len(digits) > 0 {
+					last = digits[len(digits)-1]
+				}
+				roundUp = (last-'0')%2 == 1
+			}
+		}
+	}
+	if roundUp {
+		i := len(digits) - 1
+		for i >= 0 {
+			if digits[i] == '9' {
+				digits[i] = '0'
+				i--
+			} else {
+				digits[i]++
+				break
+			}
+		}
+		if i < 0 {
+			digits = append([]byte{'1'}, digits...)
+		}
+	}
+	intLen := len(digits) - fractionDigits
+	return string(digits[:intLen]), string(digits[intLen:])
+}
+
+// NewNumberFormatter creates a formatter for the given number pattern and locale
+func NewNumberFormatter(pattern string, locale string) NumberFormatter {
+	formatter := NumberFormatter{}
+	formatter.init(pattern, locale)
+	return formatter
+}
@@ -0,0 +1,124 @@
+package reportbro
+
+import "testing"
+
+func TestNumberFormatterFormat(t *testing.T) {
+	tests := []struct {
+		pattern string
+		locale  string
+		value   float64
+		want    string
+	}{
+		{"#,##0.00", "en", 1234567.891, "1,234,567.89"},
+		{"#,##0.00", "de", -1234567.891, "-1.234.567,89"},
+		{"#,##0.00", "fr", 1234.5, "1\u00a0234,50"},
+		{"#,##0.00", "de_CH", 1234.5, "1’234.50"},
+		{"#,##,##0.##", "en_IN", 12345678.5, "1,23,45,678.5"},
+		{"0.0%", "en", 0.1234, "12.3%"},
+		{"#,##0‰", "en", 0.5, "500‰"},
+		{"#,##0.00;(#,##0.00)", "en", -12.5, "(12.50)"},
+		{"*x#,##0", "en", 12, "xxx12"},
+		{"0.###E0", "en", 12345, "1.234E4"},
+		{"00.###E+00", "en", 0.000123, "12.3E-05"},
+		{"#.##", "en", 0.5, ".5"},
+		{"#", "en", 0, "0"},
+		{"#,###", "en", 0, "0"},
+		{"#.##", "en", 0, "0"},
+		{"#.##", "en", 0.001, "0"},
+		{"#,###", "en", -0.2, "0"},
+		{"'#'0", "en", 5, "#5"},
+		{"0000", "en", 42, "0042"},
+		{"0.00", "en", 0.125, "0.12"},
+		{"0.00", "en", 0.375, "0.38"},
+		{"#,##0.00 ¤", "de", 9.995, "10,00 CHF"},
+	}
+	for _, test := range tests {
+		formatter := NewNumberFormatter(test.pattern, test.locale)
+		formatter.CurrencySymbol = "CHF"
+		if got := formatter.Format(test.value); got != test.want {
+			t.Errorf("Format(%q, %s, %v) = %q, want %q", test.pattern, test.locale, test.value, got, test.want)
+		}
+	}
+}
+
+func TestParseLocaleNumber(t *testing.T) {
+	tests := []struct {
+		value  string
+		locale string
+		want   float64
+	}{
+		{"1234.5", "de", 1234.5},
+		{"1,234.5", "en", 1234.5},
+		{"1.234,5", "de", 1234.5},
+		{"1 234,5", "fr", 1234.5},
+		{"1’234.5", "de_CH", 1234.5},
+		{" -12 ", "en", -12},
+	}
+	for _, test := range tests {
+		got, err := parseLocaleNumber(test.value, test.locale)
+		if err != nil || got != test.want {
+			t.Errorf("parseLocaleNumber(%q, %s) = %v, %v, want %v", test.value, test.locale, got, err, test.want)
+		}
+	}
+	for _, value := range []string{"abc", "1,5", "1.234,5"} {
+		if got, err := parseLocaleNumber(value, "en"); err == nil {
+			t.Errorf("parseLocaleNumber(%q, en) = %v, want error", value, got)
+		}
+	}
+}
+
+func TestParseFloatValue(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		locale string
+		want   float64
+	}{
+		{12.5, "en", 12.5},
+		{7, "en", 7},
+		{"1,234", "en", 1234},
+		{"1,234.5", "en", 1234.5},
+		{"1,23,456", "en_IN", 123456},
+		// strings which are not grouped like numbers of the locale use the last comma as decimal separator
+		{"1,5", "en", 1.5},
+		{"1,50", "en", 1.5},
+		{"1,5", "de", 1.5},
+		{"1.234,5", "de", 1234.5},
+		{"1,234,5", "en", 1234.5},
+	}
+	for _, test := range tests {
+		got, err := parseFloatValue(test.value, test.locale)
+		if err != nil || got != test.want {
+			t.Errorf("parseFloatValue(%#v, %s) = %v, %v, want %v", test.value, test.locale, got, err, test.want)
+		}
+	}
+	for _, value := range []interface{}{"abc", "1.234,5", map[string]interface{}{}} {
+		if got, err := parseFloatValue(value, "en"); err == nil {
+			t.Errorf("parseFloatValue(%#v) = %v, want error", value, got)
+		}
+	}
+}
+
+func TestNumberFormatterCurrencySymbolNotRenderable(t *testing.T) {
+	pdfDoc := newFPDFRB(documentProperties{PageFormat: PageFormatA4, Orientation: OrientationPortrait}, "")
+	isRenderable := func(text string) bool {
+		return pdfDoc.canRenderText(text, "helvetica", "", nil)
+	}
+	tests := []struct {
+		currency string
+		locale   string
+		want     string
+	}{
+		{"EUR", "de", "1.234,50 €"},
+		{"GBP", "en_GB", "£1,234.50"},
+		{"INR", "en_IN", "INR\u00a01,234.50"},
+		{"PLN", "pl", "1\u00a0234,50 PLN"},
+	}
+	for _, test := range tests {
+		formatter := NewNumberFormatter(getLocaleCurrencyPattern(test.locale), test.locale)
+		formatter.Currency = test.currency
+		formatter.isRenderable = isRenderable
+		if got := formatter.Format(1234.5); got != test.want {
+			t.Errorf("Format(%s, %s) = %q, want %q", test.currency, test.locale, got, test.want)
+		}
+	}
+}
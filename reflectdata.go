@@ -0,0 +1,303 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	decimalType    = reflect.TypeOf(decimal.Decimal{})
+	byteSliceType  = reflect.TypeOf([]byte(nil))
+	structFieldMap sync.Map // reflect.Type -> map[string]dataStructField
+)
+
+// dataStructField is a struct field mapped to a parameter name by its json tag
+type dataStructField struct {
+	index     []int
+	omitEmpty bool
+}
+
+// normalizeData converts the report data to a map. Data can be a map, a struct (or pointer to a struct)
+// whose fields are mapped by their json tags, or JSON encoded bytes of an object.
+func normalizeData(data interface{}) (interface{}, error) {
+	switch v := data.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return v, nil
+	case json.RawMessage:
+		return unmarshalData(v)
+	case []byte:
+		return unmarshalData(v)
+	case string:
+		return unmarshalData([]byte(v))
+	}
+	rv := indirectValue(reflect.ValueOf(data))
+	if !rv.IsValid() {
+		return map[string]interface{}{}, nil
+	}
+	if !isDataRecord(rv.Interface()) {
+		return nil, fmt.Errorf("report data must be a map, struct or JSON object, got %T", data)
+	}
+	return rv.Interface(), nil
+}
+
+func unmarshalData(data []byte) (interface{}, error) {
+	rv := map[string]interface{}{}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return rv, nil
+	}
+	if err := json.Unmarshal(data, &rv); err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// indirectValue dereferences pointers and interfaces, an invalid value is returned for nil pointers
+func indirectValue(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// isDataRecord returns true if the value can be used as data of a map parameter or an array row,
+// i.e. it is a map with string keys or a struct which is not handled as a single value
+func isDataRecord(value interface{}) bool {
+	if _, ok := value.(map[string]interface{}); ok {
+		return true
+	}
+	rv := indirectValue(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		return false
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		return rv.Type().Key().Kind() == reflect.String
+	case reflect.Struct:
+		return rv.Type() != timeType && rv.Type() != decimalType && !isStringerValue(value)
+	}
+	return false
+}
+
+// getDataField returns the value of the named field of a map or struct in the report data,
+// struct fields are matched by their json tag name or their field name if there is no tag
+func getDataField(data interface{}, name string) interface{} {
+	if m, ok := data.(map[string]interface{}); ok {
+		return m[name]
+	}
+	rv := indirectValue(reflect.ValueOf(data))
+	if !rv.IsValid() {
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		value := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !value.IsValid() {
+			return nil
+		}
+		return value.Interface()
+	case reflect.Struct:
+		field, ok := getStructFields(rv.Type())[name]
+		if !ok {
+			return nil
+		}
+		value, ok := fieldByIndex(rv, field.index)
+		if !ok || (field.omitEmpty && value.IsZero()) {
+			return nil
+		}
+		return value.Interface()
+	}
+	return nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but returns false instead of panicking
+// in case an embedded struct pointer is nil
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, idx := range index {
+		if i > 0 {
+			rv = indirectValue(rv)
+			if !rv.IsValid() {
+				return rv, false
+			}
+		}
+		rv = rv.Field(idx)
+	}
+	return rv, true
+}
+
+// getStructFields returns the exported fields of a struct type by their json name, fields of
+// embedded structs without json tag are promoted like in encoding/json
+func getStructFields(t reflect.Type) map[string]dataStructField {
+	if fields, ok := structFieldMap.Load(t); ok {
+		return fields.(map[string]dataStructField)
+	}
+	fields := map[string]dataStructField{}
+	collectStructFields(t, nil, fields, map[reflect.Type]bool{})
+	structFieldMap.Store(t, fields)
+	return fields
+}
+
+func collectStructFields(t reflect.Type, index []int, fields map[string]dataStructField, visited map[reflect.Type]bool) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	// fields of embedded structs are added afterwards so fields of the outer struct take precedence
+	embedded := make([]reflect.StructField, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded = append(embedded, field)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		fields[name] = dataStructField{index: fieldIndex, omitEmpty: strings.Contains(","+options+",", ",omitempty,")}
+	}
+	for _, field := range embedded {
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		embeddedFields := map[string]dataStructField{}
+		collectStructFields(fieldType, append(append([]int{}, index...), field.Index...), embeddedFields, visited)
+		for name, embeddedField := range embeddedFields {
+			if _, exists := fields[name]; !exists {
+				fields[name] = embeddedField
+			}
+		}
+	}
+}
+
+// isStringerValue returns true for struct values implementing fmt.Stringer (except time.Time and
+// decimal.Decimal which are supported directly), e.g. decimal types of other packages or *big.Float.
+// These are treated as single value and converted with their String method.
+func isStringerValue(value interface{}) bool {
+	if _, ok := value.(fmt.Stringer); !ok {
+		return false
+	}
+	rv := indirectValue(reflect.ValueOf(value))
+	return rv.IsValid() && rv.Kind() == reflect.Struct && rv.Type() != timeType && rv.Type() != decimalType
+}
+
+// getDataList returns the items of a slice or array in the report data, []byte is not
+// treated as list because it contains binary data (e.g. an image)
+func getDataList(value interface{}) ([]interface{}, bool) {
+	if list, ok := value.([]interface{}); ok {
+		return list, true
+	}
+	rv := indirectValue(reflect.ValueOf(value))
+	if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type() == byteSliceType {
+		return nil, false
+	}
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// getDataValue converts a single value of the report data to the types used internally:
+// pointers are dereferenced and values of named types (e.g. type Status string) are converted
+// to their basic type. time.Time, decimals, []byte, fmt.Stringer and RowSource values are returned unchanged.
+func getDataValue(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, bool, int, float64, time.Time, decimal.Decimal, []byte, RowSource:
+		return value
+	}
+	rv := indirectValue(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		return nil
+	}
+	if rv.Type() == timeType || rv.Type() == decimalType || rv.Type() == byteSliceType {
+		return rv.Interface()
+	}
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.IsNil() {
+		return nil
+	}
+	if isStringerValue(value) {
+		return value
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint())
+	case reflect.Float32:
+		// convert with shortest representation so e.g. float32(0.1) does not become 0.10000000149011612
+		return decimal.NewFromFloat32(float32(rv.Float())).InexactFloat64()
+	case reflect.Float64:
+		return rv.Float()
+	}
+	return rv.Interface()
+}
+
+// normalizeDataValue converts a value of the report data including all nested values to maps, lists
+// and the basic types returned by getDataValue. It is used for data which is not mapped to a parameter
+// structure, e.g. nested arrays inside array rows.
+func normalizeDataValue(value interface{}) interface{} {
+	value = getDataValue(value)
+	if value == nil {
+		return nil
+	}
+	if list, ok := getDataList(value); ok {
+		rv := make([]interface{}, len(list))
+		for i, item := range list {
+			rv[i] = normalizeDataValue(item)
+		}
+		return rv
+	}
+	if !isDataRecord(value) {
+		return value
+	}
+	rv := map[string]interface{}{}
+	val := indirectValue(reflect.ValueOf(value))
+	if val.Kind() == reflect.Map {
+		iter := val.MapRange()
+		for iter.Next() {
+			rv[iter.Key().String()] = normalizeDataValue(iter.Value().Interface())
+		}
+	} else {
+		for name := range getStructFields(val.Type()) {
+			if fieldValue := getDataField(value, name); fieldValue != nil {
+				rv[name] = normalizeDataValue(fieldValue)
+			}
+		}
+	}
+	return rv
+}
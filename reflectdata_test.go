@@ -0,0 +1,170 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type testStatus string
+
+type testAudit struct {
+	CreatedBy string    `json:"createdBy"`
+	Created   time.Time `json:"created"`
+}
+
+type testContact struct {
+	Email string `json:"email"`
+}
+
+type testItem struct {
+	Name     string          `json:"name"`
+	Price    decimal.Decimal `json:"price"`
+	Quantity *int            `json:"quantity"`
+}
+
+type testInvoice struct {
+	testAudit
+	*testContact
+	Number   string     `json:"number"`
+	Status   testStatus `json:"status"`
+	Total    float32    `json:"total"`
+	Paid     *bool      `json:"paid"`
+	Due      *time.Time `json:"due"`
+	Note     string     `json:"note,omitempty"`
+	Internal string     `json:"-"`
+	Customer struct {
+		Name string
+	} `json:"customer"`
+	Items  []testItem `json:"items"`
+	hidden string
+}
+
+func newReflectTestReport(t *testing.T, data interface{}) report {
+	t.Helper()
+	js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","patternLocale":"en"},"parameters":[
+		{"id":1,"name":"number","type":"string"},
+		{"id":2,"name":"status","type":"string"},
+		{"id":3,"name":"total","type":"number"},
+		{"id":4,"name":"paid","type":"boolean","nullable":true},
+		{"id":5,"name":"due","type":"date","nullable":true},
+		{"id":6,"name":"note","type":"string","nullable":true},
+		{"id":7,"name":"Internal","type":"string","nullable":true},
+		{"id":8,"name":"createdBy","type":"string"},
+		{"id":9,"name":"created","type":"datetime"},
+		{"id":10,"name":"email","type":"string","nullable":true},
+		{"id":11,"name":"customer","type":"map","children":[{"id":12,"name":"Name","type":"string"}]},
+		{"id":13,"name":"items","type":"array","children":[
+			{"id":14,"name":"name","type":"string"},
+			{"id":15,"name":"price","type":"number"},
+			{"id":16,"name":"quantity","type":"number","nullable":true}]}],
+		"styles":[],"docElements":[],"version":2}`
+	var definition map[string]interface{}
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	return NewReport(definition, data, false, "", nil)
+}
+
+func TestStructData(t *testing.T) {
+	created := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+	due := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	paid := true
+	quantity := 3
+	invoice := testInvoice{
+		testAudit:   testAudit{CreatedBy: "anna", Created: created},
+		testContact: &testContact{Email: "anna@example.com"},
+		Number:      "R-1",
+		Status:      "open",
+		Total:       0.1,
+		Paid:        &paid,
+		Due:         &due,
+		Internal:    "secret",
+		Items: []testItem{
+			{Name: "a", Price: decimal.RequireFromString("1.25"), Quantity: &quantity},
+			{Name: "b", Price: decimal.RequireFromString("0.1")},
+		},
+		hidden: "hidden",
+	}
+	invoice.Customer.Name = "ACME"
+
+	// the data can be given as struct or pointer to a struct
+	for _, data := range []interface{}{invoice, &invoice} {
+		report := newReflectTestReport(t, data)
+		if len(report.Errors()) > 0 {
+			t.Fatalf("%T: errors %v", data, report.Errors())
+		}
+		want := map[string]interface{}{
+			"number": "R-1", "status": "open", "total": 0.1, "paid": true, "note": nil, "Internal": nil,
+			"createdBy": "anna", "email": "anna@example.com",
+		}
+		for name, value := range want {
+			if got := report.Data[name]; got != value {
+				t.Errorf("%T: %s = %#v, want %#v", data, name, got, value)
+			}
+		}
+		if got, ok := report.Data["created"].(time.Time); !ok || !got.Equal(created) {
+			t.Errorf("%T: created = %v, want %v", data, report.Data["created"], created)
+		}
+		if got, ok := report.Data["due"].(time.Time); !ok || !got.Equal(due) {
+			t.Errorf("%T: due = %v, want %v", data, report.Data["due"], due)
+		}
+		if got := fmt.Sprint(report.Data["customer"]); got != "map[Name:ACME]" {
+			t.Errorf("%T: customer = %s, want map[Name:ACME]", data, got)
+		}
+		if got := fmt.Sprint(report.Data["items"]); got != "[map[name:a price:1.25 quantity:3] map[name:b price:0.1 quantity:<nil>]]" {
+			t.Errorf("%T: items = %s", data, got)
+		}
+	}
+}
+
+func TestStructDataNilPointers(t *testing.T) {
+	// nil pointers of fields and embedded structs are null values
+	report := newReflectTestReport(t, &testInvoice{Number: "R-2"})
+	for _, name := range []string{"paid", "due", "email"} {
+		if value := report.Data[name]; value != nil {
+			t.Errorf("%s = %#v, want nil", name, value)
+		}
+	}
+	if got := report.Data["items"]; !reflect.DeepEqual(got, []interface{}{}) {
+		t.Errorf("items = %#v, want empty list", got)
+	}
+	var invoice *testInvoice
+	if report := newReflectTestReport(t, invoice); len(report.Errors()) > 0 {
+		t.Errorf("nil pointer: errors %v", report.Errors())
+	}
+	if report := newReflectTestReport(t, []int{1}); !hasError(report, "errorMsgInvalidData") {
+		t.Errorf("slice: errors = %v, want errorMsgInvalidData", report.Errors())
+	}
+}
+
+func TestNormalizeDataValue(t *testing.T) {
+	created := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	price := decimal.RequireFromString("9.99")
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{testStatus("paid"), "paid"},
+		{float32(0.1), 0.1},
+		{uint8(7), 7},
+		{&price, price},
+		{created, created},
+		{[]byte("abc"), []byte("abc")},
+		{[]testStatus{"a", "b"}, []interface{}{"a", "b"}},
+		{map[string]int{"a": 1}, map[string]interface{}{"a": 1}},
+		{testItem{Name: "x", Price: price}, map[string]interface{}{"name": "x", "price": price, "quantity": nil}},
+		{testAudit{CreatedBy: "anna"}, map[string]interface{}{"createdBy": "anna", "created": time.Time{}}},
+		{[]interface{}(nil), nil},
+		{(*testItem)(nil), nil},
+	}
+	for _, test := range tests {
+		if got := normalizeDataValue(test.value); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("normalizeDataValue(%#v) = %#v, want %#v", test.value, got, test.want)
+		}
+	}
+}
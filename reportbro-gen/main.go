@@ -0,0 +1,86 @@
+// Command reportbro-gen generates Go structs for the data of a report definition and a typed
+// render function, e.g. to be used with go generate:
+//
+//	//go:generate go run github.com/GeorgeD19/reportbro-lib-go/reportbro-gen -report invoice.json -type Invoice
+//
+// Mistyped field names of the report data are compile errors then instead of empty cells in the report.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	reportbro "github.com/GeorgeD19/reportbro-lib-go"
+)
+
+func main() {
+	reportFile := flag.String("report", "", "report definition file (json), may also contain the definition in a \"report\" field")
+	output := flag.String("o", "", "output file, default is <report>_report.go")
+	packageName := flag.String("package", os.Getenv("GOPACKAGE"), "package name of the generated code, default is $GOPACKAGE")
+	typeName := flag.String("type", "ReportData", "name of the struct of the report data")
+	funcName := flag.String("func", "Render", "name of the render function")
+	embed := flag.Bool("embed", true, "include the report definition file with go:embed instead of copying it into the generated code")
+	flag.Parse()
+
+	if *reportFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(*reportFile, filepath.Ext(*reportFile)) + "_report.go"
+	}
+	if err := generate(*reportFile, *output, *packageName, *typeName, *funcName, *embed); err != nil {
+		fmt.Fprintln(os.Stderr, "reportbro-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func generate(reportFile string, output string, packageName string, typeName string, funcName string, embed bool) error {
+	file, err := os.ReadFile(reportFile)
+	if err != nil {
+		return err
+	}
+	var reportDefinition map[string]interface{}
+	if err := json.Unmarshal(file, &reportDefinition); err != nil {
+		return fmt.Errorf("invalid report definition %s: %w", reportFile, err)
+	}
+	if definition, ok := reportDefinition["report"].(map[string]interface{}); ok {
+		// file containing report definition and test data, see example
+		reportDefinition = definition
+	}
+
+	outputDir, err := filepath.Abs(filepath.Dir(output))
+	if err != nil {
+		return err
+	}
+	if packageName == "" {
+		packageName = filepath.Base(outputDir)
+	}
+	embedPath := ""
+	if embed {
+		reportPath, err := filepath.Abs(reportFile)
+		if err != nil {
+			return err
+		}
+		// go:embed only supports files in the package directory or below
+		if relPath, err := filepath.Rel(outputDir, reportPath); err == nil && filepath.IsLocal(relPath) {
+			embedPath = filepath.ToSlash(relPath)
+		}
+	}
+
+	code, err := reportbro.GenerateGoCode(reportDefinition, reportbro.GoCodeOptions{
+		Package:   packageName,
+		TypeName:  typeName,
+		FuncName:  funcName,
+		EmbedPath: embedPath,
+		Source:    filepath.Base(reportFile),
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, code, 0644)
+}
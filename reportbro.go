@@ -18,16 +18,17 @@ package reportbro
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"math"
 	"regexp"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/araddon/dateparse"
 	"github.com/jung-kurt/gofpdf"
+	"github.com/shopspring/decimal"
 	"github.com/spf13/cast"
 	"github.com/vincent-petithory/dataurl"
 )
@@ -48,6 +49,7 @@ type documentPDFRenderer struct {
 	pdfDoc             FPDFRB
 	context            Context
 	addWatermark       bool
+	report             *report
 }
 
 func (self *documentPDFRenderer) init(headerBand containerProvider, contentBand containerProvider, footerBand containerProvider, report *report, context Context, additionalFonts string, addWatermark bool) {
@@ -60,6 +62,7 @@ func (self *documentPDFRenderer) init(headerBand containerProvider, contentBand
 	self.pdfDoc.CMargin = 0 // interior cell margin
 	self.context = context
 	self.addWatermark = addWatermark
+	self.report = report
 }
 
 func (self *documentPDFRenderer) addPage() {
@@ -99,6 +102,15 @@ func (self *documentPDFRenderer) render() ([]byte, error) {
 			return nil, fmt.Errorf("Too many pages (probably an endless loop)")
 		}
 	}
+	for _, stream := range self.report.rowStreams {
+		if stream.err != nil {
+			return nil, stream.err
+		}
+	}
+	// rows of streams are validated while rendering
+	if err := self.report.getValidationError(); err != nil {
+		return nil, err
+	}
 	self.context.setPageCount(pageCount)
 
 	footerOffsetY := self.documentProperties.pageHeight - self.documentProperties.footerSize - self.documentProperties.marginBottom
@@ -162,11 +174,38 @@ func newDocumentPDFRenderer(headerBand containerProvider, contentBand containerP
 	return documentPDFRenderer
 }
 
+// Errors returns the errors of the report definition and data
+func (self *report) Errors() []Error {
+	return self.errors
+}
+
 func (self *report) GeneratePDF(addWatermark bool) ([]byte, error) {
+	return self.GeneratePDFContext(context.Background(), addWatermark)
+}
+
+// GeneratePDFContext generates the pdf like GeneratePDF, ctx is used when reading the rows of
+// row sources, e.g. to cancel the queries of a SQLRowSource
+func (self *report) GeneratePDFContext(ctx context.Context, addWatermark bool) ([]byte, error) {
+	defer self.closeRowStreams()
+	for _, stream := range self.rowStreams {
+		stream.ctx = ctx
+	}
+	if err := self.getValidationError(); err != nil {
+		return nil, err
+	}
 	renderer := newDocumentPDFRenderer(self.header, self.content, self.footer, self, self.context, self.additionalFonts, addWatermark)
 	return renderer.render()
 }
 
+// closeRowStreams closes all row sources of the report data, rows which were not read are discarded
+func (self *report) closeRowStreams() {
+	for _, stream := range self.rowStreams {
+		if err := stream.close(); err != nil {
+			log.Println(Error{Message: "errorMsgInvalidDataSource", ObjectID: stream.parameter.ID, Field: "data_source", Info: err.Error()})
+		}
+	}
+}
+
 type DocumentXLSXRenderer struct {
 	headerBand         containerProvider
 	contentBand        containerProvider
@@ -258,25 +297,30 @@ func (self *DocumentXLSXRenderer) GenerateXlsx(filename string) {
 }
 
 type documentProperties struct {
-	ID                    int
-	PageFormat            PageFormat
-	Orientation           Orientation
-	pageWidth             float64
-	pageHeight            float64
-	contentHeight         float64
-	marginLeft            float64
-	marginTop             float64
-	marginRight           float64
-	marginBottom          float64
-	PatternLocale         string
-	PatternCurrencySymbol string
-	header                bool
-	headerDisplay         BandDisplay
-	headerSize            float64
-	footer                bool
-	footerDisplay         BandDisplay
-	footerSize            float64
-	report                *report
+	ID                     int
+	PageFormat             PageFormat
+	Orientation            Orientation
+	pageWidth              float64
+	pageHeight             float64
+	contentHeight          float64
+	marginLeft             float64
+	marginTop              float64
+	marginRight            float64
+	marginBottom           float64
+	PatternLocale          string
+	PatternCurrencySymbol  string
+	PatternCurrency        string
+	PatternCurrencyDisplay CurrencyDisplay
+	RoundingMode           RoundingMode
+	DecimalMode            bool
+	TimeZone               *time.Location
+	header                 bool
+	headerDisplay          BandDisplay
+	headerSize             float64
+	footer                 bool
+	footerDisplay          BandDisplay
+	footerSize             float64
+	report                 *report
 }
 
 func (self *documentProperties) init(report *report, data map[string]interface{}) {
@@ -315,8 +359,8 @@ func (self *documentProperties) init(report *report, data map[string]interface{}
 		}
 		unit = UnitMm
 	} else {
-		self.pageWidth = float64(GetIntValue(data, "pageWidth"))
-		self.pageHeight = float64(GetIntValue(data, "pageHeight"))
+		self.pageWidth = GetFloatValue(data, "pageWidth")
+		self.pageHeight = GetFloatValue(data, "pageHeight")
 		unit = GetUnit(GetStringValue(data, "unit"))
 		if unit == UnitMm {
 			if self.pageWidth < 100 || self.pageWidth >= 100000 {
@@ -341,14 +385,35 @@ func (self *documentProperties) init(report *report, data map[string]interface{}
 		self.pageHeight = math.Round((dpi * self.pageHeight))
 	}
 
-	self.contentHeight = float64(GetIntValue(data, "contentHeight"))
-	self.marginLeft = float64(GetIntValue(data, "marginLeft"))
-	self.marginTop = float64(GetIntValue(data, "marginTop"))
-	self.marginRight = float64(GetIntValue(data, "marginRight"))
-	self.marginBottom = float64(GetIntValue(data, "marginBottom"))
+	self.contentHeight = GetFloatValue(data, "contentHeight")
+	self.marginLeft = GetFloatValue(data, "marginLeft")
+	self.marginTop = GetFloatValue(data, "marginTop")
+	self.marginRight = GetFloatValue(data, "marginRight")
+	self.marginBottom = GetFloatValue(data, "marginBottom")
 	self.PatternLocale = GetStringValue(data, "patternLocale")
 	self.PatternCurrencySymbol = GetStringValue(data, "patternCurrencySymbol")
-	if notIn(self.PatternLocale, []string{"de", "en", "es", "fr", "it"}) {
+	self.PatternCurrency = strings.ToUpper(GetStringValue(data, "patternCurrency"))
+	if self.PatternCurrency == "" && self.PatternCurrencySymbol == "" {
+		self.PatternCurrency = getLocaleCurrency(self.PatternLocale)
+	} else if self.PatternCurrency != "" && !isCurrencyCode(self.PatternCurrency) {
+		log.Println("invalid pattern_currency")
+		self.PatternCurrency = ""
+	}
+	self.PatternCurrencyDisplay = GetCurrencyDisplay(GetStringValue(data, "patternCurrencyDisplay"))
+	self.RoundingMode = GetRoundingMode(GetStringValue(data, "roundingMode"))
+	self.DecimalMode = GetBoolValue(data, "decimalMode")
+	if timeZone := GetStringValue(data, "timeZone"); timeZone != "" {
+		location, err := time.LoadLocation(timeZone)
+		if err != nil {
+			// dates would be shown in the wrong time zone, therefore the report is not generated
+			timeZoneError := Error{Message: "errorMsgInvalidTimeZone", ObjectID: self.ID, Field: "time_zone", Info: timeZone}
+			self.report.errors = append(self.report.errors, timeZoneError)
+			self.report.validationErrors = append(self.report.validationErrors, timeZoneError)
+		} else {
+			self.TimeZone = location
+		}
+	}
+	if !isValidLocale(self.PatternLocale) {
 		log.Println("invalid pattern_locale")
 	}
 
@@ -363,10 +428,10 @@ func (self *documentProperties) init(report *report, data map[string]interface{}
 	self.footer = GetBoolValue(data, "footer")
 	if self.footer {
 		self.footerDisplay = GetBandDisplay(GetStringValue(data, "footerDisplay"))
-		self.footerSize = float64(GetIntValue(data, "footerSize"))
+		self.footerSize = GetFloatValue(data, "footerSize")
 	} else {
 		self.footerDisplay = BandDisplayNever
-		self.footerSize = float64(GetIntValue(data, "footerSize"))
+		self.footerSize = GetFloatValue(data, "footerSize")
 	}
 	if self.contentHeight == 0 {
 		self.contentHeight = self.pageHeight - self.headerSize - self.footerSize - self.marginTop - self.marginBottom
@@ -387,7 +452,15 @@ type FPDFRB struct {
 	Y               float64
 	LoadedImages    map[string]string
 	AvailableFonts  map[string]string
-	Tr              func(string) string
+	Fonts           map[string]*trueTypeFont
+	FontFileNames   map[string]string
+	UTF8Font        bool
+	// font of the following text, characters without glyph are rendered with the first fallback font which has a glyph
+	FontFamily    string
+	FontStyle     string
+	FontSize      float64
+	FallbackFonts []string
+	Tr            func(string) string
 }
 
 func (self *FPDFRB) init(documentProperties documentProperties, additionalFonts string) {
@@ -410,12 +483,23 @@ func (self *FPDFRB) init(documentProperties documentProperties, additionalFonts
 		self.Fpdf = gofpdf.New(orientation, "pt", documentProperties.PageFormat.String(), additionalFonts)
 	}
 	self.Fpdf.SetAutoPageBreak(false, 0)
+	self.AdditionalFonts = additionalFonts
+	self.Fonts = make(map[string]*trueTypeFont)
 	self.X = 0.0
 	self.Y = 0.0
 	// "" defaults to "cp1252" | This removes unwanted Â from special characters e.g. £
 	self.Tr = self.Fpdf.UnicodeTranslatorFromDescriptor("")
 }
 
+// encodeText converts text for the current font, text of core fonts is cp1252 encoded
+// and TrueType fonts use the utf-8 text
+func (self *FPDFRB) encodeText(text string) string {
+	if self.UTF8Font {
+		return text
+	}
+	return self.Tr(text)
+}
+
 func (self *FPDFRB) addImage(img string, imageKey string) {
 	self.LoadedImages[imageKey] = img
 }
@@ -427,6 +511,12 @@ func (self *FPDFRB) getImage(imageKey string) string {
 	return ""
 }
 
+// setLetterSpacing sets the additional space after each character for the following text,
+// gofpdf has no support for the character spacing operator so it is written directly
+func (self *FPDFRB) setLetterSpacing(letterSpacing float64) {
+	self.Fpdf.RawWriteStr(fmt.Sprintf("%.3f Tc\n", letterSpacing*self.Fpdf.GetConversionRatio()))
+}
+
 func newFPDFRB(documentProperties documentProperties, additionalFonts string) FPDFRB {
 	fpdfrb := FPDFRB{}
 	fpdfrb.init(documentProperties, additionalFonts)
@@ -447,10 +537,12 @@ type report struct {
 	IsTestData         bool
 	additionalFonts    string
 	context            Context
+	rowStreams         []*rowStream
+	validationErrors   []Error
 	LogMode            bool
 }
 
-func (self *report) init(reportDefinition map[string]interface{}, data map[string]interface{}, isTestData bool, additionalFonts string, imageData map[string][]byte) {
+func (self *report) init(reportDefinition map[string]interface{}, data interface{}, isTestData bool, additionalFonts string, imageData map[string][]byte) {
 	self.errors = make([]Error, 0)
 
 	self.documentProperties = newDocumentProperties(self, reportDefinition["documentProperties"].(map[string]interface{}))
@@ -551,8 +643,13 @@ func (self *report) init(reportDefinition map[string]interface{}, data map[strin
 
 	self.context = NewContext(*self, self.parameters, self.Data)
 
+	srcData, err := normalizeData(data)
+	if err != nil {
+		self.errors = append(self.errors, Error{Message: "errorMsgInvalidData", Info: err.Error()})
+		srcData = map[string]interface{}{}
+	}
 	computedparameters := map[int]computedParameter{}
-	self.processData(&self.Data, data, parameterList, isTestData, &computedparameters, map[int]Parameter{})
+	self.processData(&self.Data, srcData, parameterList, isTestData, &computedparameters, map[int]Parameter{}, "")
 	if len(self.errors) < 1 {
 		self.computeParameters(computedparameters, self.Data)
 	} else {
@@ -579,6 +676,13 @@ func (self *report) parseParameterValue(parameter Parameter, parentID int, isTes
 	if isTestData {
 		errorField = "test_data"
 	}
+	if isStringerValue(value) {
+		// e.g. decimal types of other packages, the value is parsed from its string representation
+		value = value.(fmt.Stringer).String()
+	} else if bytesValue, ok := value.([]byte); ok {
+		// e.g. text columns of database drivers
+		value = string(bytesValue)
+	}
 
 	if ParameterType == ParameterTypeString {
 		if value != nil {
@@ -588,25 +692,15 @@ func (self *report) parseParameterValue(parameter Parameter, parentID int, isTes
 		} else if parameter.Nullable == false {
 			value = ""
 		}
+	} else if ParameterType == ParameterTypeNumber && self.documentProperties.DecimalMode {
+		value = self.parseDecimalValue(parameter, parentID, isTestData, value)
 	} else if ParameterType == ParameterTypeNumber {
+		if decimalValue, ok := value.(decimal.Decimal); ok {
+			value = decimalValue.InexactFloat64()
+		}
 		if value != nil && ((getDataType(value) == DataTypeString && cast.ToString(value) != "") || (getDataType(value) == DataTypeFloat && cast.ToFloat64(value) != 0.0) || (getDataType(value) == DataTypeInt && cast.ToInt(value) != 0)) {
-			if getDataType(value) == DataTypeString {
-				runes := cast.ToString(value)
-				out := []rune(runes)
-				caught := false
-				for i := len(runes); i > 0; i-- {
-					if "," == string(runes[i-1]) {
-						if !caught {
-							caught = true
-							out[i-1] = rune('.')
-						}
-					}
-				}
-
-				value = strings.Replace(string(out), ",", "", -1)
-			}
 			var err error
-			value, err = strconv.ParseFloat(cast.ToString(value), 64)
+			value, err = parseLocaleNumber(cast.ToString(value), self.documentProperties.PatternLocale)
 			if err != nil {
 				if parentID != 0 && isTestData {
 					self.errors = append(self.errors, Error{Message: "errorMsgInvalidTestData", ObjectID: parentID, Field: "test_data"})
@@ -644,49 +738,26 @@ func (self *report) parseParameterValue(parameter Parameter, parentID int, isTes
 		} else if parameter.Nullable == false {
 			value = false
 		}
-	} else if ParameterType == ParameterTypeDate {
-		if getDataType(value) == DataTypeString {
-			if isTestData && value == nil {
-				if parameter.Nullable {
-					value = nil
-				} else {
-					value = time.Now().Format(time.RFC3339)
-				}
-			} else {
-				format := "2006-01-02"
-				colonCount := strings.Count(cast.ToString(value), ":")
-				if colonCount == 1 {
-					format = "2006-01-02 15:04"
-				} else if colonCount == 2 {
-					format = "2006-01-02 15:04:05"
-				}
-				time, err := time.Parse(format, cast.ToString(value))
-				if err == nil {
-					value = time.Format(format)
-				} else {
-					// One last attempt
-					time, err := dateparse.ParseAny(cast.ToString(value))
-					if err == nil {
-						value = time.Format(format)
-					} else {
-						if parentID != 0 && isTestData {
-							self.errors = append(self.errors, Error{Message: "errorMsgInvalidTestData", ObjectID: parentID, Field: "test_data", Info: value})
-							self.errors = append(self.errors, Error{Message: "errorMsgInvalidDate", ObjectID: parameter.ID, Field: "type", Info: value})
-						} else {
-							self.errors = append(self.errors, Error{Message: "errorMsgInvalidDate", ObjectID: parameter.ID, Field: errorField, context: parameter.Name, Info: value})
-						}
-					}
-				}
-			}
-		} else if value != nil {
-			if parentID != 0 && isTestData {
+	} else if ParameterType.isDate() {
+		if value != nil && value != "" {
+			dateValue, err := parseDateValue(value, ParameterType, self.documentProperties.TimeZone)
+			if err == nil {
+				value = dateValue
+			} else if parentID != 0 && isTestData {
 				self.errors = append(self.errors, Error{Message: "errorMsgInvalidTestData", ObjectID: parentID, Field: "test_data", Info: value})
 				self.errors = append(self.errors, Error{Message: "errorMsgInvalidDate", ObjectID: parameter.ID, Field: "type", Info: value})
 			} else {
 				self.errors = append(self.errors, Error{Message: "errorMsgInvalidDate", ObjectID: parameter.ID, Field: errorField, context: parameter.Name, Info: value})
 			}
-		} else if parameter.Nullable == false {
-			value = time.Now()
+		} else if parameter.Nullable {
+			value = nil
+		} else {
+			// date values keep their day, so the current time is converted to the time zone first
+			now := time.Now()
+			if self.documentProperties.TimeZone != nil {
+				now = now.In(self.documentProperties.TimeZone)
+			}
+			value, _ = parseDateValue(now, ParameterType, self.documentProperties.TimeZone)
 		}
 	}
 
@@ -698,8 +769,9 @@ type computedParameter struct {
 	parentNames []string
 }
 
-// Here is where the context data is resolved
-func (self *report) processData(destData *map[string]interface{}, srcData map[string]interface{}, parameters []interface{}, isTestData bool, computedParameters *map[int]computedParameter, parents map[int]Parameter) {
+// processData maps the source data onto the parameters, srcData is either a map or a struct whose fields
+// are matched by their json tags (see getDataField). path is the path of srcData used in validation errors.
+func (self *report) processData(destData *map[string]interface{}, srcData interface{}, parameters []interface{}, isTestData bool, computedParameters *map[int]computedParameter, parents map[int]Parameter, path string) {
 	field := "type"
 	if isTestData {
 		field = "test_data"
@@ -719,7 +791,7 @@ func (self *report) processData(destData *map[string]interface{}, srcData map[st
 			self.errors = append(self.errors, Error{Message: "errorMsgInvalidparameterName", ObjectID: param.ID, Field: "name", Info: param.Name})
 		}
 		parameterType := param.Type
-		if param.Eval || (parameterType == ParameterTypeAverage || parameterType == ParameterTypeSum) {
+		if param.Eval || parameterType.isAggregate() {
 			if param.Expression == "" {
 				self.errors = append(self.errors, Error{Message: "errorMsgMissingExpression", ObjectID: param.ID, Field: "expression", context: param.Name})
 			} else {
@@ -735,12 +807,19 @@ func (self *report) processData(destData *map[string]interface{}, srcData map[st
 				computedParameters = &drComputedParameters
 			}
 		} else {
-			value := srcData[param.Name]
-			if parameterType == ParameterTypeString || parameterType == ParameterTypeNumber || parameterType == ParameterTypeBoolean || parameterType == ParameterTypeDate {
+			value := getDataValue(getDataField(srcData, param.Name))
+			rawValue := value
+			parameterPath := getParameterPath(path, param.Name)
+			if parameterType == ParameterTypeString || parameterType == ParameterTypeNumber || parameterType == ParameterTypeBoolean || parameterType.isDate() {
 				value = self.parseParameterValue(param, parentID, isTestData, parameterType, value)
 			} else if len(parents) < 1 {
 				if parameterType == ParameterTypeArray {
-					if value, ok := value.([]interface{}); ok {
+					if source, ok := value.(RowSource); ok {
+						// rows are read and processed when the table or section is rendered
+						stream := newRowStream(self, param, source, isTestData)
+						self.rowStreams = append(self.rowStreams, stream)
+						value = stream
+					} else if rows, ok := getDataList(value); ok {
 						parents[len(parents)] = param
 						parameterList := make([]interface{}, 0)
 						for _, field := range param.Fields {
@@ -749,9 +828,13 @@ func (self *report) processData(destData *map[string]interface{}, srcData map[st
 						// create new list which will be assigned to destData to keep srcData unmodified
 						destArray := make([]interface{}, 0)
 
-						for _, row := range value {
+						for i, row := range rows {
+							if !isDataRecord(row) {
+								self.errors = append(self.errors, Error{Message: "errorMsgInvalidArray", ObjectID: param.ID, Field: field, context: param.Name})
+								continue
+							}
 							destArrayItem := make(map[string]interface{}, 0)
-							self.processData(&destArrayItem, row.(map[string]interface{}), parameterList, isTestData, computedParameters, parents)
+							self.processData(&destArrayItem, row, parameterList, isTestData, computedParameters, parents, fmt.Sprintf("%s[%d]", parameterPath, i))
 							destArray = append(destArray, destArrayItem)
 						}
 						delete(parents, len(parents)-1)
@@ -764,10 +847,10 @@ func (self *report) processData(destData *map[string]interface{}, srcData map[st
 						self.errors = append(self.errors, Error{Message: "errorMsgInvalidArray", ObjectID: param.ID, Field: field, context: param.Name})
 					}
 				} else if parameterType == ParameterTypeSimpleArray {
-					if value, ok := value.([]interface{}); ok {
+					if items, ok := getDataList(value); ok {
 						listValues := make([]interface{}, 0)
-						for _, listValue := range value {
-							parsedValue := self.parseParameterValue(param, parentID, isTestData, param.ArrayItemType, listValue)
+						for _, listValue := range items {
+							parsedValue := self.parseParameterValue(param, parentID, isTestData, param.ArrayItemType, getDataValue(listValue))
 							listValues = append(listValues, parsedValue)
 						}
 						value = listValues
@@ -782,13 +865,13 @@ func (self *report) processData(destData *map[string]interface{}, srcData map[st
 					if value == nil && param.Nullable == false {
 						value = make(map[string]interface{}, 0)
 					}
-					if value, ok := value.(map[string]interface{}); ok {
+					if isDataRecord(value) {
 						if len(param.Children) > 0 {
 							parents[len(parents)] = param
 							// create new dict which will be assigned to destData to keep srcData unmodified
 							destMap := make(map[string]interface{}, 0)
 
-							self.processData(&destMap, value, param.Children, isTestData, computedParameters, parents)
+							self.processData(&destMap, value, param.Children, isTestData, computedParameters, parents, parameterPath)
 							delete(parents, len(parents)-1)
 							value = destMap
 						} else {
@@ -797,7 +880,15 @@ func (self *report) processData(destData *map[string]interface{}, srcData map[st
 					} else {
 						self.errors = append(self.errors, Error{Message: "errorMsgMissingData", ObjectID: param.ID, Field: "name", context: param.Name})
 					}
+				} else {
+					value = normalizeDataValue(value)
 				}
+			} else {
+				// nested data of array rows is not mapped to parameters and only converted to maps and lists
+				value = normalizeDataValue(value)
+			}
+			if _, ok := value.(*rowStream); !ok {
+				self.validateParameterValue(param, parameterPath, rawValue, value)
 			}
 
 			(*destData)[param.Name] = value
@@ -806,50 +897,14 @@ func (self *report) processData(destData *map[string]interface{}, srcData map[st
 }
 
 func (self *report) computeParameters(computedParameters map[int]computedParameter, data map[string]interface{}) {
-	for _, computedParameter := range computedParameters {
+	// parameters must be computed in the order they were defined because
+	// expressions can reference previously computed parameters
+	for i := 0; i < len(computedParameters); i++ {
+		computedParameter := computedParameters[i]
 		parameter := computedParameter.parameter
 		var value interface{}
-		if parameter.Type == ParameterTypeAverage || parameter.Type == ParameterTypeSum {
-			expr := stripParameterName(parameter.Expression)
-			pos := pyFind(expr, ".", 0, 0)
-			if pos == -1 {
-				self.errors = append(self.errors, Error{Message: "errorMsgInvalidAvgSumExpression", ObjectID: parameter.ID, Field: "expression", context: parameter.Name})
-			} else {
-				parameterName := expr[:pos]
-				parameterField := expr[pos+1:]
-				if items, ok := data[parameterName]; !ok {
-					self.errors = append(self.errors, Error{Message: "errorMsgInvalidAvgSumExpression", ObjectID: parameter.ID, Field: "expression", context: parameter.Name})
-				} else {
-					total := 0.0
-					if items != nil {
-						for _, item := range items.([]interface{}) {
-							if itemValue, ok := item.(map[string]interface{})[parameterField]; !ok {
-								self.errors = append(self.errors, Error{Message: "errorMsgInvalidAvgSumExpression", ObjectID: parameter.ID, Field: "expression", context: parameter.Name})
-								break
-							} else {
-								runes := cast.ToString(itemValue)
-								out := []rune(runes)
-								caught := false
-								for i := len(runes); i > 0; i-- {
-									if "," == string(runes[i-1]) {
-										if !caught {
-											caught = true
-											out[i-1] = rune('.')
-										}
-									}
-								}
-
-								total += cast.ToFloat64(strings.Replace(string(out), ",", "", -1))
-							}
-						}
-					}
-					if parameter.Type == ParameterTypeAverage {
-						value = total / float64(len(items.([]interface{})))
-					} else if parameter.Type == ParameterTypeSum {
-						value = total
-					}
-				}
-			}
+		if parameter.Type.isAggregate() {
+			value = self.computeAggregate(parameter, data)
 		} else {
 			value = self.context.evaluateExpression(parameter.Expression, parameter.ID, "expression")
 		}
@@ -872,7 +927,224 @@ func (self *report) computeParameters(computedParameters map[int]computedParamet
 
 }
 
-func NewReport(reportDefinition map[string]interface{}, data map[string]interface{}, isTestData bool, additionalFonts string, imageData map[string][]byte) report {
+// computeAggregate computes the value of an aggregate parameter (sum, average, count, ...) from
+// the rows of the array parameter given in the expression, e.g. ${items.amount}.
+// Rows can be restricted with the filter expression of the parameter which is evaluated in the
+// context of each row, e.g. ${status} == 'paid'.
+func (self *report) computeAggregate(parameter Parameter, data map[string]interface{}) interface{} {
+	expr := stripParameterName(parameter.Expression)
+	parameterName := expr
+	parameterField := ""
+	if pos := pyFind(expr, ".", 0, 0); pos != -1 {
+		parameterName = expr[:pos]
+		parameterField = expr[pos+1:]
+	} else if parameter.Type != ParameterTypeCount {
+		// only count can be computed without a field, all other aggregates need a value
+		self.errors = append(self.errors, Error{Message: "errorMsgInvalidAvgSumExpression", ObjectID: parameter.ID, Field: "expression", context: parameter.Name})
+		return nil
+	}
+	items, ok := data[parameterName]
+	if !ok {
+		self.errors = append(self.errors, Error{Message: "errorMsgInvalidAvgSumExpression", ObjectID: parameter.ID, Field: "expression", context: parameter.Name})
+		return nil
+	}
+	if _, ok := items.(*rowStream); ok {
+		// rows of a RowSource are only available while rendering
+		self.errors = append(self.errors, Error{Message: "errorMsgInvalidAggregateDataSource", ObjectID: parameter.ID, Field: "expression", context: parameter.Name})
+		return nil
+	}
+	rows, _ := items.([]interface{})
+
+	var arrayParameter Parameter
+	if param, ok := self.parameters[parameterName].(Parameter); ok {
+		arrayParameter = param
+	}
+	var fieldParameter *Parameter
+	if parameterField != "" {
+		if field, ok := arrayParameter.Fields[parameterField].(Parameter); ok {
+			fieldParameter = &field
+		}
+	}
+
+	values := make([]interface{}, 0)
+	for _, item := range rows {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if parameter.Filter != "" && !self.isAggregateRowIncluded(parameter, arrayParameter, row) {
+			continue
+		}
+		if parameterField == "" {
+			values = append(values, row)
+			continue
+		}
+		itemValue, ok := row[parameterField]
+		if !ok {
+			self.errors = append(self.errors, Error{Message: "errorMsgInvalidAvgSumExpression", ObjectID: parameter.ID, Field: "expression", context: parameter.Name})
+			return nil
+		}
+		values = append(values, itemValue)
+	}
+
+	return self.aggregateValues(parameter.Type, values, parameter.Nullable, fieldParameter)
+}
+
+// aggregateValues computes an aggregate of the given values, null values are ignored. In case there are
+// no values sum and counts return 0, all other aggregates return nil if nullable or the default value otherwise.
+// fieldParameter is the parameter of the aggregated values and is used to get the default value for first/last.
+func (self *report) aggregateValues(parameterType ParameterType, values []interface{}, nullable bool, fieldParameter *Parameter) interface{} {
+	switch parameterType {
+	case ParameterTypeFirst, ParameterTypeLast:
+		values = removeNilValues(values)
+		if len(values) == 0 {
+			if nullable || fieldParameter == nil {
+				return nil
+			}
+			// use default value of the field type in case there are no rows
+			return self.parseParameterValue(*fieldParameter, 0, false, fieldParameter.Type, nil)
+		}
+		if parameterType == ParameterTypeFirst {
+			return values[0]
+		}
+		return values[len(values)-1]
+	case ParameterTypeCount:
+		count := 0
+		for _, value := range values {
+			if value != nil {
+				count++
+			}
+		}
+		return self.numberValue(decimal.NewFromInt(int64(count)))
+	case ParameterTypeCountDistinct:
+		distinctValues := map[string]bool{}
+		for _, value := range values {
+			if value != nil {
+				distinctValues[fmt.Sprint(value)] = true
+			}
+		}
+		return self.numberValue(decimal.NewFromInt(int64(len(distinctValues))))
+	}
+
+	// all remaining aggregates are computed from numbers, decimals are used
+	// so sums are exact and not affected by binary floating point errors
+	numbers := make([]decimal.Decimal, 0)
+	for _, value := range values {
+		if value != nil {
+			numbers = append(numbers, self.getAggregateNumber(value, fieldParameter))
+		}
+	}
+	if len(numbers) == 0 {
+		if parameterType == ParameterTypeSum || !nullable {
+			return self.numberValue(decimal.Zero)
+		}
+		return nil
+	}
+
+	switch parameterType {
+	case ParameterTypeSum:
+		return self.numberValue(decimal.Sum(numbers[0], numbers[1:]...))
+	case ParameterTypeAverage:
+		return self.numberValue(divideDecimal(decimal.Sum(numbers[0], numbers[1:]...), decimal.NewFromInt(int64(len(numbers))), self.documentProperties.RoundingMode))
+	case ParameterTypeMin:
+		return self.numberValue(decimal.Min(numbers[0], numbers[1:]...))
+	case ParameterTypeMax:
+		return self.numberValue(decimal.Max(numbers[0], numbers[1:]...))
+	case ParameterTypeMedian:
+		sort.Slice(numbers, func(i, j int) bool {
+			return numbers[i].LessThan(numbers[j])
+		})
+		middle := len(numbers) / 2
+		if len(numbers)%2 == 0 {
+			return self.numberValue(divideDecimal(numbers[middle-1].Add(numbers[middle]), decimal.NewFromInt(2), self.documentProperties.RoundingMode))
+		}
+		return self.numberValue(numbers[middle])
+	}
+	return nil
+}
+
+// getAggregateNumber converts a value of an aggregate to a decimal, strings are parsed with the pattern
+// locale of the report. Values which are no numbers are added to the report errors and count as 0.
+func (self *report) getAggregateNumber(value interface{}, fieldParameter *Parameter) decimal.Decimal {
+	if number, ok := toDecimal(value); ok {
+		return number
+	}
+	number, err := parseFloatValue(value, self.documentProperties.PatternLocale)
+	if err != nil {
+		aggregateError := Error{Message: "errorMsgInvalidNumber", Field: "expression", Info: value}
+		if fieldParameter != nil {
+			aggregateError.ObjectID = fieldParameter.ID
+			aggregateError.context = fieldParameter.Name
+		}
+		self.errors = append(self.errors, aggregateError)
+	}
+	return decimal.NewFromFloat(number)
+}
+
+// parseDecimalValue parses the value of a number parameter in decimal mode
+func (self *report) parseDecimalValue(parameter Parameter, parentID int, isTestData bool, value interface{}) interface{} {
+	errorField := "type"
+	if isTestData {
+		errorField = "test_data"
+	}
+	if value == nil || value == "" {
+		if parameter.Nullable && (value == nil || isTestData) {
+			return nil
+		}
+		return decimal.Zero
+	}
+	if strValue, ok := value.(string); ok {
+		rv, err := parseLocaleDecimal(strValue, self.documentProperties.PatternLocale)
+		if err != nil {
+			if parentID != 0 && isTestData {
+				self.errors = append(self.errors, Error{Message: "errorMsgInvalidTestData", ObjectID: parentID, Field: "test_data"})
+				self.errors = append(self.errors, Error{Message: "errorMsgInvalidNumber", ObjectID: parentID, Field: "type"})
+			} else {
+				self.errors = append(self.errors, Error{Message: "errorMsgInvalidNumber", Field: errorField, context: parameter.Name})
+			}
+		}
+		return rv
+	}
+	if rv, ok := toDecimal(value); ok {
+		return rv
+	}
+	return decimal.Zero
+}
+
+// removeNilValues returns the values without nil values
+func removeNilValues(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if value != nil {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// numberValue returns the number as decimal in decimal mode, otherwise as float
+func (self *report) numberValue(number decimal.Decimal) interface{} {
+	if self.documentProperties.DecimalMode {
+		return number
+	}
+	return number.InexactFloat64()
+}
+
+// isAggregateRowIncluded evaluates the filter expression of an aggregate parameter for the given array row
+func (self *report) isAggregateRowIncluded(parameter Parameter, arrayParameter Parameter, row map[string]interface{}) bool {
+	rowParameters := make(map[string]interface{}, 0)
+	for name, field := range arrayParameter.Fields {
+		rowParameters[name] = field
+	}
+	self.context.pushContext(rowParameters, row)
+	included := cast.ToBool(self.context.evaluateExpression(parameter.Filter, parameter.ID, "filter"))
+	self.context.popContext()
+	return included
+}
+
+// NewReport creates a report from the report definition and the data. Data can be a map, a struct (or pointer
+// to a struct) whose fields are mapped to the parameters by their json tags, or JSON encoded bytes of an object.
+func NewReport(reportDefinition map[string]interface{}, data interface{}, isTestData bool, additionalFonts string, imageData map[string][]byte) report {
 	report := report{}
 	report.init(reportDefinition, data, isTestData, additionalFonts, imageData)
 	return report
@@ -0,0 +1,159 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// newTestReport returns a report without elements with the given parameters (json array)
+// and document properties (json object members)
+func newTestReport(t *testing.T, parameters string, documentProperties string, data map[string]interface{}) report {
+	t.Helper()
+	var definition map[string]interface{}
+	js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","marginLeft":20,"marginTop":20,"marginRight":20,"marginBottom":20` + documentProperties + `},
+		"parameters":` + parameters + `,"styles":[],"docElements":[],"version":2}`
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	return NewReport(definition, data, false, "", nil)
+}
+
+// hasError returns true if the report has an error with the given message
+func hasError(report report, message string) bool {
+	for _, err := range report.Errors() {
+		if err.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseNumberParameter(t *testing.T) {
+	tests := []struct {
+		value       interface{}
+		locale      string
+		decimalMode bool
+		want        interface{}
+	}{
+		{"1,234.5", "en", false, 1234.5},
+		{"1.234,5", "de", false, 1234.5},
+		{"12.5", "de", false, 12.5},
+		{7, "en", false, 7.0},
+		{"1,234.5", "en", true, "1234.5"},
+	}
+	for _, test := range tests {
+		properties := `,"patternLocale":"` + test.locale + `"`
+		if test.decimalMode {
+			properties += `,"decimalMode":true`
+		}
+		report := newTestReport(t, `[{"id":1,"name":"amount","type":"number"}]`, properties, map[string]interface{}{"amount": test.value})
+		got := report.Data["amount"]
+		if test.decimalMode {
+			got = fmt.Sprint(got)
+		}
+		if len(report.Errors()) != 0 || got != test.want {
+			t.Errorf("amount %#v in %s = %#v (errors %v), want %#v", test.value, test.locale, got, report.Errors(), test.want)
+		}
+	}
+
+	// numbers which are not formatted like numbers of the locale are invalid instead of being misread,
+	// e.g. "1,5" is not 15 and "1.234,5" is not 1.2345 for english
+	for _, decimalMode := range []bool{false, true} {
+		for _, value := range []string{"1,5", "1.234,5"} {
+			properties := `,"patternLocale":"en"`
+			if decimalMode {
+				properties += `,"decimalMode":true`
+			}
+			report := newTestReport(t, `[{"id":1,"name":"amount","type":"number"}]`, properties, map[string]interface{}{"amount": value})
+			if !hasError(report, "errorMsgInvalidNumber") {
+				t.Errorf("amount %q (decimal mode %v) = %#v, want errorMsgInvalidNumber", value, decimalMode, report.Data["amount"])
+			}
+		}
+	}
+}
+
+func TestComputeAggregate(t *testing.T) {
+	aggregates := []struct {
+		name       string
+		aggregate  string
+		expression string
+		nullable   bool
+	}{
+		{"count", "count", "${items}", false},
+		{"countAmount", "count", "${items.amount}", false},
+		{"distinct", "count_distinct", "${items.name}", false},
+		{"sum", "sum", "${items.amount}", false},
+		{"min", "min", "${items.amount}", true},
+		{"max", "max", "${items.amount}", false},
+		{"median", "median", "${items.amount}", true},
+		{"first", "first", "${items.amount}", true},
+		{"last", "last", "${items.name}", false},
+		{"lastCode", "last", "${items.code}", false},
+	}
+	parameters := `[{"id":1,"name":"items","type":"array","children":[
+		{"id":2,"name":"name","type":"string","nullable":true},{"id":3,"name":"amount","type":"number","nullable":true},
+		{"id":4,"name":"code","type":"string"}]}`
+	for i, aggregate := range aggregates {
+		parameters += fmt.Sprintf(`,{"id":%d,"name":%q,"type":%q,"expression":%q,"nullable":%t}`,
+			10+i, aggregate.name, aggregate.aggregate, aggregate.expression, aggregate.nullable)
+	}
+	parameters += "]"
+
+	tests := []struct {
+		name  string
+		items []interface{}
+		want  map[string]interface{}
+	}{
+		{"odd count", []interface{}{
+			map[string]interface{}{"name": "b", "code": "b", "amount": 5},
+			map[string]interface{}{"name": "a", "code": "a", "amount": 1.5},
+			map[string]interface{}{"name": "b", "code": "b", "amount": 3},
+		}, map[string]interface{}{"count": 3.0, "countAmount": 3.0, "distinct": 2.0, "sum": 9.5, "min": 1.5, "max": 5.0,
+			"median": 3.0, "first": 5.0, "last": "b", "lastCode": "b"}},
+		{"even count", []interface{}{
+			map[string]interface{}{"name": "a", "code": "a", "amount": 4},
+			map[string]interface{}{"name": "b", "code": "b", "amount": 1},
+			map[string]interface{}{"name": "c", "code": "c", "amount": 10},
+			map[string]interface{}{"name": "d", "code": "d", "amount": 2},
+		}, map[string]interface{}{"count": 4.0, "countAmount": 4.0, "distinct": 4.0, "sum": 17.0, "min": 1.0, "max": 10.0,
+			"median": 3.0, "first": 4.0, "last": "d", "lastCode": "d"}},
+		// null values are not counted and not included in the other aggregates
+		{"null values", []interface{}{
+			map[string]interface{}{"name": "a", "code": "a", "amount": nil},
+			map[string]interface{}{"name": nil, "code": "a", "amount": 2},
+			map[string]interface{}{"name": "a", "code": "a", "amount": nil},
+		}, map[string]interface{}{"count": 3.0, "countAmount": 1.0, "distinct": 1.0, "sum": 2.0, "min": 2.0, "max": 2.0,
+			"median": 2.0, "first": 2.0, "last": "a", "lastCode": "a"}},
+		{"only null values", []interface{}{
+			map[string]interface{}{"name": nil, "code": "a", "amount": nil},
+		}, map[string]interface{}{"count": 1.0, "countAmount": 0.0, "distinct": 0.0, "sum": 0.0, "min": nil, "max": 0.0,
+			"median": nil, "first": nil, "last": nil, "lastCode": "a"}},
+		// nullable aggregates are null without rows, the others get the default value of the field
+		{"empty", []interface{}{},
+			map[string]interface{}{"count": 0.0, "countAmount": 0.0, "distinct": 0.0, "sum": 0.0, "min": nil, "max": 0.0,
+				"median": nil, "first": nil, "last": nil, "lastCode": ""}},
+	}
+	for _, test := range tests {
+		report := newTestReport(t, parameters, `,"patternLocale":"en"`, map[string]interface{}{"items": test.items})
+		if len(report.Errors()) > 0 {
+			t.Errorf("%s: errors %v", test.name, report.Errors())
+			continue
+		}
+		for name, want := range test.want {
+			if got := report.Data[name]; got != want {
+				t.Errorf("%s: %s = %#v, want %#v", test.name, name, got, want)
+			}
+		}
+	}
+
+	// the median of an even count is the exact average of the middle values in decimal mode
+	items := []interface{}{
+		map[string]interface{}{"name": "a", "code": "a", "amount": "0.1"},
+		map[string]interface{}{"name": "b", "code": "b", "amount": "0.2"},
+	}
+	report := newTestReport(t, parameters, `,"patternLocale":"en","decimalMode":true`, map[string]interface{}{"items": items})
+	if got := fmt.Sprint(report.Data["median"]); got != "0.15" {
+		t.Errorf("decimal mode: median = %s, want 0.15", got)
+	}
+}
@@ -0,0 +1,966 @@
+package reportbro
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	colorful "github.com/lucasb-eyer/go-colorful"
+)
+
+// font size and baseline shift of superscript and subscript text relative to the font size
+const (
+	richTextScriptSize      = 0.7
+	richTextSuperscriptRise = 0.35
+	richTextSubscriptDrop   = 0.15
+	// indent of list items relative to the font size
+	richTextListIndent = 1.8
+)
+
+// richTextVerticalAlign is the baseline position of superscript and subscript text
+type richTextVerticalAlign int
+
+const (
+	richTextBaseline    richTextVerticalAlign = 0
+	richTextSuperscript richTextVerticalAlign = 1
+	richTextSubscript   richTextVerticalAlign = 2
+)
+
+// richTextStyle is the style of a run of rich text, it is based on the style of the text element
+type richTextStyle struct {
+	font          string
+	bold          bool
+	italic        bool
+	underline     bool
+	strikethrough bool
+	fontSize      float64
+	textColor     Color
+	link          string
+	verticalAlign richTextVerticalAlign
+	letterSpacing float64
+	fallbackFonts []string
+	// factor of absolute font sizes of spans when the text is shrunk
+	fontScale float64
+}
+
+func (self *richTextStyle) init(style *textStyle) {
+	self.font = style.Font
+	self.bold = style.Bold
+	self.italic = style.Italic
+	self.underline = style.Underline
+	self.strikethrough = style.Strikethrough
+	self.fontSize = style.FontSize
+	self.textColor = style.TextColor
+	self.letterSpacing = style.LetterSpacing
+	self.fallbackFonts = style.FallbackFonts
+	self.fontScale = 1
+	if style.fontScale != 0 {
+		self.fontScale = style.fontScale
+	}
+}
+
+// getFontStyle returns the font style without underline, lines are drawn for each fragment
+func (self *richTextStyle) getFontStyle() string {
+	fontStyle := ""
+	if self.bold {
+		fontStyle += "B"
+	}
+	if self.italic {
+		fontStyle += "I"
+	}
+	return fontStyle
+}
+
+// getRenderFontSize returns the font size of the rendered text which is smaller for superscript and subscript
+func (self *richTextStyle) getRenderFontSize() float64 {
+	if self.verticalAlign != richTextBaseline {
+		return self.fontSize * richTextScriptSize
+	}
+	return self.fontSize
+}
+
+func (self *richTextStyle) setFont(pdfDoc *FPDFRB) {
+	pdfDoc.setFont(self.font, self.getFontStyle(), self.getRenderFontSize())
+	pdfDoc.setFallbackFonts(self.fallbackFonts)
+}
+
+// richTextRun is text with a single style
+type richTextRun struct {
+	text  string
+	style *richTextStyle
+}
+
+// richTextParagraph contains the runs of a paragraph, paragraphs are separated by line breaks and list items
+type richTextParagraph struct {
+	runs   []richTextRun
+	indent float64
+	marker *textFragment
+}
+
+// richTextParser parses the supported html subset of rich text: <b> <strong> <i> <em> <u> <s> <strike> <del>
+// <span style> <br> <p> <div> <a href> <ul> <ol> <li> <sup> <sub>. Other tags are ignored (their text is kept),
+// the content of <script> and <style> is removed.
+type richTextParser struct {
+	fillParameters func(string) string
+	baseStyle      *richTextStyle
+	styles         []*richTextStyle
+	tags           []string
+	lists          []int
+	paragraphs     []richTextParagraph
+	paragraph      *richTextParagraph
+	skipText       bool
+}
+
+func (self *richTextParser) init(baseStyle *richTextStyle, fillParameters func(string) string) {
+	self.fillParameters = fillParameters
+	self.baseStyle = baseStyle
+	self.styles = []*richTextStyle{baseStyle}
+	// name of the tag which opened each style, the base style has no tag
+	self.tags = []string{""}
+	self.lists = make([]int, 0)
+	self.paragraphs = make([]richTextParagraph, 0)
+	self.paragraph = nil
+}
+
+// parse returns the paragraphs of the rich text
+func (self *richTextParser) parse(content string) []richTextParagraph {
+	for len(content) > 0 {
+		pos := strings.IndexByte(content, '<')
+		if pos == -1 {
+			self.addText(content)
+			break
+		}
+		if pos > 0 {
+			self.addText(content[:pos])
+		}
+		content = content[pos:]
+		if strings.HasPrefix(content, "<!--") {
+			end := strings.Index(content, "-->")
+			if end == -1 {
+				break
+			}
+			content = content[end+3:]
+			continue
+		}
+		end := strings.IndexByte(content, '>')
+		if end == -1 {
+			// not a tag
+			self.addText(content)
+			break
+		}
+		self.handleTag(content[1:end])
+		content = content[end+1:]
+	}
+	if self.paragraph != nil {
+		self.endParagraph()
+	}
+	return self.paragraphs
+}
+
+func (self *richTextParser) currentStyle() *richTextStyle {
+	return self.styles[len(self.styles)-1]
+}
+
+func (self *richTextParser) handleTag(tag string) {
+	tag = strings.TrimSpace(tag)
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimSuffix(strings.TrimPrefix(tag, "/"), "/")
+	tag = strings.TrimSpace(tag)
+	// the tag name is separated from the attributes by any whitespace, e.g. a line break
+	name, attributes := tag, ""
+	if pos := strings.IndexFunc(tag, unicode.IsSpace); pos != -1 {
+		name, attributes = tag[:pos], tag[pos:]
+	}
+	name = strings.ToLower(name)
+
+	switch name {
+	case "script", "style":
+		self.skipText = !closing
+		return
+	case "br":
+		self.getParagraph()
+		self.endParagraph()
+		return
+	case "p", "div":
+		if self.paragraph != nil {
+			self.endParagraph()
+		}
+		return
+	case "ul", "ol":
+		if self.paragraph != nil {
+			self.endParagraph()
+		}
+		if closing {
+			if len(self.lists) > 0 {
+				self.lists = self.lists[:len(self.lists)-1]
+			}
+		} else if name == "ul" {
+			self.lists = append(self.lists, -1)
+		} else {
+			self.lists = append(self.lists, 0)
+		}
+		return
+	case "li":
+		if self.paragraph != nil {
+			self.endParagraph()
+		}
+		if !closing {
+			self.startListItem()
+		}
+		return
+	}
+
+	var style *richTextStyle
+	switch name {
+	case "b", "strong", "i", "em", "u", "ins", "s", "strike", "del", "sup", "sub", "span", "a":
+		if closing {
+			// the style of the last matching opening tag is closed together with all styles opened after it,
+			// e.g. the italic style of <b><i>x</b>, closing tags without opening tag are ignored
+			for i := len(self.tags) - 1; i > 0; i-- {
+				if self.tags[i] == name {
+					self.styles = self.styles[:i]
+					self.tags = self.tags[:i]
+					break
+				}
+			}
+			return
+		}
+		tmp := *self.currentStyle()
+		style = &tmp
+	default:
+		return
+	}
+	switch name {
+	case "b", "strong":
+		style.bold = true
+	case "i", "em":
+		style.italic = true
+	case "u", "ins":
+		style.underline = true
+	case "s", "strike", "del":
+		style.strikethrough = true
+	case "sup":
+		style.verticalAlign = richTextSuperscript
+	case "sub":
+		style.verticalAlign = richTextSubscript
+	case "span":
+		self.applyCSS(style, getRichTextAttribute(attributes, "style"))
+	case "a":
+		href := self.fillParameters(getRichTextAttribute(attributes, "href"))
+		if isSafeRichTextLink(href) {
+			style.link = href
+		}
+	}
+	self.styles = append(self.styles, style)
+	self.tags = append(self.tags, name)
+}
+
+// applyCSS applies the supported css properties of a span to the style
+func (self *richTextParser) applyCSS(style *richTextStyle, css string) {
+	for _, declaration := range strings.Split(css, ";") {
+		property, value, found := strings.Cut(declaration, ":")
+		if !found {
+			continue
+		}
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch property {
+		case "font-weight":
+			number, err := strconv.Atoi(value)
+			style.bold = value == "bold" || value == "bolder" || (err == nil && number >= 600)
+		case "font-style":
+			style.italic = value == "italic" || value == "oblique"
+		case "text-decoration", "text-decoration-line":
+			style.underline = strings.Contains(value, "underline")
+			style.strikethrough = strings.Contains(value, "line-through")
+		case "color":
+			if color, ok := parseCSSColor(value); ok {
+				style.textColor = color
+			}
+		case "font-size":
+			if fontSize, ok := parseCSSFontSize(value, style.fontSize, style.fontScale); ok {
+				style.fontSize = fontSize
+			}
+		case "font-family":
+			if font := getRichTextFont(value); font != "" {
+				style.font = font
+			}
+		case "vertical-align":
+			if value == "super" {
+				style.verticalAlign = richTextSuperscript
+			} else if value == "sub" {
+				style.verticalAlign = richTextSubscript
+			} else if value == "baseline" {
+				style.verticalAlign = richTextBaseline
+			}
+		}
+	}
+}
+
+// addText adds text of the rich text content, whitespace is collapsed like in html
+// and parameters are filled in afterwards so parameter values are never parsed as html
+func (self *richTextParser) addText(text string) {
+	if self.skipText {
+		return
+	}
+	text = html.UnescapeString(collapseWhitespace(text))
+	if text == "" {
+		return
+	}
+	if self.paragraph == nil || len(self.paragraph.runs) == 0 {
+		text = strings.TrimLeft(text, " ")
+		if text == "" {
+			return
+		}
+	} else if runs := self.paragraph.runs; strings.HasSuffix(runs[len(runs)-1].text, " ") {
+		text = strings.TrimLeft(text, " ")
+	}
+	text = self.fillParameters(text)
+	// line breaks of parameter values start a new paragraph
+	for i, part := range strings.Split(strings.Replace(text, "\r", "", -1), "\n") {
+		if i > 0 {
+			self.endParagraph()
+		}
+		if part != "" {
+			paragraph := self.getParagraph()
+			paragraph.runs = append(paragraph.runs, richTextRun{text: part, style: self.currentStyle()})
+		}
+	}
+}
+
+// getParagraph returns the current paragraph, a new paragraph is started if necessary
+func (self *richTextParser) getParagraph() *richTextParagraph {
+	if self.paragraph == nil {
+		self.paragraph = &richTextParagraph{runs: make([]richTextRun, 0), indent: self.getListIndent()}
+	}
+	return self.paragraph
+}
+
+func (self *richTextParser) endParagraph() {
+	paragraph := self.getParagraph()
+	if len(paragraph.runs) > 0 {
+		// spaces at the end of a paragraph are not printed
+		last := &paragraph.runs[len(paragraph.runs)-1]
+		last.text = strings.TrimRight(last.text, " ")
+	}
+	self.paragraphs = append(self.paragraphs, *paragraph)
+	self.paragraph = nil
+}
+
+// startListItem starts a paragraph with the bullet or number of the list item as marker
+func (self *richTextParser) startListItem() {
+	paragraph := self.getParagraph()
+	if len(self.lists) == 0 {
+		return
+	}
+	marker := "•"
+	if itemNumber := self.lists[len(self.lists)-1]; itemNumber >= 0 {
+		itemNumber++
+		self.lists[len(self.lists)-1] = itemNumber
+		marker = fmt.Sprintf("%d.", itemNumber)
+	}
+	markerStyle := *self.currentStyle()
+	markerStyle.link = ""
+	markerStyle.verticalAlign = richTextBaseline
+	paragraph.marker = &textFragment{text: marker, style: &markerStyle}
+}
+
+func (self *richTextParser) getListIndent() float64 {
+	return float64(len(self.lists)) * self.baseStyle.fontSize * richTextListIndent
+}
+
+func newRichTextParser(baseStyle *richTextStyle, fillParameters func(string) string) *richTextParser {
+	richTextParser := richTextParser{}
+	richTextParser.init(baseStyle, fillParameters)
+	return &richTextParser
+}
+
+// getRichTextAttribute returns the value of an attribute of a tag, e.g. href="..."
+func getRichTextAttribute(attributes string, name string) string {
+	for attributes != "" {
+		attributes = strings.TrimLeftFunc(attributes, unicode.IsSpace)
+		key := attributes
+		end := strings.IndexFunc(attributes, func(c rune) bool { return c == '=' || unicode.IsSpace(c) })
+		if end != -1 {
+			key = attributes[:end]
+		}
+		attributes = strings.TrimLeftFunc(attributes[len(key):], unicode.IsSpace)
+		value := ""
+		if strings.HasPrefix(attributes, "=") {
+			attributes = strings.TrimLeftFunc(attributes[1:], unicode.IsSpace)
+			if attributes != "" && (attributes[0] == '"' || attributes[0] == '\'') {
+				quote := attributes[0]
+				end = strings.IndexByte(attributes[1:], quote)
+				if end == -1 {
+					value, attributes = attributes[1:], ""
+				} else {
+					value, attributes = attributes[1:end+1], attributes[end+2:]
+				}
+			} else {
+				end = strings.IndexFunc(attributes, unicode.IsSpace)
+				if end == -1 {
+					value, attributes = attributes, ""
+				} else {
+					value, attributes = attributes[:end], attributes[end:]
+				}
+			}
+		}
+		if strings.ToLower(key) == name {
+			return html.UnescapeString(value)
+		}
+		if key == "" && value == "" {
+			break
+		}
+	}
+	return ""
+}
+
+// isSafeRichTextLink returns true for links to web pages, mail addresses and phone numbers
+func isSafeRichTextLink(href string) bool {
+	link := strings.ToLower(strings.TrimSpace(href))
+	for _, scheme := range []string{"http://", "https://", "mailto:", "tel:"} {
+		if strings.HasPrefix(link, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// collapseWhitespace replaces sequences of whitespace (except non-breaking spaces) by a single space
+func collapseWhitespace(text string) string {
+	var result strings.Builder
+	space := false
+	for _, c := range text {
+		if c != ' ' && unicode.IsSpace(c) || c == ' ' {
+			if !space {
+				result.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		result.WriteRune(c)
+		space = false
+	}
+	return result.String()
+}
+
+// parseCSSColor parses css colors in hex (#rgb, #rrggbb), rgb() or basic named format
+func parseCSSColor(value string) (Color, bool) {
+	namedColors := map[string]string{
+		"black": "#000000", "white": "#ffffff", "red": "#ff0000", "green": "#008000", "blue": "#0000ff",
+		"yellow": "#ffff00", "orange": "#ffa500", "purple": "#800080", "gray": "#808080", "grey": "#808080",
+	}
+	if hex, ok := namedColors[value]; ok {
+		value = hex
+	}
+	if strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")") {
+		parts := strings.Split(value[4:len(value)-1], ",")
+		if len(parts) != 3 {
+			return Color{}, false
+		}
+		rgb := make([]int, 3)
+		for i, part := range parts {
+			number, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || number < 0 || number > 255 {
+				return Color{}, false
+			}
+			rgb[i] = number
+		}
+		value = fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+	}
+	if len(value) == 4 && value[0] == '#' {
+		value = string([]byte{'#', value[1], value[1], value[2], value[2], value[3], value[3]})
+	}
+	if _, err := colorful.Hex(value); err != nil || len(value) != 7 {
+		return Color{}, false
+	}
+	return NewColor(value), true
+}
+
+// parseCSSFontSize parses a css font size in pt, px, em or %, em and % are relative to the current font size.
+// Absolute font sizes are multiplied by scale.
+func parseCSSFontSize(value string, fontSize float64, scale float64) (float64, bool) {
+	factor := 1.0
+	relative := false
+	for _, unit := range []string{"pt", "px", "em", "%"} {
+		if strings.HasSuffix(value, unit) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit))
+			if unit == "em" {
+				relative = true
+			} else if unit == "%" {
+				relative = true
+				factor = 0.01
+			}
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 || math.IsInf(number, 0) {
+		return 0, false
+	}
+	if relative {
+		return fontSize * number * factor, true
+	}
+	return number * scale, true
+}
+
+// getRichTextFont returns the core font for a css font family
+func getRichTextFont(fontFamily string) string {
+	for _, family := range strings.Split(fontFamily, ",") {
+		switch strings.Trim(strings.TrimSpace(family), `"'`) {
+		case "helvetica", "arial", "sans-serif":
+			return "Helvetica"
+		case "times", "times new roman", "serif":
+			return "Times"
+		case "courier", "courier new", "monospace":
+			return "Courier"
+		}
+	}
+	return ""
+}
+
+// textFragment is a part of a rich text line with a single style, the width of spaces is
+// increased for justified text
+type textFragment struct {
+	text  string
+	style *richTextStyle
+	width float64
+	space bool
+}
+
+// renderPDF renders the fragment, y is the top of the line and baselineY the baseline of the line text.
+// Underline and strikethrough are drawn for the whole line by renderRichTextDecorations.
+func (self *textFragment) renderPDF(x float64, y float64, baselineY float64, width float64, lineFontSize float64, pdfDoc *FPDFRB) {
+	if self.style.verticalAlign == richTextSuperscript {
+		baselineY -= self.style.fontSize * richTextSuperscriptRise
+	} else if self.style.verticalAlign == richTextSubscript {
+		baselineY += self.style.fontSize * richTextSubscriptDrop
+	}
+	if !self.space {
+		self.style.setFont(pdfDoc)
+		pdfDoc.Fpdf.SetTextColor(self.style.textColor.R, self.style.textColor.G, self.style.textColor.B)
+		if self.style.letterSpacing != 0 {
+			pdfDoc.setLetterSpacing(self.style.letterSpacing)
+		}
+		pdfDoc.renderText(x, baselineY, self.text, self.style.letterSpacing)
+		if self.style.letterSpacing != 0 {
+			pdfDoc.setLetterSpacing(0)
+		}
+	}
+	if self.style.link != "" {
+		pdfDoc.Fpdf.LinkString(x, y, width, lineFontSize, self.style.link)
+	}
+}
+
+// richTextWord is a word of rich text which can consist of fragments with different styles,
+// space is the space before the word (nil for the first word of a paragraph)
+type richTextWord struct {
+	space     *textFragment
+	fragments []textFragment
+}
+
+// richTextLineBreak is a line of rich text created by richTextLineBreaker
+type richTextLineBreak struct {
+	fragments    []textFragment
+	marker       *textFragment
+	indent       float64
+	fontSize     float64
+	paragraphEnd bool
+	rtl          bool
+}
+
+// richTextLineBreaker splits rich text paragraphs into lines which fit into the given width,
+// words are broken and hyphenated the same way as by lineBreaker
+type richTextLineBreaker struct {
+	pdfDoc          *FPDFRB
+	width           float64
+	fontSize        float64
+	firstLineIndent float64
+	hangingIndent   float64
+	textDirection   TextDirection
+	lineBreaker     *lineBreaker
+	lines           []richTextLineBreak
+}
+
+func (self *richTextLineBreaker) init(pdfDoc *FPDFRB, width float64, style *textStyle, hyphenator *hyphenator) {
+	self.pdfDoc = pdfDoc
+	self.width = width
+	self.fontSize = style.FontSize
+	self.firstLineIndent = style.FirstLineIndent
+	self.hangingIndent = style.HangingIndent
+	self.textDirection = style.TextDirection
+	self.lineBreaker = newLineBreaker(pdfDoc, width, style, hyphenator)
+	self.lines = make([]richTextLineBreak, 0)
+}
+
+// split returns the lines of the paragraphs, each paragraph starts a new line
+func (self *richTextLineBreaker) split(paragraphs []richTextParagraph) []richTextLineBreak {
+	for _, paragraph := range paragraphs {
+		self.addParagraph(paragraph)
+	}
+	return self.lines
+}
+
+func (self *richTextLineBreaker) addParagraph(paragraph richTextParagraph) {
+	indent := paragraph.indent + self.firstLineIndent
+	width := self.width - indent
+	line := make([]textFragment, 0)
+	lineWidth := 0.0
+	marker := paragraph.marker
+	var text strings.Builder
+	runs := make([]richTextRun, len(paragraph.runs))
+	for i, run := range paragraph.runs {
+		text.WriteString(run.text)
+		// arabic text is shaped for each run, letters are not joined across style changes
+		runs[i] = run
+		runs[i].text = shapeArabicText(run.text)
+	}
+	rtl := isRTLParagraph(text.String(), self.textDirection)
+	addLine := func(paragraphEnd bool) {
+		self.addLine(line, marker, indent, paragraphEnd, rtl)
+		line = make([]textFragment, 0)
+		lineWidth = 0.0
+		marker = nil
+		indent = paragraph.indent + self.hangingIndent
+		width = self.width - indent
+	}
+	for _, word := range getRichTextWords(runs) {
+		fragments := word.fragments
+		for {
+			spaceWidth := 0.0
+			if len(line) > 0 && word.space != nil {
+				spaceWidth = self.getWidth(word.space.text, word.space.style)
+			}
+			if lineWidth+spaceWidth+self.getFragmentsWidth(fragments) <= width {
+				if spaceWidth > 0 {
+					line = append(line, *word.space)
+				}
+				line = append(line, fragments...)
+				lineWidth += spaceWidth + self.getFragmentsWidth(fragments)
+				break
+			}
+			if len(fragments) == 0 {
+				// spaces at the end of a line are not printed
+				break
+			}
+			if prefix, rest, ok := self.hyphenate(fragments, width-lineWidth-spaceWidth); ok {
+				if spaceWidth > 0 {
+					line = append(line, *word.space)
+				}
+				line = append(line, prefix...)
+				addLine(false)
+				fragments = rest
+				continue
+			}
+			if len(line) > 0 {
+				addLine(false)
+				continue
+			}
+			// word does not fit into an empty line, it is split at the last character which fits
+			prefix, rest := self.splitWord(fragments, width)
+			line = append(line, prefix...)
+			addLine(false)
+			if len(rest) == 0 {
+				break
+			}
+			fragments = rest
+		}
+	}
+	addLine(true)
+}
+
+// addLine adds a line, soft hyphens are removed and the width of the fragments is set
+func (self *richTextLineBreaker) addLine(fragments []textFragment, marker *textFragment, indent float64, paragraphEnd bool, rtl bool) {
+	fontSize := 0.0
+	for i := range fragments {
+		fragments[i].text = strings.Replace(fragments[i].text, softHyphen, "", -1)
+		fragments[i].width = self.getWidth(fragments[i].text, fragments[i].style)
+		if fragments[i].style.fontSize > fontSize {
+			fontSize = fragments[i].style.fontSize
+		}
+	}
+	if fontSize == 0 {
+		fontSize = self.fontSize
+		if marker != nil {
+			fontSize = marker.style.fontSize
+		}
+	}
+	self.lines = append(self.lines, richTextLineBreak{
+		fragments: fragments, marker: marker, indent: indent, fontSize: fontSize, paragraphEnd: paragraphEnd, rtl: rtl})
+}
+
+func (self *richTextLineBreaker) getWidth(text string, style *richTextStyle) float64 {
+	return getRichTextWidth(text, style, self.pdfDoc)
+}
+
+func (self *richTextLineBreaker) getFragmentsWidth(fragments []textFragment) float64 {
+	width := 0.0
+	for _, fragment := range fragments {
+		width += self.getWidth(fragment.text, fragment.style)
+	}
+	return width
+}
+
+// hyphenate returns the first part of the word which fits into the available width and the remaining part of the word
+func (self *richTextLineBreaker) hyphenate(fragments []textFragment, availableWidth float64) ([]textFragment, []textFragment, bool) {
+	breaks := self.lineBreaker.getWordBreaks(getFragmentsText(fragments))
+	for i := len(breaks) - 1; i >= 0; i-- {
+		prefix, _ := splitFragments(fragments, breaks[i].pos)
+		if breaks[i].hyphen {
+			prefix[len(prefix)-1].text += "-"
+		}
+		if self.getFragmentsWidth(prefix) <= availableWidth {
+			_, rest := splitFragments(fragments, breaks[i].next)
+			return prefix, rest, true
+		}
+	}
+	return nil, nil, false
+}
+
+// splitWord returns the longest part of the word which fits into an empty line (at least one character)
+// and the remaining part of the word
+func (self *richTextLineBreaker) splitWord(fragments []textFragment, width float64) ([]textFragment, []textFragment) {
+	text := getFragmentsText(fragments)
+	end := 0
+	for pos, c := range text {
+		next := pos + utf8.RuneLen(c)
+		if prefix, _ := splitFragments(fragments, next); end > 0 && self.getFragmentsWidth(prefix) > width {
+			break
+		}
+		end = next
+	}
+	return splitFragments(fragments, end)
+}
+
+func newRichTextLineBreaker(pdfDoc *FPDFRB, width float64, style *textStyle, hyphenator *hyphenator) *richTextLineBreaker {
+	richTextLineBreaker := richTextLineBreaker{}
+	richTextLineBreaker.init(pdfDoc, width, style, hyphenator)
+	return &richTextLineBreaker
+}
+
+// addRichTextEllipsis shortens the fragments of the line so an ellipsis can be appended
+func (self *TextLine) addRichTextEllipsis(pdfDoc *FPDFRB) {
+	fragments := make([]textFragment, len(self.Fragments))
+	copy(fragments, self.Fragments)
+	var ellipsisStyle *richTextStyle
+	if len(fragments) > 0 {
+		ellipsisStyle = fragments[len(fragments)-1].style
+	} else if self.Marker != nil {
+		ellipsisStyle = self.Marker.style
+	} else {
+		return
+	}
+	availableWidth := self.Width - self.Indent - getRichTextWidth(ellipsis, ellipsisStyle, pdfDoc)
+	for len(fragments) > 0 {
+		last := &fragments[len(fragments)-1]
+		last.text = strings.TrimRight(last.text, " ")
+		if last.text == "" {
+			fragments = fragments[:len(fragments)-1]
+			continue
+		}
+		width := 0.0
+		for i := range fragments {
+			fragments[i].width = getRichTextWidth(fragments[i].text, fragments[i].style, pdfDoc)
+			width += fragments[i].width
+		}
+		if width <= availableWidth {
+			break
+		}
+		_, size := utf8.DecodeLastRuneInString(last.text)
+		last.text = last.text[:len(last.text)-size]
+	}
+	fragments = append(fragments, textFragment{text: ellipsis, style: ellipsisStyle,
+		width: getRichTextWidth(ellipsis, ellipsisStyle, pdfDoc)})
+	self.Fragments = fragments
+	self.Text = getFragmentsText(fragments)
+	// the text style is used for the following text
+	pdfDoc.setFont(self.Style.base().Font, self.Style.base().FontStyle, self.Style.base().FontSize)
+	pdfDoc.setFallbackFonts(self.Style.base().FallbackFonts)
+}
+
+// renderRichTextDecorations draws underline and strikethrough of the fragments at the baseline of the line,
+// consecutive fragments with the same color are connected by a single line with the metrics of the largest font
+func renderRichTextDecorations(fragments []textFragment, positions []float64, widths []float64, baselineY float64, pdfDoc *FPDFRB) {
+	hasDecoration := func(style *richTextStyle, strikethrough bool) bool {
+		if strikethrough {
+			return style.strikethrough
+		}
+		return style.underline
+	}
+	for _, strikethrough := range []bool{false, true} {
+		for start := 0; start < len(fragments); {
+			style := fragments[start].style
+			if !hasDecoration(style, strikethrough) {
+				start++
+				continue
+			}
+			fontStyle := style
+			end := start + 1
+			for end < len(fragments) && hasDecoration(fragments[end].style, strikethrough) &&
+				fragments[end].style.textColor == style.textColor {
+				if fragments[end].style.getRenderFontSize() > fontStyle.getRenderFontSize() {
+					fontStyle = fragments[end].style
+				}
+				end++
+			}
+			x := positions[start]
+			width := positions[end-1] + widths[end-1] - x
+			metrics := pdfDoc.getFontMetrics(fontStyle.font, fontStyle.getFontStyle())
+			pdfDoc.Fpdf.SetDrawColor(style.textColor.R, style.textColor.G, style.textColor.B)
+			if strikethrough {
+				pdfDoc.renderStrikethrough(x, baselineY, width, metrics, fontStyle.getRenderFontSize())
+			} else {
+				pdfDoc.renderUnderline(x, baselineY, width, metrics, fontStyle.getRenderFontSize())
+			}
+			start = end
+		}
+	}
+}
+
+// getVisualFragments returns the fragments of a line in visual order, fragments are split where
+// the embedding level changes and the text of right-to-left parts is reversed
+func getVisualFragments(fragments []textFragment, rtl bool, pdfDoc *FPDFRB) []textFragment {
+	text := getFragmentsText(fragments)
+	if !rtl && !hasRTLText(text) {
+		return fragments
+	}
+	levels := getBidiLevels([]rune(text), rtl)
+	parts := make([]textFragment, 0, len(fragments))
+	partLevels := make([]int, 0, len(fragments))
+	offset := 0
+	for _, fragment := range fragments {
+		runes := []rune(fragment.text)
+		start := 0
+		for i := 1; i <= len(runes); i++ {
+			if i < len(runes) && levels[offset+i] == levels[offset+start] {
+				continue
+			}
+			part := fragment
+			if start > 0 || i < len(runes) {
+				part.text = string(runes[start:i])
+				part.width = getRichTextWidth(part.text, part.style, pdfDoc)
+			}
+			parts = append(parts, part)
+			partLevels = append(partLevels, levels[offset+start])
+			start = i
+		}
+		offset += len(runes)
+	}
+	visualFragments := make([]textFragment, 0, len(parts))
+	for _, i := range getBidiVisualOrder(partLevels) {
+		part := parts[i]
+		if partLevels[i]%2 == 1 {
+			part.text = reverseBidiText(part.text)
+		}
+		visualFragments = append(visualFragments, part)
+	}
+	return visualFragments
+}
+
+// getRichTextWidth returns the width of text with the given style, soft hyphens are ignored
+func getRichTextWidth(text string, style *richTextStyle, pdfDoc *FPDFRB) float64 {
+	style.setFont(pdfDoc)
+	return pdfDoc.getTextWidth(strings.Replace(text, softHyphen, "", -1), style.letterSpacing)
+}
+
+// getRichTextWords splits the runs of a paragraph into words, a word ends at a space or a line break
+// opportunity between ideographs and not at a style change
+func getRichTextWords(runs []richTextRun) []richTextWord {
+	words := []richTextWord{{fragments: make([]textFragment, 0)}}
+	for _, run := range runs {
+		for i, part := range strings.Split(run.text, " ") {
+			if i > 0 {
+				space := textFragment{text: " ", style: run.style, space: true}
+				words = append(words, richTextWord{space: &space, fragments: make([]textFragment, 0)})
+			}
+			for _, segment := range splitAtLineBreakOpportunities(part) {
+				if segment == "" {
+					continue
+				}
+				word := &words[len(words)-1]
+				if text := getFragmentsText(word.fragments); text != "" {
+					if isLineBreakOpportunity(text, segment) {
+						words = append(words, richTextWord{fragments: make([]textFragment, 0)})
+						word = &words[len(words)-1]
+					}
+				}
+				word.fragments = append(word.fragments, textFragment{text: segment, style: run.style})
+			}
+		}
+	}
+	return words
+}
+
+func getFragmentsText(fragments []textFragment) string {
+	var text strings.Builder
+	for _, fragment := range fragments {
+		text.WriteString(fragment.text)
+	}
+	return text.String()
+}
+
+// splitFragments splits the fragments at a byte position of the text of the fragments
+func splitFragments(fragments []textFragment, pos int) ([]textFragment, []textFragment) {
+	before := make([]textFragment, 0, len(fragments))
+	after := make([]textFragment, 0, len(fragments))
+	offset := 0
+	for _, fragment := range fragments {
+		if offset+len(fragment.text) <= pos {
+			before = append(before, fragment)
+		} else if offset >= pos {
+			after = append(after, fragment)
+		} else {
+			head, tail := fragment, fragment
+			head.text = fragment.text[:pos-offset]
+			tail.text = fragment.text[pos-offset:]
+			before = append(before, head)
+			after = append(after, tail)
+		}
+		offset += len(fragment.text)
+	}
+	return before, after
+}
+
+// getRichTextLines returns the lines of rich text content for the given width
+func getRichTextLines(content string, style *textStyle, fillParameters func(string) string, width float64, hyphenator *hyphenator, link string, pdfDoc *FPDFRB) []TextLine {
+	baseStyle := &richTextStyle{}
+	baseStyle.init(style)
+	paragraphs := newRichTextParser(baseStyle, fillParameters).parse(content)
+	lines := make([]TextLine, 0)
+	for _, lineBreak := range newRichTextLineBreaker(pdfDoc, width, style, hyphenator).split(paragraphs) {
+		line := NewTextLine(getFragmentsText(lineBreak.fragments), width, style, link)
+		line.ParagraphEnd = lineBreak.paragraphEnd
+		line.Fragments = lineBreak.fragments
+		line.Marker = lineBreak.marker
+		line.Indent = lineBreak.indent
+		line.FontSize = lineBreak.fontSize
+		line.RTL = lineBreak.rtl
+		lines = append(lines, line)
+	}
+	// the text style is used for the following text
+	pdfDoc.setFont(style.Font, style.FontStyle, style.FontSize)
+	pdfDoc.setFallbackFonts(style.FallbackFonts)
+	return lines
+}
+
+// getRichTextPlainText returns the text of rich text content without markup, e.g. for spreadsheets
+func getRichTextPlainText(content string, style *textStyle, fillParameters func(string) string) string {
+	baseStyle := &richTextStyle{}
+	baseStyle.init(style)
+	paragraphs := make([]string, 0)
+	for _, paragraph := range newRichTextParser(baseStyle, fillParameters).parse(content) {
+		var text strings.Builder
+		if paragraph.marker != nil {
+			text.WriteString(paragraph.marker.text + " ")
+		}
+		for _, run := range paragraph.runs {
+			text.WriteString(run.text)
+		}
+		paragraphs = append(paragraphs, text.String())
+	}
+	return strings.Join(paragraphs, "\n")
+}
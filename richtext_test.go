@@ -0,0 +1,77 @@
+package reportbro
+
+import (
+	"strings"
+	"testing"
+)
+
+// formatRichTextRuns returns the runs of the parsed paragraphs with their style, e.g. "[bi]text"
+func formatRichTextRuns(paragraphs []richTextParagraph) string {
+	parts := make([]string, 0)
+	for _, paragraph := range paragraphs {
+		for _, run := range paragraph.runs {
+			style := ""
+			if run.style.bold {
+				style += "b"
+			}
+			if run.style.italic {
+				style += "i"
+			}
+			if run.style.fontSize != 10 {
+				style += "s"
+			}
+			parts = append(parts, "["+style+"]"+run.text)
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func TestRichTextParser(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"a <b>b</b> c", "[]a [b]b[] c"},
+		{"<b><i>x</i>y</b>z", "[bi]x[b]y[]z"},
+		// closing a tag also closes the tags opened after it
+		{"<b><i>x</b>y</i>z", "[bi]x[]y[]z"},
+		{"<b>x</i>y</b>z", "[b]x[b]y[]z"},
+		{"<B>x</B>y", "[b]x[]y"},
+		// attributes are separated from the tag name by any whitespace
+		{"<span\nstyle=\"font-size: 20px\">x</span>y", "[s]x[]y"},
+		{"<span\tstyle='font-weight: bold'>x</span>y", "[b]x[]y"},
+		{"<b >x</b >y", "[b]x[]y"},
+	}
+	for _, test := range tests {
+		parser := newRichTextParser(&richTextStyle{fontSize: 10, fontScale: 1}, func(text string) string { return text })
+		if got := formatRichTextRuns(parser.parse(test.content)); got != test.want {
+			t.Errorf("parse(%q) = %q, want %q", test.content, got, test.want)
+		}
+	}
+}
+
+func TestParseCSSFontSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		fontSize float64
+		scale    float64
+		want     float64
+		ok       bool
+	}{
+		{"12pt", 10, 1, 12, true},
+		{"12px", 10, 1, 12, true},
+		{"1.5em", 10, 1, 15, true},
+		{"50%", 10, 1, 5, true},
+		// absolute sizes are scaled when the text is shrunk, relative sizes are based on the shrunk size
+		{"12pt", 5, 0.5, 6, true},
+		{"2em", 5, 0.5, 10, true},
+		{"-1pt", 10, 1, 0, false},
+		{"large", 10, 1, 0, false},
+	}
+	for _, test := range tests {
+		got, ok := parseCSSFontSize(test.value, test.fontSize, test.scale)
+		if got != test.want || ok != test.ok {
+			t.Errorf("parseCSSFontSize(%q, %v, %v) = %v, %v, want %v, %v", test.value, test.fontSize, test.scale, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package reportbro
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+)
+
+// RowSource provides the rows of an array parameter one at a time so large data sets do not have to be
+// loaded into memory. Next returns false as second value when there are no more rows.
+//
+// A RowSource is read only once while the report is rendered, therefore it can be the data source of a
+// single table or section but it cannot be used for aggregate parameters (sum, average, ...).
+// Group variables of a table band before a group (e.g. ${group.sum(amount)} in a group header) need
+// all rows of the group before it is printed, so the rows of each group are kept in memory in this case.
+// Bands after a group accumulate their group variables and do not keep the rows.
+// If the RowSource implements io.Closer it is closed once the report is generated, also when
+// rendering fails or the rows were not read completely.
+type RowSource interface {
+	Next() (map[string]interface{}, bool, error)
+}
+
+// rowSourceBinder is implemented by row sources which need the array parameter and the report data
+// before the first row is read, e.g. to get query arguments from other parameters (see SQLRowSource)
+type rowSourceBinder interface {
+	bind(ctx context.Context, parameter Parameter, data map[string]interface{}) error
+}
+
+// rowStream reads the rows of a RowSource bound to an array parameter, each row is mapped onto
+// the row parameters when it is read (like the rows of an array in processData)
+type rowStream struct {
+	report     *report
+	parameter  Parameter
+	source     RowSource
+	ctx        context.Context
+	isTestData bool
+	started    bool
+	done       bool
+	rowIndex   int
+	err        error
+}
+
+func (self *rowStream) init(report *report, parameter Parameter, source RowSource, isTestData bool) {
+	self.report = report
+	self.parameter = parameter
+	self.source = source
+	self.ctx = context.Background()
+	self.isTestData = isTestData
+}
+
+// next returns the next processed row, false is returned if there are no more rows or reading failed
+func (self *rowStream) next() (map[string]interface{}, bool) {
+	if self.done {
+		return nil, false
+	}
+	var err error
+	if binder, ok := self.source.(rowSourceBinder); ok && !self.started {
+		err = binder.bind(self.ctx, self.parameter, self.report.Data)
+	}
+	self.started = true
+	var row map[string]interface{}
+	ok := false
+	if err == nil {
+		row, ok, err = self.source.Next()
+	}
+	if err != nil {
+		self.err = fmt.Errorf("reading rows of parameter %s failed: %w", self.parameter.Name, err)
+		log.Println(Error{Message: "errorMsgInvalidDataSource", ObjectID: self.parameter.ID, Field: "data_source", Info: err.Error()})
+	}
+	if !ok || err != nil {
+		self.done = true
+		return nil, false
+	}
+
+	errorCount := len(self.report.errors)
+	destRow := make(map[string]interface{}, 0)
+	computedParameters := map[int]computedParameter{}
+	self.report.processData(&destRow, row, self.parameter.Children, self.isTestData, &computedParameters, map[int]Parameter{0: self.parameter}, fmt.Sprintf("%s[%d]", self.parameter.Name, self.rowIndex))
+	self.rowIndex++
+	// the report is already rendered so errors of the row data are only logged
+	for _, rowError := range self.report.errors[errorCount:] {
+		log.Println(rowError)
+	}
+	self.report.errors = self.report.errors[:errorCount]
+	return destRow, true
+}
+
+// close closes the row source if it implements io.Closer
+func (self *rowStream) close() error {
+	self.done = true
+	if closer, ok := self.source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func newRowStream(report *report, parameter Parameter, source RowSource, isTestData bool) *rowStream {
+	rowStream := rowStream{}
+	rowStream.init(report, parameter, source, isTestData)
+	return &rowStream
+}
+
+// dataRows contains the data rows of a table or section. Rows of a streamed array parameter are read
+// on demand and released once they are not needed anymore so only a small window of rows is kept in memory.
+type dataRows struct {
+	rows   []interface{}
+	offset int
+	stream *rowStream
+}
+
+// has returns true if the row with the given index exists, rows of a stream are read until the row is available
+func (self *dataRows) has(index int) bool {
+	for self.stream != nil && index >= self.offset+len(self.rows) {
+		row, ok := self.stream.next()
+		if !ok {
+			break
+		}
+		self.rows = append(self.rows, row)
+	}
+	return index >= self.offset && index < self.offset+len(self.rows)
+}
+
+// get returns the row with the given index, the row must be available (see has)
+func (self *dataRows) get(index int) map[string]interface{} {
+	row, _ := self.rows[index-self.offset].(map[string]interface{})
+	return row
+}
+
+// release removes all rows before the given index of a stream from the buffer,
+// rows of an array are kept because they are in memory anyway
+func (self *dataRows) release(index int) {
+	if self.stream == nil {
+		return
+	}
+	count := index - self.offset
+	if count > len(self.rows) {
+		count = len(self.rows)
+	}
+	if count <= 0 {
+		return
+	}
+	for i := 0; i < count; i++ {
+		self.rows[i] = nil
+	}
+	self.rows = self.rows[count:]
+	self.offset += count
+}
+
+// newDataRows returns the rows of the data of an array parameter which is either a list or a stream,
+// false is returned for invalid data or in case the stream was already read by another element
+func newDataRows(data interface{}) (*dataRows, bool) {
+	switch rows := data.(type) {
+	case []interface{}:
+		return &dataRows{rows: rows}, true
+	case *rowStream:
+		if rows.started {
+			return &dataRows{rows: make([]interface{}, 0)}, false
+		}
+		return &dataRows{rows: make([]interface{}, 0), stream: rows}, true
+	}
+	return &dataRows{rows: make([]interface{}, 0)}, false
+}
+
+// newStaticDataRows returns a single empty row used for elements without data source
+func newStaticDataRows() *dataRows {
+	return &dataRows{rows: []interface{}{map[string]interface{}{}}}
+}
@@ -0,0 +1,110 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// generatedRowSource returns rows with the numbers 1 to count, onNext is called before each row is read
+type generatedRowSource struct {
+	count  int
+	read   int
+	onNext func()
+}
+
+func (self *generatedRowSource) Next() (map[string]interface{}, bool, error) {
+	if self.onNext != nil {
+		self.onNext()
+	}
+	if self.read == self.count {
+		return nil, false, nil
+	}
+	self.read++
+	return map[string]interface{}{"number": self.read}, true, nil
+}
+
+func TestRowSourceStreaming(t *testing.T) {
+	const rowCount = 500
+	const parameters = `[{"id":1,"name":"tableRows","type":"array","children":[{"id":2,"name":"number","type":"number"}]},
+		{"id":3,"name":"sectionRows","type":"array","children":[{"id":4,"name":"number","type":"number"}]}]`
+	table := `{"id":100,"elementType":"table","containerId":"0_content","x":0,"y":0,"width":300,"height":20,
+		"dataSource":"${tableRows}","columns":1,"header":false,"footer":false,"contentDataRows":[
+		{"id":110,"height":20,"columnData":[{"id":111,"content":"t${number}","width":300,"height":20,"fontSize":10,"lineSpacing":1}]}]}`
+	section := `{"id":200,"elementType":"section","containerId":"0_content","x":0,"y":40,"width":300,"height":20,
+		"dataSource":"${sectionRows}","header":false,"footer":false,"contentData":{"id":210,"height":20,"linkedContainerId":"210"}},
+		{"id":211,"elementType":"text","containerId":"210","x":0,"y":0,"width":300,"height":20,"content":"s${number}","fontSize":10,"lineSpacing":1}`
+	js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","marginLeft":20,"marginTop":20,"marginRight":20,"marginBottom":20},
+		"parameters":` + parameters + `,"styles":[],"docElements":[` + table + "," + section + `],"version":2}`
+	var definition map[string]interface{}
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	tableSource := &generatedRowSource{count: rowCount}
+	sectionSource := &generatedRowSource{count: rowCount}
+	report := NewReport(definition, map[string]interface{}{"tableRows": tableSource, "sectionRows": sectionSource}, false, "", nil)
+	if len(report.Errors()) > 0 {
+		t.Fatalf("errors %v", report.Errors())
+	}
+
+	var tableElement *TableElement
+	var sectionElement *SectionElement
+	for _, element := range report.content.base().DocElements {
+		switch v := element.(type) {
+		case *TableElement:
+			tableElement = v
+		case *SectionElement:
+			sectionElement = v
+		}
+	}
+	if tableElement == nil || sectionElement == nil {
+		t.Fatalf("table or section element not found")
+	}
+	// the number of rows kept in memory is checked whenever a row is read
+	maxTableRows, maxSectionRows, maxPrepared := 0, 0, 0
+	tableSource.onNext = func() {
+		if len(tableElement.Rows.rows) > maxTableRows {
+			maxTableRows = len(tableElement.Rows.rows)
+		}
+		if len(tableElement.PreparedRows) > maxPrepared {
+			maxPrepared = len(tableElement.PreparedRows)
+		}
+	}
+	sectionSource.onNext = func() {
+		if len(sectionElement.Rows.rows) > maxSectionRows {
+			maxSectionRows = len(sectionElement.Rows.rows)
+		}
+	}
+
+	pdf, err := report.GeneratePDF(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tableSource.read != rowCount || sectionSource.read != rowCount {
+		t.Errorf("rows read = %d and %d, want %d", tableSource.read, sectionSource.read, rowCount)
+	}
+	// the table reads rows in batches of 10 which are released once they are added to the page
+	// rows are released once they are processed, i.e. at most the current row is kept when the next row is read
+	if maxTableRows > 1 || maxSectionRows > 1 {
+		t.Errorf("rows kept in memory = %d (table) and %d (section), want at most 1", maxTableRows, maxSectionRows)
+	}
+	if tableElement.Rows.offset != rowCount || sectionElement.Rows.offset != rowCount {
+		t.Errorf("rows released = %d (table) and %d (section), want %d", tableElement.Rows.offset, sectionElement.Rows.offset, rowCount)
+	}
+	// the table prepares rows in batches of 10 before they are added to the page
+	if maxPrepared > 20 {
+		t.Errorf("prepared table rows = %d, want at most 20", maxPrepared)
+	}
+
+	texts := getPDFTexts(t, pdf)
+	want := make([]string, 0, 2*rowCount)
+	for i := 1; i <= rowCount; i++ {
+		want = append(want, fmt.Sprintf("t%d", i))
+	}
+	for i := 1; i <= rowCount; i++ {
+		want = append(want, fmt.Sprintf("s%d", i))
+	}
+	if fmt.Sprint(texts) != fmt.Sprint(want) {
+		t.Errorf("texts = %q, want t1 to t%d and s1 to s%d", texts, rowCount, rowCount)
+	}
+}
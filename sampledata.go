@@ -0,0 +1,379 @@
+package reportbro
+
+import (
+	"bytes"
+	b64 "encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// SampleDataOptions contains the options of GenerateSampleData
+type SampleDataOptions struct {
+	// Seed of the random generator, the same seed always generates the same data
+	Seed int64
+	// Rows is the number of rows of array parameters, default is 5
+	Rows int
+	// Date is the reference date of generated dates, dates are within a year from it. Default is 2024-01-01.
+	Date time.Time
+}
+
+var sampleFirstNames = []string{"Anna", "Ben", "Clara", "David", "Emma", "Felix", "Grace", "Henry", "Iris", "Jonas", "Laura", "Max", "Nina", "Oscar", "Paula", "Tom"}
+var sampleLastNames = []string{"Baker", "Fischer", "Garcia", "Johnson", "Keller", "Miller", "Novak", "Rossi", "Schmidt", "Smith", "Taylor", "Weber"}
+var sampleCompanies = []string{"Acme Corp", "Blue Sky Ltd", "Globex Inc", "Initech GmbH", "Northwind Traders", "Stark Industries", "Umbrella AG", "Wayne Enterprises"}
+var sampleStreets = []string{"Main Street", "Oak Avenue", "Park Road", "Station Street", "Church Lane", "Mill Road", "High Street"}
+var sampleCities = []string{"Berlin", "Boston", "London", "Madrid", "Milan", "Paris", "Vienna", "Zurich"}
+var sampleCountries = []string{"Austria", "France", "Germany", "Italy", "Spain", "Switzerland", "United Kingdom", "United States"}
+var sampleCurrencies = []string{"EUR", "USD", "GBP", "CHF"}
+var sampleStatuses = []string{"open", "paid", "pending", "shipped"}
+var sampleWords = strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua")
+
+// sampleDataGenerator creates plausible data for the parameters of a report definition
+type sampleDataGenerator struct {
+	random      *rand.Rand
+	rows        int
+	date        time.Time
+	placeholder string
+}
+
+func (self *sampleDataGenerator) init(options SampleDataOptions) {
+	self.random = rand.New(rand.NewSource(options.Seed))
+	self.rows = options.Rows
+	if self.rows <= 0 {
+		self.rows = 5
+	}
+	self.date = options.Date
+	if self.date.IsZero() {
+		self.date = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// getData returns the data of the given parameters, computed and internal parameters are skipped
+func (self *sampleDataGenerator) getData(parameters []interface{}, rowIndex int) map[string]interface{} {
+	data := make(map[string]interface{}, len(parameters))
+	for _, item := range parameters {
+		parameter := item.(Parameter)
+		if parameter.IsInternal || parameter.Eval || parameter.Type.isAggregate() || parameter.Name == "" {
+			continue
+		}
+		switch parameter.Type {
+		case ParameterTypeArray, ParameterTypeSimpleArray:
+			count := self.rows
+			if parameter.MinItems > 0 && count < parameter.MinItems {
+				count = parameter.MinItems
+			}
+			if parameter.MaxItems > 0 && count > parameter.MaxItems {
+				count = parameter.MaxItems
+			}
+			rows := make([]interface{}, count)
+			for i := range rows {
+				if parameter.Type == ParameterTypeArray {
+					rows[i] = self.getData(parameter.Children, i)
+				} else {
+					rows[i] = self.getValue(parameter, parameter.ArrayItemType, i)
+				}
+			}
+			data[parameter.Name] = rows
+		case ParameterTypeMap:
+			data[parameter.Name] = self.getData(parameter.Children, rowIndex)
+		default:
+			data[parameter.Name] = self.getValue(parameter, parameter.Type, rowIndex)
+		}
+	}
+	return data
+}
+
+// getValue returns a value for a scalar parameter or simple array item
+func (self *sampleDataGenerator) getValue(parameter Parameter, parameterType ParameterType, rowIndex int) interface{} {
+	if len(parameter.AllowedValues) > 0 {
+		return parameter.AllowedValues[self.random.Intn(len(parameter.AllowedValues))]
+	}
+	switch parameterType {
+	case ParameterTypeString:
+		return self.getString(parameter, rowIndex)
+	case ParameterTypeNumber:
+		return self.getNumber(parameter)
+	case ParameterTypeBoolean:
+		return self.random.Intn(2) == 1
+	case ParameterTypeDate, ParameterTypeDatetime, ParameterTypeTime:
+		return self.getDate(parameter, parameterType)
+	case ParameterTypeImage:
+		return self.getPlaceholderImage()
+	}
+	return nil
+}
+
+// getString returns a string matching the parameter name, e.g. a city for a parameter named "city"
+func (self *sampleDataGenerator) getString(parameter Parameter, rowIndex int) string {
+	words := getNameWords(parameter.Name)
+	has := func(names ...string) bool {
+		for _, word := range words {
+			for _, name := range names {
+				if word == name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	last := words[len(words)-1]
+	var value string
+	switch {
+	case has("email", "mail"):
+		value = fmt.Sprintf("%s.%s@example.com", strings.ToLower(self.pick(sampleFirstNames)), strings.ToLower(self.pick(sampleLastNames)))
+	case has("url", "website", "web", "homepage"):
+		value = "https://www.example.com"
+	case has("phone", "tel", "telephone", "mobile", "fax"):
+		value = fmt.Sprintf("+1 555 %04d", self.random.Intn(10000))
+	case has("zip", "postal", "postcode", "plz"):
+		value = fmt.Sprintf("%05d", self.random.Intn(100000))
+	case inArray(last, []string{"id", "no", "nr", "number", "code", "sku", "ref", "reference", "key"}):
+		// e.g. customer_id or invoice_number
+		prefix := []rune(strings.ToUpper(words[0]))
+		if len(prefix) > 3 {
+			prefix = prefix[:3]
+		}
+		value = fmt.Sprintf("%s-%05d", string(prefix), 1000+self.random.Intn(99000))
+	case has("company", "customer", "supplier", "vendor", "organization", "firm"):
+		value = self.pick(sampleCompanies)
+	case has("firstname", "first", "forename"):
+		value = self.pick(sampleFirstNames)
+	case has("lastname", "last", "surname"):
+		value = self.pick(sampleLastNames)
+	case has("name", "contact", "person", "author"):
+		value = self.pick(sampleFirstNames) + " " + self.pick(sampleLastNames)
+	case has("street", "address"):
+		value = fmt.Sprintf("%d %s", 1+self.random.Intn(200), self.pick(sampleStreets))
+	case has("city", "town"):
+		value = self.pick(sampleCities)
+	case has("country"):
+		value = self.pick(sampleCountries)
+	case has("currency"):
+		value = self.pick(sampleCurrencies)
+	case has("status", "state"):
+		value = self.pick(sampleStatuses)
+	case has("description", "text", "note", "notes", "comment", "remark", "remarks", "info", "message"):
+		value = self.getSentence(6 + self.random.Intn(6))
+	case has("title", "subject", "heading"):
+		value = self.getSentence(2 + self.random.Intn(3))
+	default:
+		for i, word := range words {
+			words[i] = upperFirst(word)
+		}
+		value = fmt.Sprintf("%s %d", strings.Join(words, " "), rowIndex+1)
+	}
+
+	length := len([]rune(value))
+	if parameter.MaxLength > 0 && length > parameter.MaxLength {
+		value = string([]rune(value)[:parameter.MaxLength])
+	} else if parameter.MinLength > length {
+		value += strings.Repeat("x", parameter.MinLength-length)
+	}
+	return value
+}
+
+// getNumber returns a number with the decimal places of the number pattern, within min and max of the parameter
+func (self *sampleDataGenerator) getNumber(parameter Parameter) float64 {
+	decimals := getPatternDecimals(parameter.Pattern)
+	minValue, maxValue := 1.0, 1000.0
+	if strings.Contains(parameter.Pattern, "%") {
+		// the value is a fraction which is multiplied by 100 when formatted, e.g. 0.125 -> 12.5%
+		minValue, maxValue = 0.0, 1.0
+		decimals += 2
+	} else if strings.Contains(parameter.Pattern, "‰") {
+		minValue, maxValue = 0.0, 1.0
+		decimals += 3
+	} else if words := getNameWords(parameter.Name); len(words) > 0 {
+		switch words[len(words)-1] {
+		case "quantity", "qty", "count", "pieces", "pcs", "units":
+			minValue, maxValue, decimals = 1, 20, 0
+		case "percent", "percentage", "rate", "discount", "vat", "tax":
+			minValue, maxValue = 0, 25
+		}
+	}
+	if limit, ok := parameter.MinValue.(decimal.Decimal); ok {
+		minValue = limit.InexactFloat64()
+		if maxValue < minValue {
+			maxValue = minValue + 1000
+		}
+	}
+	if limit, ok := parameter.MaxValue.(decimal.Decimal); ok {
+		maxValue = limit.InexactFloat64()
+		if minValue > maxValue {
+			minValue = maxValue - 1000
+		}
+	}
+	factor := math.Pow(10, float64(decimals))
+	value := math.Round((minValue+self.random.Float64()*(maxValue-minValue))*factor) / factor
+	// rounding must not exceed the limits
+	return math.Max(math.Min(value, maxValue), minValue)
+}
+
+// getDate returns a date within a year from the reference date (or within min and max of the parameter)
+// as ISO-8601 string, the value is parsed in the time zone of the report
+func (self *sampleDataGenerator) getDate(parameter Parameter, parameterType ParameterType) string {
+	start := self.date.AddDate(0, -6, 0)
+	end := self.date.AddDate(0, 6, 0)
+	if limit, ok := parameter.MinValue.(time.Time); ok {
+		start = limit
+		if end.Before(start) {
+			end = start.AddDate(1, 0, 0)
+		}
+	}
+	if limit, ok := parameter.MaxValue.(time.Time); ok {
+		end = limit
+		if start.After(end) {
+			start = end.AddDate(-1, 0, 0)
+		}
+	}
+	value := start
+	if seconds := int64(end.Sub(start) / time.Second); seconds > 0 {
+		value = start.Add(time.Duration(self.random.Int63n(seconds)) * time.Second)
+	}
+	switch parameterType {
+	case ParameterTypeDatetime:
+		// full minutes only
+		return value.Truncate(time.Minute).Format("2006-01-02 15:04")
+	case ParameterTypeTime:
+		return value.Format("15:04")
+	}
+	return value.Format("2006-01-02")
+}
+
+// getPlaceholderImage returns a gray png image with a cross as data url
+func (self *sampleDataGenerator) getPlaceholderImage() string {
+	if self.placeholder == "" {
+		width, height := 160, 100
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		background := color.RGBA{R: 224, G: 224, B: 224, A: 255}
+		foreground := color.RGBA{R: 160, G: 160, B: 160, A: 255}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				img.Set(x, y, background)
+			}
+		}
+		for x := 0; x < width; x++ {
+			y := x * height / width
+			img.Set(x, y, foreground)
+			img.Set(x, height-1-y, foreground)
+			img.Set(x, 0, foreground)
+			img.Set(x, height-1, foreground)
+		}
+		for y := 0; y < height; y++ {
+			img.Set(0, y, foreground)
+			img.Set(width-1, y, foreground)
+		}
+		var buf bytes.Buffer
+		png.Encode(&buf, img)
+		self.placeholder = "data:image/png;base64," + b64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+	return self.placeholder
+}
+
+func (self *sampleDataGenerator) pick(values []string) string {
+	return values[self.random.Intn(len(values))]
+}
+
+// getSentence returns a lorem ipsum sentence with the given number of words
+func (self *sampleDataGenerator) getSentence(wordCount int) string {
+	words := make([]string, wordCount)
+	for i := range words {
+		words[i] = self.pick(sampleWords)
+	}
+	sentence := strings.Join(words, " ")
+	return upperFirst(sentence)
+}
+
+// upperFirst converts the first letter of the string to upper case
+func upperFirst(value string) string {
+	if value == "" {
+		return value
+	}
+	first, size := utf8.DecodeRuneInString(value)
+	return string(unicode.ToUpper(first)) + value[size:]
+}
+
+// getNameWords splits a parameter name into lower case words, e.g. customerName_2 -> customer, name, 2
+func getNameWords(name string) []string {
+	words := make([]string, 0)
+	var word strings.Builder
+	var prev rune
+	for _, r := range name {
+		if r == '_' || (unicode.IsUpper(r) && unicode.IsLower(prev)) || (unicode.IsDigit(r) != unicode.IsDigit(prev) && word.Len() > 0) {
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		}
+		if r != '_' {
+			word.WriteRune(unicode.ToLower(r))
+		}
+		prev = r
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		words = append(words, "value")
+	}
+	return words
+}
+
+// getPatternDecimals returns the number of decimal places of a number pattern, e.g. 2 for #,##0.00
+func getPatternDecimals(pattern string) int {
+	if pattern == "" {
+		return 2
+	}
+	_, fraction, found := strings.Cut(pattern, ".")
+	if !found {
+		return 0
+	}
+	decimals := 0
+	for _, c := range fraction {
+		if c != '0' && c != '#' {
+			break
+		}
+		decimals++
+	}
+	return decimals
+}
+
+// GenerateSampleData returns plausible data for the parameters of a report definition, e.g. to preview a
+// report without test data: strings matching the parameter names, numbers with the decimal places of the
+// number pattern, dates, array rows and placeholder images. Validation rules of the parameters are respected
+// (except regex). The data only depends on the options, i.e. the same seed always returns the same data.
+func GenerateSampleData(reportDefinition map[string]interface{}, options SampleDataOptions) (map[string]interface{}, error) {
+	reportParameters, ok := reportDefinition["parameters"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("report definition has no parameters")
+	}
+	// errors of the parameter definitions, e.g. an invalid regex or limit, are collected in the report
+	parameterReport := &report{}
+	parameters := make([]interface{}, 0, len(reportParameters))
+	for _, item := range reportParameters {
+		data, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter in report definition")
+		}
+		parameters = append(parameters, NewParameter(parameterReport, data))
+	}
+	if len(parameterReport.errors) > 0 {
+		messages := make([]string, 0, len(parameterReport.errors))
+		for _, err := range parameterReport.errors {
+			messages = append(messages, err.Error())
+		}
+		return nil, fmt.Errorf("invalid parameters in report definition: %s", strings.Join(messages, "; "))
+	}
+	generator := sampleDataGenerator{}
+	generator.init(options)
+	return generator.getData(parameters, 0), nil
+}
@@ -0,0 +1,84 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSampleData(t *testing.T) {
+	var definition map[string]interface{}
+	js := `{"parameters":[{"id":1,"name":"amount","type":"number","pattern":"#,##0.00","min":10,"max":20},
+		{"id":2,"name":"items","type":"array","children":[{"id":3,"name":"name","type":"string"}]}]}`
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	data, err := GenerateSampleData(definition, SampleDataOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := GenerateSampleData(definition, SampleDataOptions{Seed: 1})
+	if !reflect.DeepEqual(data, again) {
+		t.Errorf("GenerateSampleData() with the same seed = %v and %v, want the same data", data, again)
+	}
+	if amount, ok := data["amount"].(float64); !ok || amount < 10 || amount > 20 {
+		t.Errorf("amount = %v, want a number between 10 and 20", data["amount"])
+	}
+}
+
+func TestGenerateSampleDataInvalidParameter(t *testing.T) {
+	tests := []struct {
+		parameters string
+		want       string
+	}{
+		{`[{"id":1,"name":"code","type":"string","regex":"[a-"}]`, "errorMsgInvalidRegex"},
+		{`[{"id":1,"name":"amount","type":"number","min":"abc"}]`, "errorMsgInvalidLimit"},
+		{`[{"id":1,"name":"items","type":"array","children":[{"id":2,"name":"a","type":"string"},{"id":3,"name":"a","type":"string"}]}]`, "errorMsgDuplicateparameterField"},
+	}
+	for _, test := range tests {
+		var definition map[string]interface{}
+		if err := json.Unmarshal([]byte(`{"parameters":`+test.parameters+`}`), &definition); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := GenerateSampleData(definition, SampleDataOptions{Seed: 1}); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("GenerateSampleData(%s) error = %v, want %s", test.parameters, err, test.want)
+		}
+	}
+}
+
+func TestGenerateSampleDataValues(t *testing.T) {
+	var definition map[string]interface{}
+	js := `{"parameters":[{"id":1,"name":"größe_nr","type":"string"},{"id":2,"name":"übersicht","type":"string"},
+		{"id":3,"name":"share","type":"number","pattern":"0.0%"},{"id":4,"name":"rate","type":"number","pattern":"#,##0.00"}]}`
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	percentDecimals := false
+	for seed := int64(1); seed <= 20; seed++ {
+		data, err := GenerateSampleData(definition, SampleDataOptions{Seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value, _ := data["größe_nr"].(string); !strings.HasPrefix(value, "GRÖ-") {
+			t.Errorf("größe_nr = %q, want prefix GRÖ-", value)
+		}
+		if value, _ := data["übersicht"].(string); value != "Übersicht 1" {
+			t.Errorf("übersicht = %q, want Übersicht 1", value)
+		}
+		// the percent pattern multiplies the value by 100
+		share, ok := data["share"].(float64)
+		if !ok || share < 0 || share > 1 || math.Abs(share*1000-math.Round(share*1000)) > 1e-9 {
+			t.Errorf("share = %v, want a fraction with 3 decimals", data["share"])
+		} else if math.Abs(share*10-math.Round(share*10)) > 1e-9 {
+			percentDecimals = true
+		}
+		if rate, ok := data["rate"].(float64); !ok || rate < 0 || rate > 25 {
+			t.Errorf("rate = %v, want a number between 0 and 25", data["rate"])
+		}
+	}
+	if !percentDecimals {
+		t.Errorf("share has no decimal places of the percent pattern")
+	}
+}
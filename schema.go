@@ -0,0 +1,186 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns a JSON Schema (draft 2020-12) of the input data of the report. Computed parameters
+// (expressions and aggregates) and internal parameters (page_count, page_number) are not part of the data
+// and therefore excluded. Validation rules of the parameters (required, allowedValues, min, ...) are included.
+// Number parameters accept json numbers and strings formatted with the pattern locale of the report.
+func (self *report) Schema() []byte {
+	schema := self.getObjectSchema(self.getParameterList())
+	schema["$schema"] = jsonSchemaDraft
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// getParameterList returns the report parameters sorted by id, i.e. in the order of the report definition
+func (self *report) getParameterList() []interface{} {
+	parameters := make([]Parameter, 0, len(self.parameters))
+	for _, item := range self.parameters {
+		parameters = append(parameters, item.(Parameter))
+	}
+	sort.Slice(parameters, func(i, j int) bool { return parameters[i].ID < parameters[j].ID })
+	parameterList := make([]interface{}, 0, len(parameters))
+	for _, parameter := range parameters {
+		parameterList = append(parameterList, parameter)
+	}
+	return parameterList
+}
+
+// getObjectSchema returns the schema of an object containing the given parameters as properties
+func (self *report) getObjectSchema(parameters []interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	for _, item := range parameters {
+		parameter := item.(Parameter)
+		if parameter.IsInternal || parameter.Eval || parameter.Type.isAggregate() {
+			continue
+		}
+		properties[parameter.Name] = self.getParameterSchema(parameter)
+		if parameter.Required {
+			required = append(required, parameter.Name)
+		}
+	}
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// getParameterSchema returns the schema of the data of a single parameter
+func (self *report) getParameterSchema(parameter Parameter) map[string]interface{} {
+	var schema map[string]interface{}
+	switch parameter.Type {
+	case ParameterTypeArray:
+		schema = map[string]interface{}{
+			"type":  "array",
+			"items": self.getObjectSchema(parameter.Children),
+		}
+	case ParameterTypeSimpleArray:
+		schema = map[string]interface{}{
+			"type":  "array",
+			"items": self.getValueSchema(parameter, parameter.ArrayItemType),
+		}
+	case ParameterTypeMap:
+		schema = self.getObjectSchema(parameter.Children)
+	default:
+		return self.getValueSchema(parameter, parameter.Type)
+	}
+	if parameter.MinItems > 0 {
+		schema["minItems"] = parameter.MinItems
+	}
+	if parameter.MaxItems > 0 {
+		schema["maxItems"] = parameter.MaxItems
+	}
+	if parameter.Nullable {
+		schema["type"] = []interface{}{schema["type"], "null"}
+	}
+	return schema
+}
+
+// getValueSchema returns the schema of a scalar value, i.e. of a parameter or a simple array item
+func (self *report) getValueSchema(parameter Parameter, parameterType ParameterType) map[string]interface{} {
+	schema := make(map[string]interface{})
+	var valueType string
+	switch parameterType {
+	case ParameterTypeString, ParameterTypeImage:
+		valueType = "string"
+	case ParameterTypeNumber:
+		valueType = "number"
+		// numbers can also be given as strings formatted with the pattern locale of the report, e.g. "1,234.5",
+		// minimum and maximum only apply to json numbers and allowedValues (enum) only accept json numbers
+		schema["pattern"] = getNumberStringPattern(self.documentProperties.PatternLocale)
+	case ParameterTypeBoolean:
+		valueType = "boolean"
+	case ParameterTypeDate:
+		valueType = "string"
+		schema["format"] = "date"
+	case ParameterTypeDatetime:
+		valueType = "string"
+		schema["format"] = "date-time"
+	case ParameterTypeTime:
+		valueType = "string"
+		schema["format"] = "time"
+	default:
+		// unknown types accept any value
+		return schema
+	}
+	valueTypes := []interface{}{valueType}
+	if parameterType == ParameterTypeNumber {
+		valueTypes = append(valueTypes, "string")
+	}
+	if parameter.Nullable {
+		valueTypes = append(valueTypes, "null")
+	}
+	if len(valueTypes) == 1 {
+		schema["type"] = valueType
+	} else {
+		schema["type"] = valueTypes
+	}
+
+	if len(parameter.AllowedValues) > 0 {
+		allowedValues := append(make([]interface{}, 0, len(parameter.AllowedValues)+1), parameter.AllowedValues...)
+		if parameter.Nullable {
+			allowedValues = append(allowedValues, nil)
+		}
+		schema["enum"] = allowedValues
+	}
+	if parameterType == ParameterTypeString {
+		minLength := parameter.MinLength
+		if parameter.Required && minLength == 0 && parameter.Type == ParameterTypeString {
+			// an empty string is treated as missing value
+			minLength = 1
+		}
+		if minLength > 0 {
+			schema["minLength"] = minLength
+		}
+		if parameter.MaxLength > 0 {
+			schema["maxLength"] = parameter.MaxLength
+		}
+		if parameter.Regex != nil {
+			schema["pattern"] = parameter.Regex.String()
+		}
+	} else if parameterType == ParameterTypeNumber {
+		if number, ok := parameter.MinValue.(decimal.Decimal); ok {
+			schema["minimum"] = json.Number(number.String())
+		}
+		if number, ok := parameter.MaxValue.(decimal.Decimal); ok {
+			schema["maximum"] = json.Number(number.String())
+		}
+	}
+	return schema
+}
+
+// getNumberStringPattern returns the regular expression of number strings which are accepted for number
+// parameters, i.e. plain numbers (e.g. "1234.5") and numbers formatted with the separators of the locale
+// (e.g. "1.234,5" for "de") where group separators are followed by 3 digits (see isLocaleNumber)
+func getNumberStringPattern(locale string) string {
+	symbols, _ := getNumberSymbols(locale)
+	decimalSeparator := regexp.QuoteMeta(symbols.Decimal)
+	localeNumber := "[0-9]+"
+	if symbols.Group != "" {
+		groupSeparator := regexp.QuoteMeta(symbols.Group)
+		if symbols.Group == "\u00a0" {
+			// no-break space is often entered as normal space
+			groupSeparator = "[\u00a0 ]"
+		}
+		localeNumber = "[0-9]+(?:(?:" + groupSeparator + "[0-9]{2,3})*" + groupSeparator + "[0-9]{3})?"
+	}
+	plainNumber := `[0-9]+(?:\.[0-9]*)?(?:[eE][+-]?[0-9]+)?|\.[0-9]+`
+	return `^\s*[+-]?(?:` + plainNumber + "|" + localeNumber + "(?:" + decimalSeparator + `[0-9]+)?)\s*$`
+}
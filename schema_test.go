@@ -0,0 +1,179 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+)
+
+// validateJSONSchema validates a decoded json value against the keywords generated by Schema and returns
+// the paths of invalid values, format is only an annotation in draft 2020-12 and not validated
+func validateJSONSchema(schema map[string]interface{}, value interface{}, path string) []string {
+	invalid := []string{path}
+	if types, ok := schema["type"]; ok {
+		typeList, ok := types.([]interface{})
+		if !ok {
+			typeList = []interface{}{types}
+		}
+		matched := false
+		for _, schemaType := range typeList {
+			switch value.(type) {
+			case nil:
+				matched = matched || schemaType == "null"
+			case bool:
+				matched = matched || schemaType == "boolean"
+			case float64:
+				matched = matched || schemaType == "number"
+			case string:
+				matched = matched || schemaType == "string"
+			case []interface{}:
+				matched = matched || schemaType == "array"
+			case map[string]interface{}:
+				matched = matched || schemaType == "object"
+			}
+		}
+		if !matched {
+			return invalid
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			found = found || item == value
+		}
+		if !found {
+			return invalid
+		}
+	}
+	errors := make([]string, 0)
+	switch v := value.(type) {
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if minLength, ok := schema["minLength"].(float64); ok && length < minLength {
+			return invalid
+		}
+		if maxLength, ok := schema["maxLength"].(float64); ok && length > maxLength {
+			return invalid
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(v) {
+			return invalid
+		}
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && v < minimum {
+			return invalid
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && v > maximum {
+			return invalid
+		}
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(v)) < minItems {
+			return invalid
+		}
+		if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(v)) > maxItems {
+			return invalid
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				errors = append(errors, validateJSONSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := v[name.(string)]; !ok {
+					errors = append(errors, getParameterPath(path, name.(string)))
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, item := range v {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				errors = append(errors, validateJSONSchema(property, item, getParameterPath(path, name))...)
+			}
+		}
+	}
+	return errors
+}
+
+func TestSchema(t *testing.T) {
+	const parameters = `[
+		{"id":1,"name":"title","type":"string","required":true,"maxLength":10},
+		{"id":2,"name":"amount","type":"number","min":0,"max":10000},
+		{"id":3,"name":"discount","type":"number","nullable":true},
+		{"id":4,"name":"paid","type":"boolean"},
+		{"id":5,"name":"due","type":"date"},
+		{"id":6,"name":"status","type":"string","allowedValues":["open","paid"]},
+		{"id":7,"name":"tags","type":"simple_array","arrayItemType":"string","maxItems":2},
+		{"id":8,"name":"address","type":"map","children":[{"id":9,"name":"city","type":"string","regex":"[A-Z][a-z]+"}]},
+		{"id":10,"name":"items","type":"array","children":[
+			{"id":11,"name":"name","type":"string","required":true},
+			{"id":12,"name":"price","type":"number"}]},
+		{"id":13,"name":"total","type":"sum","expression":"${items.price}"}]`
+	const document = `{"title":"Invoice","amount":"1,234.5","discount":null,"paid":true,"due":"2024-03-05",
+		"status":"open","tags":["a","b"],"address":{"city":"Vienna"},
+		"items":[{"name":"a","price":1.5},{"name":"b","price":"2"}]}`
+	schemaReport := newTestReport(t, parameters, `,"patternLocale":"en"`, map[string]interface{}{})
+	var schema map[string]interface{}
+	if err := json.Unmarshal(schemaReport.Schema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schema["properties"].(map[string]interface{})["total"]; ok {
+		t.Errorf("schema contains the aggregate parameter total")
+	}
+
+	tests := []struct {
+		name   string
+		update map[string]interface{}
+		want   []string
+	}{
+		{"valid", nil, []string{}},
+		{"plain number string", map[string]interface{}{"amount": "999.5"}, []string{}},
+		{"missing required", map[string]interface{}{"title": nil}, []string{"title"}},
+		{"too long", map[string]interface{}{"title": "Invoice 2024"}, []string{"title"}},
+		{"too large", map[string]interface{}{"amount": 10000.5}, []string{"amount"}},
+		{"number with invalid group", map[string]interface{}{"amount": "1,5"}, []string{"amount"}},
+		{"no number", map[string]interface{}{"discount": "abc"}, []string{"discount"}},
+		{"not allowed", map[string]interface{}{"status": "closed"}, []string{"status"}},
+		{"too many items", map[string]interface{}{"tags": []interface{}{"a", "b", "c"}}, []string{"tags"}},
+		{"regex", map[string]interface{}{"address": map[string]interface{}{"city": "vienna"}}, []string{"address.city"}},
+		{"nested", map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{"name": "a", "price": 1.0}, map[string]interface{}{"price": "1.234,5"}}},
+			[]string{"items[1].name", "items[1].price"}},
+	}
+	for _, test := range tests {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(document), &data); err != nil {
+			t.Fatal(err)
+		}
+		for name, value := range test.update {
+			if value == nil {
+				delete(data, name)
+			} else {
+				data[name] = value
+			}
+		}
+		got := validateJSONSchema(schema, data, "")
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("%s: invalid values = %q, want %q", test.name, got, test.want)
+		}
+		// the report must accept exactly the data which is valid according to the schema
+		report := newTestReport(t, parameters, `,"patternLocale":"en"`, data)
+		if valid := len(report.Errors()) == 0; valid != (len(test.want) == 0) {
+			t.Errorf("%s: report errors = %v, want valid %t", test.name, report.Errors(), len(test.want) == 0)
+		}
+	}
+}
+
+func TestGetNumberStringPattern(t *testing.T) {
+	values := []string{"1234.5", "-1e3", ".5", "1,234.5", "1.234,5", "1,5", "12,34,567.5", "1 234,5", "1\u00a0234,5", "1,2345", "abc", "1.2.3"}
+	for _, locale := range []string{"en", "de", "fr"} {
+		pattern := regexp.MustCompile(getNumberStringPattern(locale))
+		for _, value := range values {
+			if got, want := pattern.MatchString(value), isLocaleNumber(value, locale); got != want {
+				t.Errorf("getNumberStringPattern(%s) matches %q = %t, want %t", locale, value, got, want)
+			}
+		}
+	}
+}
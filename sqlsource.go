@@ -0,0 +1,147 @@
+package reportbro
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SQLQueryer is implemented by *sql.DB, *sql.Tx and *sql.Conn
+type SQLQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SQLRowSource is a RowSource which reads the rows of an array parameter from a database query.
+// The query is executed with the context of the report generation (see GeneratePDFContext) when the
+// first row is needed and the result set is closed once all rows are read or the report is generated.
+// The result columns are mapped to the fields of the array parameter by name (case insensitive) and
+// converted to the field types like any other data.
+//
+// Args contains the names of report parameters whose values are passed as query arguments in the given
+// order, e.g. "customer_id" or "filter.from" for a field of a map parameter. Placeholders in the query
+// depend on the driver (e.g. $1 for postgres, ? for sqlite and mysql).
+type SQLRowSource struct {
+	DB      SQLQueryer
+	Query   string
+	Args    []string
+	rows    *sql.Rows
+	fields  map[string]interface{}
+	columns []string
+	values  []interface{}
+	args    []interface{}
+}
+
+func (self *SQLRowSource) init(db SQLQueryer, query string, args []string) {
+	self.DB = db
+	self.Query = query
+	self.Args = args
+}
+
+// bind gets the query arguments from the report data and executes the query,
+// it is called before the first row is read
+func (self *SQLRowSource) bind(ctx context.Context, parameter Parameter, data map[string]interface{}) error {
+	self.fields = parameter.Fields
+	self.args = make([]interface{}, 0, len(self.Args))
+	for _, name := range self.Args {
+		value, ok := getParameterData(stripParameterName(name), data)
+		if !ok {
+			return fmt.Errorf("query argument %s is not a report parameter", name)
+		}
+		self.args = append(self.args, value)
+	}
+	return self.open(ctx)
+}
+
+// Next implements RowSource
+func (self *SQLRowSource) Next() (map[string]interface{}, bool, error) {
+	if self.rows == nil {
+		return nil, false, fmt.Errorf("query %q was not executed", self.Query)
+	}
+	if !self.rows.Next() {
+		err := self.rows.Err()
+		self.Close()
+		return nil, false, err
+	}
+	scanArgs := make([]interface{}, len(self.values))
+	for i := range self.values {
+		scanArgs[i] = &self.values[i]
+	}
+	if err := self.rows.Scan(scanArgs...); err != nil {
+		self.Close()
+		return nil, false, err
+	}
+	row := make(map[string]interface{}, len(self.columns))
+	for i, column := range self.columns {
+		if column != "" {
+			row[column] = self.values[i]
+		}
+	}
+	return row, true, nil
+}
+
+// Close closes the result set of the query and releases its connection
+func (self *SQLRowSource) Close() error {
+	if self.rows == nil {
+		return nil
+	}
+	return self.rows.Close()
+}
+
+func (self *SQLRowSource) open(ctx context.Context) error {
+	rows, err := self.DB.QueryContext(ctx, self.Query, self.args...)
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return err
+	}
+	self.rows = rows
+	self.columns = columns
+	self.values = make([]interface{}, len(columns))
+	if self.fields != nil {
+		self.mapColumns()
+	}
+	return nil
+}
+
+// mapColumns maps the column names of the result to the field names of the array parameter,
+// columns without matching field are ignored
+func (self *SQLRowSource) mapColumns() {
+	fieldNames := make(map[string]string, len(self.fields))
+	for name := range self.fields {
+		fieldNames[strings.ToLower(name)] = name
+	}
+	for i, column := range self.columns {
+		if _, ok := self.fields[column]; ok {
+			continue
+		}
+		self.columns[i] = fieldNames[strings.ToLower(column)]
+	}
+}
+
+// NewSQLRowSource returns a RowSource for the query, args are the names of the report parameters
+// used as query arguments
+func NewSQLRowSource(db SQLQueryer, query string, args ...string) *SQLRowSource {
+	sqlRowSource := SQLRowSource{}
+	sqlRowSource.init(db, query, args)
+	return &sqlRowSource
+}
+
+// getParameterData returns the value of a parameter from the report data, fields of map
+// parameters are accessed with a dot, e.g. "filter.from"
+func getParameterData(name string, data map[string]interface{}) (interface{}, bool) {
+	var value interface{} = data
+	for _, part := range strings.Split(name, ".") {
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = values[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
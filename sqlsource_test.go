@@ -0,0 +1,136 @@
+package reportbro
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqlTestParameters = `[
+	{"id":1,"name":"customer_id","type":"number"},
+	{"id":2,"name":"orders","type":"array","children":[
+		{"id":3,"name":"number","type":"string"},
+		{"id":4,"name":"amount","type":"number"}]}]`
+
+const sqlTestTable = `{"id":100,"elementType":"table","containerId":"%s","x":0,"y":0,"width":400,"height":40,
+	"dataSource":"${orders}","columns":2,"header":false,"footer":false,"contentDataRows":[{"id":101,"height":20,"columnData":[
+		{"id":102,"content":"${number}","width":200,"height":20,"fontSize":10,"lineSpacing":1},
+		{"id":103,"content":"${amount}","width":200,"height":20,"fontSize":10,"lineSpacing":1}]}]}`
+
+func openTestDB(t *testing.T, orderCount int) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "orders.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec("CREATE TABLE orders (customer_id INTEGER, Number TEXT, AMOUNT REAL, note TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= orderCount; i++ {
+		if _, err := db.Exec("INSERT INTO orders VALUES (?, ?, ?, 'x')", 1+i%2, "A-"+strconv.Itoa(i), float64(i)+0.5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func newSQLTestReport(t *testing.T, tableContainerID string, documentProperties string, data map[string]interface{}) report {
+	t.Helper()
+	var definition map[string]interface{}
+	js := `{"documentProperties":{"pageFormat":"A4","orientation":"portrait","marginLeft":20,"marginTop":20,"marginRight":20,"marginBottom":20` + documentProperties + `},
+		"parameters":` + sqlTestParameters + `,"styles":[],"docElements":[` + fmt.Sprintf(sqlTestTable, tableContainerID) + `],"version":2}`
+	if err := json.Unmarshal([]byte(js), &definition); err != nil {
+		t.Fatal(err)
+	}
+	return NewReport(definition, data, false, "", nil)
+}
+
+func TestSQLRowSourceReadsAllRows(t *testing.T) {
+	db := openTestDB(t, 5)
+	source := NewSQLRowSource(db, "SELECT Number, AMOUNT, note FROM orders WHERE customer_id = ? ORDER BY AMOUNT", "customer_id")
+	report := newSQLTestReport(t, "0_content", "", map[string]interface{}{"customer_id": 2, "orders": source})
+	stream, ok := report.Data["orders"].(*rowStream)
+	if !ok {
+		t.Fatalf("orders = %T, want *rowStream", report.Data["orders"])
+	}
+
+	var rows []map[string]interface{}
+	for {
+		row, ok := stream.next()
+		if !ok {
+			break
+		}
+		rows = append(rows, row)
+	}
+	if stream.err != nil {
+		t.Fatal(stream.err)
+	}
+	want := []map[string]interface{}{
+		{"number": "A-1", "amount": 1.5},
+		{"number": "A-3", "amount": 3.5},
+		{"number": "A-5", "amount": 5.5},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %v", len(rows), len(want), rows)
+	}
+	for i, row := range rows {
+		for name, value := range want[i] {
+			if row[name] != value {
+				t.Errorf("row %d: %s = %#v, want %#v", i, name, row[name], value)
+			}
+		}
+		if _, ok := row["note"]; ok {
+			t.Errorf("row %d: column without parameter was mapped: %v", i, row)
+		}
+	}
+	if inUse := db.Stats().InUse; inUse != 0 {
+		t.Errorf("%d connections in use after all rows were read", inUse)
+	}
+}
+
+func TestSQLRowSourceGeneratePDF(t *testing.T) {
+	db := openTestDB(t, 100)
+	source := NewSQLRowSource(db, "SELECT number, amount FROM orders ORDER BY amount")
+	report := newSQLTestReport(t, "0_content", "", map[string]interface{}{"customer_id": 1, "orders": source})
+	if _, err := report.GeneratePDF(false); err != nil {
+		t.Fatal(err)
+	}
+	if inUse := db.Stats().InUse; inUse != 0 {
+		t.Errorf("%d connections in use after the report was generated", inUse)
+	}
+}
+
+func TestSQLRowSourceClosedOnEarlyStop(t *testing.T) {
+	db := openTestDB(t, 100)
+	source := NewSQLRowSource(db, "SELECT number, amount FROM orders ORDER BY amount")
+	// the page header only has room for a few rows so the table stops reading the stream early
+	report := newSQLTestReport(t, "0_header", `,"header":true,"headerSize":60,"headerDisplay":"always"`,
+		map[string]interface{}{"customer_id": 1, "orders": source})
+	report.GeneratePDF(false)
+	stream := report.Data["orders"].(*rowStream)
+	if stream.rowIndex == 0 || stream.rowIndex >= 100 {
+		t.Fatalf("%d rows were read, want a part of the rows", stream.rowIndex)
+	}
+	if inUse := db.Stats().InUse; inUse != 0 {
+		t.Errorf("%d connections in use after the report was generated", inUse)
+	}
+}
+
+func TestSQLRowSourceCanceled(t *testing.T) {
+	db := openTestDB(t, 5)
+	source := NewSQLRowSource(db, "SELECT number, amount FROM orders")
+	report := newSQLTestReport(t, "0_content", "", map[string]interface{}{"customer_id": 1, "orders": source})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := report.GeneratePDFContext(ctx, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
@@ -1,6 +1,7 @@
 package reportbro
 
 import (
+	"regexp"
 	"strings"
 
 	colorful "github.com/lucasb-eyer/go-colorful"
@@ -53,8 +54,20 @@ type Parameter struct {
 	Eval               bool
 	Nullable           bool
 	Expression         string
+	Filter             string
 	Pattern            string
 	PatternHasCurrency bool
+	Currency           string
+	RoundingMode       RoundingMode
+	Required           bool
+	AllowedValues      []interface{}
+	MinValue           interface{}
+	MaxValue           interface{}
+	MinLength          int
+	MaxLength          int
+	Regex              *regexp.Regexp
+	MinItems           int
+	MaxItems           int
 	IsInternal         bool
 	Children           []interface{}
 	Fields             map[string]interface{}
@@ -76,8 +89,14 @@ func (self *Parameter) init(report *report, data map[string]interface{}) {
 	self.Eval = GetBoolValue(data, "eval")
 	self.Nullable = GetBoolValue(data, "nullable")
 	self.Expression = GetStringValue(data, "expression")
+	self.Filter = GetStringValue(data, "filter")
 	self.Pattern = GetStringValue(data, "pattern")
 	self.PatternHasCurrency = strings.Contains(self.Pattern, "$")
+	self.Currency = GetStringValue(data, "currency")
+	if roundingMode := GetStringValue(data, "roundingMode"); roundingMode != "" {
+		self.RoundingMode = GetRoundingMode(roundingMode)
+	}
+	self.initValidation(data)
 	self.IsInternal = !notIn(self.Name, []string{"page_count", "page_number"})
 	self.Children = make([]interface{}, 0)
 	self.Fields = make(map[string]interface{}, 0)
@@ -129,7 +148,7 @@ func (self *BorderStyle) border() *BorderStyle {
 
 func (self *BorderStyle) init(data map[string]interface{}, keyPrefix string) {
 	self.BorderColor = NewColor(GetStringValue(data, keyPrefix+"borderColor"))
-	self.BorderWidth = float64(GetIntValue(data, keyPrefix+"borderWidth"))
+	self.BorderWidth = GetFloatValue(data, keyPrefix+"borderWidth")
 	self.BorderAll = GetBoolValue(data, keyPrefix+"borderAll")
 	self.BorderLeft = GetBoolValue(data, keyPrefix+"borderLeft")
 	if self.BorderLeft || self.BorderAll {
@@ -164,16 +183,29 @@ type textStyle struct {
 	TextColor           Color
 	BackgroundColor     Color
 	Font                string
+	FallbackFonts       []string
 	FontSize            float64
 	LineSpacing         float64
 	FontStyle           string
 	TextAlign           string
 	HorizontalAlignment HorizontalAlignment
+	StartAlignment      bool
 	VerticalAlignment   VerticalAlignment
 	PaddingLeft         float64
 	PaddingTop          float64
 	PaddingRight        float64
 	PaddingBottom       float64
+	Hyphenate           bool
+	Language            string
+	LetterSpacing       float64
+	FirstLineIndent     float64
+	HangingIndent       float64
+	SpaceBefore         float64
+	SpaceAfter          float64
+	TextDirection       TextDirection
+	// factor of the font size of a text which is shrunk to fit (see TextOverflowShrink), absolute
+	// font sizes of rich text are scaled by the same factor. 0 means the text is not shrunk.
+	fontScale float64
 }
 
 func (self *textStyle) base() *textStyle {
@@ -187,6 +219,8 @@ func (self *textStyle) init(data map[string]interface{}, keyPrefix string) {
 	self.Underline = GetBoolValue(data, keyPrefix+"underline")
 	self.Strikethrough = GetBoolValue(data, keyPrefix+"strikethrough")
 	self.HorizontalAlignment = GetHorizontalAlignment(GetStringValue(data, keyPrefix+"horizontalAlignment"))
+	// text without alignment is aligned at the start of the paragraph, i.e. right for right-to-left text
+	self.StartAlignment = GetStringValue(data, keyPrefix+"horizontalAlignment") == ""
 	self.VerticalAlignment = GetVerticalAlignment(GetStringValue(data, keyPrefix+"verticalAlignment"))
 	self.TextColor = NewColor(GetStringValue(data, keyPrefix+"textColor"))
 	self.BackgroundColor = NewColor(GetStringValue(data, keyPrefix+"backgroundColor"))
@@ -194,15 +228,30 @@ func (self *textStyle) init(data map[string]interface{}, keyPrefix string) {
 	if self.Font == "" {
 		self.Font = "Helvetica"
 	}
-	self.FontSize = float64(GetIntValue(data, keyPrefix+"fontSize"))
+	// fonts which are used in the given order for characters without a glyph in the font
+	self.FallbackFonts = GetStringListValue(data, keyPrefix+"fallbackFonts")
+	self.FontSize = GetFloatValue(data, keyPrefix+"fontSize")
 	if self.FontSize == 0.0 {
 		self.FontSize = 12.0
 	}
 	self.LineSpacing = GetFloatValue(data, keyPrefix+"lineSpacing")
-	self.PaddingLeft = float64(GetIntValue(data, keyPrefix+"paddingLeft"))
-	self.PaddingTop = float64(GetIntValue(data, keyPrefix+"paddingTop"))
-	self.PaddingRight = float64(GetIntValue(data, keyPrefix+"paddingRight"))
-	self.PaddingBottom = float64(GetIntValue(data, keyPrefix+"paddingBottom"))
+	self.PaddingLeft = GetFloatValue(data, keyPrefix+"paddingLeft")
+	self.PaddingTop = GetFloatValue(data, keyPrefix+"paddingTop")
+	self.PaddingRight = GetFloatValue(data, keyPrefix+"paddingRight")
+	self.PaddingBottom = GetFloatValue(data, keyPrefix+"paddingBottom")
+	// words are hyphenated with the patterns of the language, the pattern locale of the document is used if not set
+	self.Hyphenate = GetBoolValue(data, keyPrefix+"hyphenate")
+	self.Language = GetStringValue(data, keyPrefix+"language")
+	// additional space after each character
+	self.LetterSpacing = GetFloatValue(data, keyPrefix+"letterSpacing")
+	// indent of the first line and of the following lines of each paragraph
+	self.FirstLineIndent = GetFloatValue(data, keyPrefix+"firstLineIndent")
+	self.HangingIndent = GetFloatValue(data, keyPrefix+"hangingIndent")
+	// space between paragraphs, not applied at the top of the element or page
+	self.SpaceBefore = GetFloatValue(data, keyPrefix+"spaceBefore")
+	self.SpaceAfter = GetFloatValue(data, keyPrefix+"spaceAfter")
+	// direction of paragraphs, auto uses the direction of the first letter of each paragraph
+	self.TextDirection = GetTextDirection(GetStringValue(data, keyPrefix+"textDirection"))
 	self.FontStyle = ""
 	if self.Bold {
 		self.FontStyle += "B"
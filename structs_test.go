@@ -0,0 +1,47 @@
+package reportbro
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTextStyleInit(t *testing.T) {
+	tests := []struct {
+		data      string
+		keyPrefix string
+		want      textStyle
+	}{
+		{`{}`, "", textStyle{FontSize: 12}},
+		{`{"fontSize":10.5,"paddingLeft":1.5,"paddingTop":0.25,"paddingRight":2.75,"paddingBottom":0.5,"letterSpacing":0.3}`, "",
+			textStyle{FontSize: 10.5, PaddingLeft: 1.5, PaddingTop: 0.25, PaddingRight: 2.75, PaddingBottom: 0.5, LetterSpacing: 0.3}},
+		{`{"fontSize":"10.5","paddingLeft":"1.5","paddingBottom":"0.25"}`, "",
+			textStyle{FontSize: 10.5, PaddingLeft: 1.5, PaddingBottom: 0.25}},
+		{`{"fontSize":9,"cs_fontSize":10.5,"cs_paddingTop":0.75}`, "cs_",
+			textStyle{FontSize: 10.5, PaddingTop: 0.75}},
+		{`{"fontSize":10.5,"paddingLeft":1.5,"paddingTop":0.25,"borderWidth":0.5,"borderLeft":true}`, "",
+			textStyle{FontSize: 10.5, PaddingLeft: 2, PaddingTop: 0.25, BorderStyle: BorderStyle{BorderWidth: 0.5, BorderLeft: true}}},
+	}
+	for _, test := range tests {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(test.data), &data); err != nil {
+			t.Fatal(err)
+		}
+		style := NewTextStyle(data, test.keyPrefix)
+		if style.FontSize != test.want.FontSize {
+			t.Errorf("NewTextStyle(%s, %q).FontSize = %v, want %v", test.data, test.keyPrefix, style.FontSize, test.want.FontSize)
+		}
+		if style.PaddingLeft != test.want.PaddingLeft || style.PaddingTop != test.want.PaddingTop ||
+			style.PaddingRight != test.want.PaddingRight || style.PaddingBottom != test.want.PaddingBottom {
+			t.Errorf("NewTextStyle(%s, %q) padding = %v %v %v %v, want %v %v %v %v", test.data, test.keyPrefix,
+				style.PaddingLeft, style.PaddingTop, style.PaddingRight, style.PaddingBottom,
+				test.want.PaddingLeft, test.want.PaddingTop, test.want.PaddingRight, test.want.PaddingBottom)
+		}
+		if style.LetterSpacing != test.want.LetterSpacing {
+			t.Errorf("NewTextStyle(%s, %q).LetterSpacing = %v, want %v", test.data, test.keyPrefix, style.LetterSpacing, test.want.LetterSpacing)
+		}
+		if style.BorderWidth != test.want.BorderWidth || style.BorderLeft != test.want.BorderLeft {
+			t.Errorf("NewTextStyle(%s, %q) border = %v %v, want %v %v", test.data, test.keyPrefix,
+				style.BorderWidth, style.BorderLeft, test.want.BorderWidth, test.want.BorderLeft)
+		}
+	}
+}
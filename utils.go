@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/buger/jsonparser"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cast"
 )
 
 type DataType int
@@ -96,6 +98,26 @@ func GetStringValue(data map[string]interface{}, key string) string {
 	return ""
 }
 
+// GetStringListValue retrieves a list of strings from an array or a comma separated string, empty items are skipped
+func GetStringListValue(data map[string]interface{}, key string) []string {
+	items := make([]string, 0)
+	switch value := data[key].(type) {
+	case []interface{}:
+		for _, item := range value {
+			if typeItem, typeOk := item.(string); typeOk && strings.TrimSpace(typeItem) != "" {
+				items = append(items, strings.TrimSpace(typeItem))
+			}
+		}
+	case string:
+		for _, item := range strings.Split(value, ",") {
+			if strings.TrimSpace(item) != "" {
+				items = append(items, strings.TrimSpace(item))
+			}
+		}
+	}
+	return items
+}
+
 // GetIntValue retrieves int value if exists, if not returns 0
 func GetIntValue(data map[string]interface{}, key string) int {
 	if value, ok := data[key]; ok {
@@ -207,6 +229,31 @@ func maxFloatSlice(v []float64) float64 {
 	return v[len(v)-1]
 }
 
+// parseFloatValue converts a number or number string to float. Strings are parsed with the separators
+// of the locale if they are grouped correctly (e.g. "1,234.5" for "en"), other strings are parsed like
+// before locales were supported: the last comma is the decimal separator and all other commas are removed.
+func parseFloatValue(value interface{}, locale string) (float64, error) {
+	if d, ok := value.(decimal.Decimal); ok {
+		return d.InexactFloat64(), nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return cast.ToFloat64E(value)
+	}
+	s = strings.TrimSpace(s)
+	if isLocaleNumber(s, locale) {
+		return parseLocaleNumber(s, locale)
+	}
+	if pos := strings.LastIndex(s, ","); pos != -1 {
+		s = strings.Replace(s[:pos], ",", "", -1) + "." + s[pos+1:]
+	}
+	rv, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return rv, nil
+}
+
 // Remove element from array
 func remove(slice []int, s int) []int {
 	return append(slice[:s], slice[s+1:]...)
@@ -0,0 +1,191 @@
+package reportbro
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cast"
+)
+
+// initValidation reads the optional validation rules of the parameter, invalid rules are added to the report errors
+func (self *Parameter) initValidation(data map[string]interface{}) {
+	self.Required = GetBoolValue(data, "required")
+	if allowedValues, ok := data["allowedValues"].([]interface{}); ok {
+		self.AllowedValues = allowedValues
+	}
+	self.MinValue = self.parseLimit(data, "min")
+	self.MaxValue = self.parseLimit(data, "max")
+	self.MinLength = GetIntValue(data, "minLength")
+	self.MaxLength = GetIntValue(data, "maxLength")
+	self.MinItems = GetIntValue(data, "minItems")
+	self.MaxItems = GetIntValue(data, "maxItems")
+	self.Regex = nil
+	if regex := GetStringValue(data, "regex"); regex != "" {
+		// the whole value must match
+		if re, err := regexp.Compile("^(?:" + regex + ")$"); err == nil {
+			self.Regex = re
+		} else {
+			self.report.errors = append(self.report.errors, Error{Message: "errorMsgInvalidRegex", ObjectID: self.ID, Field: "regex", Info: regex})
+		}
+	}
+}
+
+// parseLimit parses the min or max value of a number or date parameter
+func (self *Parameter) parseLimit(data map[string]interface{}, key string) interface{} {
+	value, ok := data[key]
+	if !ok || value == nil || value == "" {
+		return nil
+	}
+	parameterType := self.Type
+	if parameterType == ParameterTypeSimpleArray {
+		parameterType = self.ArrayItemType
+	}
+	if parameterType == ParameterTypeNumber {
+		if number, ok := toDecimal(value); ok {
+			return number
+		}
+	} else if parameterType.isDate() {
+		if date, err := parseDateValue(value, parameterType, self.report.documentProperties.TimeZone); err == nil {
+			return date
+		}
+	}
+	self.report.errors = append(self.report.errors, Error{Message: "errorMsgInvalidLimit", ObjectID: self.ID, Field: key, Info: value})
+	return nil
+}
+
+// getParameterPath returns the path of a parameter used in validation errors, e.g. items[2].amount
+func getParameterPath(parentPath string, name string) string {
+	if parentPath == "" {
+		return name
+	}
+	return parentPath + "." + name
+}
+
+// addValidationError adds a validation error, validation errors prevent the report from being generated
+func (self *report) addValidationError(parameter Parameter, message string, field string, path string, info interface{}) {
+	err := Error{Message: message, ObjectID: parameter.ID, Field: field, Info: info, Path: path, context: parameter.Name}
+	self.errors = append(self.errors, err)
+	self.validationErrors = append(self.validationErrors, err)
+}
+
+// validateParameterValue validates the data of a parameter against its validation rules.
+// rawValue is the value from the input data and value the parsed value.
+func (self *report) validateParameterValue(parameter Parameter, path string, rawValue interface{}, value interface{}) {
+	if rawValue == nil || rawValue == "" {
+		if parameter.Required {
+			self.addValidationError(parameter, "errorMsgValueRequired", "required", path, nil)
+		}
+		// limits are only checked for given values, otherwise required must be set
+		if parameter.Type != ParameterTypeArray && parameter.Type != ParameterTypeSimpleArray {
+			return
+		}
+	}
+
+	switch parameter.Type {
+	case ParameterTypeArray, ParameterTypeSimpleArray:
+		items, _ := value.([]interface{})
+		if parameter.MinItems > 0 && len(items) < parameter.MinItems {
+			self.addValidationError(parameter, "errorMsgTooFewItems", "minItems", path, len(items))
+		}
+		if parameter.MaxItems > 0 && len(items) > parameter.MaxItems {
+			self.addValidationError(parameter, "errorMsgTooManyItems", "maxItems", path, len(items))
+		}
+		if parameter.Type == ParameterTypeSimpleArray {
+			for i, item := range items {
+				if item != nil {
+					self.validateValue(parameter, parameter.ArrayItemType, fmt.Sprintf("%s[%d]", path, i), item)
+				}
+			}
+		}
+	case ParameterTypeString, ParameterTypeNumber, ParameterTypeBoolean, ParameterTypeDate, ParameterTypeDatetime, ParameterTypeTime:
+		if value != nil {
+			self.validateValue(parameter, parameter.Type, path, value)
+		}
+	}
+}
+
+// validateValue validates a single (parsed) value of a parameter or simple array item
+func (self *report) validateValue(parameter Parameter, parameterType ParameterType, path string, value interface{}) {
+	if len(parameter.AllowedValues) > 0 {
+		allowed := false
+		for _, allowedValue := range parameter.AllowedValues {
+			if self.isEqualValue(parameterType, value, allowedValue) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			self.addValidationError(parameter, "errorMsgValueNotAllowed", "allowedValues", path, value)
+		}
+	}
+
+	if parameterType == ParameterTypeString {
+		text := cast.ToString(value)
+		length := utf8.RuneCountInString(text)
+		if parameter.MinLength > 0 && length < parameter.MinLength {
+			self.addValidationError(parameter, "errorMsgValueTooShort", "minLength", path, value)
+		}
+		if parameter.MaxLength > 0 && length > parameter.MaxLength {
+			self.addValidationError(parameter, "errorMsgValueTooLong", "maxLength", path, value)
+		}
+		if parameter.Regex != nil && !parameter.Regex.MatchString(text) {
+			self.addValidationError(parameter, "errorMsgValueInvalidFormat", "regex", path, value)
+		}
+	} else if parameter.MinValue != nil || parameter.MaxValue != nil {
+		if compareLimit(value, parameter.MinValue) < 0 {
+			self.addValidationError(parameter, "errorMsgValueTooSmall", "min", path, value)
+		}
+		if compareLimit(value, parameter.MaxValue) > 0 {
+			self.addValidationError(parameter, "errorMsgValueTooLarge", "max", path, value)
+		}
+	}
+}
+
+// isEqualValue compares a parsed value with an allowed value of the parameter definition
+func (self *report) isEqualValue(parameterType ParameterType, value interface{}, allowedValue interface{}) bool {
+	if parameterType == ParameterTypeNumber {
+		number, ok1 := toDecimal(value)
+		allowedNumber, ok2 := toDecimal(allowedValue)
+		return ok1 && ok2 && number.Equal(allowedNumber)
+	} else if date, ok := value.(time.Time); ok {
+		allowedDate, err := parseDateValue(allowedValue, parameterType, self.documentProperties.TimeZone)
+		return err == nil && date.Equal(allowedDate)
+	} else if parameterType == ParameterTypeBoolean {
+		return cast.ToBool(value) == cast.ToBool(allowedValue)
+	}
+	return cast.ToString(value) == cast.ToString(allowedValue)
+}
+
+// compareLimit compares a number or date with the min/max limit of the parameter, 0 is returned if there is no limit
+func compareLimit(value interface{}, limit interface{}) int {
+	switch limitValue := limit.(type) {
+	case time.Time:
+		if date, ok := value.(time.Time); ok {
+			return date.Compare(limitValue)
+		}
+	case nil:
+		return 0
+	default:
+		number, ok1 := toDecimal(value)
+		limitNumber, ok2 := toDecimal(limitValue)
+		if ok1 && ok2 {
+			return number.Cmp(limitNumber)
+		}
+	}
+	return 0
+}
+
+// getValidationError returns an error containing all validation errors of the report data
+func (self *report) getValidationError() error {
+	if len(self.validationErrors) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(self.validationErrors))
+	for _, err := range self.validationErrors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Errorf("invalid report data: %s", strings.Join(messages, "; "))
+}
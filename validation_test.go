@@ -0,0 +1,101 @@
+package reportbro
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateParameters(t *testing.T) {
+	const parameters = `[
+		{"id":1,"name":"customer","type":"string","required":true,"minLength":2,"maxLength":5},
+		{"id":2,"name":"code","type":"string","regex":"[A-Z]{3}-[0-9]+"},
+		{"id":3,"name":"status","type":"string","nullable":true,"allowedValues":["open","paid"]},
+		{"id":4,"name":"quantity","type":"number","min":1,"max":10},
+		{"id":5,"name":"due","type":"date","min":"2024-01-01","max":"2024-12-31","nullable":true},
+		{"id":6,"name":"tags","type":"simple_array","arrayItemType":"number","allowedValues":[1,2,3],"maxItems":3},
+		{"id":7,"name":"items","type":"array","minItems":1,"children":[
+			{"id":8,"name":"name","type":"string","required":true},
+			{"id":9,"name":"amount","type":"number","min":0,"max":100}]}]`
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"customer": "Anna", "code": "ABC-12", "status": "open", "quantity": 5, "due": "2024-06-30",
+			"tags": []interface{}{1, 3},
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "amount": 0},
+				map[string]interface{}{"name": "b", "amount": "100"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		update func(data map[string]interface{})
+		want   []string
+	}{
+		{"valid", func(data map[string]interface{}) {}, []string{}},
+		{"valid without optional values", func(data map[string]interface{}) {
+			delete(data, "code")
+			data["status"] = nil
+			data["due"] = nil
+		}, []string{}},
+		{"required", func(data map[string]interface{}) { delete(data, "customer") }, []string{"errorMsgValueRequired customer"}},
+		{"required empty string", func(data map[string]interface{}) { data["customer"] = "" }, []string{"errorMsgValueRequired customer"}},
+		{"too short", func(data map[string]interface{}) { data["customer"] = "A" }, []string{"errorMsgValueTooShort customer"}},
+		// the length is counted in characters, not bytes
+		{"max length", func(data map[string]interface{}) { data["customer"] = "Jürgë" }, []string{}},
+		{"too long", func(data map[string]interface{}) { data["customer"] = "Jürgen" }, []string{"errorMsgValueTooLong customer"}},
+		{"regex", func(data map[string]interface{}) { data["code"] = "ABC-12x" }, []string{"errorMsgValueInvalidFormat code"}},
+		{"not allowed", func(data map[string]interface{}) { data["status"] = "closed" }, []string{"errorMsgValueNotAllowed status"}},
+		{"too small", func(data map[string]interface{}) { data["quantity"] = 0.5 }, []string{"errorMsgValueTooSmall quantity"}},
+		{"too large", func(data map[string]interface{}) { data["quantity"] = "11" }, []string{"errorMsgValueTooLarge quantity"}},
+		{"date too small", func(data map[string]interface{}) { data["due"] = "2023-12-31" }, []string{"errorMsgValueTooSmall due"}},
+		{"date too large", func(data map[string]interface{}) { data["due"] = "2025-01-01" }, []string{"errorMsgValueTooLarge due"}},
+		{"simple array item", func(data map[string]interface{}) { data["tags"] = []interface{}{1, 4, 2} },
+			[]string{"errorMsgValueNotAllowed tags[1]"}},
+		{"too many items", func(data map[string]interface{}) { data["tags"] = []interface{}{1, 2, 3, 1} },
+			[]string{"errorMsgTooManyItems tags"}},
+		{"too few items", func(data map[string]interface{}) { data["items"] = []interface{}{} },
+			[]string{"errorMsgTooFewItems items"}},
+		{"nested", func(data map[string]interface{}) {
+			data["items"] = append(data["items"].([]interface{}),
+				map[string]interface{}{"name": "c", "amount": -1},
+				map[string]interface{}{"amount": 101})
+		}, []string{"errorMsgValueTooSmall items[2].amount", "errorMsgValueRequired items[3].name", "errorMsgValueTooLarge items[3].amount"}},
+	}
+	for _, test := range tests {
+		data := valid()
+		test.update(data)
+		report := newTestReport(t, parameters, `,"patternLocale":"en"`, data)
+		got := make([]string, 0)
+		for _, err := range report.validationErrors {
+			got = append(got, fmt.Sprintf("%s %s", err.Message, err.Path))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: validation errors = %q, want %q", test.name, got, test.want)
+		}
+		if _, err := report.GeneratePDF(false); (err != nil) != (len(test.want) > 0) {
+			t.Errorf("%s: GeneratePDF() error = %v", test.name, err)
+		} else if err != nil && !strings.HasPrefix(err.Error(), "invalid report data: ") {
+			t.Errorf("%s: GeneratePDF() error = %v, want invalid report data", test.name, err)
+		}
+	}
+}
+
+func TestValidationRules(t *testing.T) {
+	tests := []struct {
+		parameters string
+		want       string
+	}{
+		{`[{"id":1,"name":"code","type":"string","regex":"(a"}]`, "errorMsgInvalidRegex"},
+		{`[{"id":1,"name":"amount","type":"number","max":"many"}]`, "errorMsgInvalidLimit"},
+		{`[{"id":1,"name":"due","type":"date","min":"tomorrow"}]`, "errorMsgInvalidLimit"},
+	}
+	for _, test := range tests {
+		report := newTestReport(t, test.parameters, `,"patternLocale":"en"`, map[string]interface{}{})
+		if !hasError(report, test.want) {
+			t.Errorf("%s: errors = %v, want %s", test.parameters, report.Errors(), test.want)
+		}
+	}
+}